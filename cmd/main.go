@@ -5,15 +5,16 @@ import (
 	"go-clean-arch/database"
 	"go-clean-arch/pkg/jwt"
 	"go-clean-arch/pkg/server"
+	"go-clean-arch/structure/entity"
 	"log"
+	"os"
 	"time"
 )
 func deleteOldNews(db database.Database) {
 	for {
-		// ตั้งค่าเขตเวลาให้ตรงกับข้อมูลที่จัดเก็บ
-		db.GetDB().Exec("SET time_zone = '+07:00'")
-		
-		result := db.GetDB().Exec("DELETE FROM `news` WHERE created_at < NOW() - INTERVAL 7 DAY")
+		// คำนวณเวลาตัดฝั่งแอปแทน NOW() จึงไม่ขึ้นกับเขตเวลาและชนิดของฐานข้อมูล
+		cutoff := time.Now().AddDate(0, 0, -7)
+		result := db.GetDB().Where("created_at < ?", cutoff).Delete(&entity.News{})
 		if result.Error != nil {
 			log.Printf("Error deleting old news: %v", result.Error)
 		} else {
@@ -26,6 +27,15 @@ func deleteOldNews(db database.Database) {
 
 func main() {
 	cfg := config.LoadConfig()
+
+	// go run ./cmd migrate [up|down|status]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	db := database.SetupDatabase(cfg)
 
 	jwt := jwt.NewJWTService(cfg)
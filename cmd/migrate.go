@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"go-clean-arch/config"
+	"go-clean-arch/database"
+	"strconv"
+)
+
+// คำสั่ง migrate:
+//
+//	go run ./cmd migrate up           รัน migration ที่ยังไม่เคยรันทั้งหมด
+//	go run ./cmd migrate down [steps] ย้อน migration ล่าสุด (ค่าเริ่มต้น 1 รายการ)
+//	go run ./cmd migrate status       แสดงสถานะของ migration ทั้งหมด
+func runMigrate(cfg *config.Config, args []string) error {
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	migrator := database.NewMigrator(db.GetDB())
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", len(rolledBack))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				fmt.Printf("[x] %s (%s)\n", status.ID, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("[ ] %s\n", status.ID)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown migrate command: %s (use up, down or status)", command)
+	}
+	return nil
+}
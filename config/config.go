@@ -11,6 +11,8 @@ import (
 
 // Config struct สำหรับการเก็บค่าคอนฟิก
 type Config struct {
+	// ฐานข้อมูลที่ใช้: mysql (ค่าเริ่มต้น) หรือ postgres
+	DBDriver   string
 	DSN        string
 	JWTSecret  string
 	ServerPort int
@@ -18,6 +20,31 @@ type Config struct {
 		Email    string
 		Password string
 	}
+	Mail struct {
+		Driver string
+		Dir    string
+	}
+	ResetPasswordURL string
+	VerifyURL        string
+	Storage          struct {
+		Driver    string
+		Dir       string
+		PublicURL string
+		Endpoint  string
+		Region    string
+		Bucket    string
+		AccessKey string
+		SecretKey string
+		PathStyle bool
+	}
+	Scanner struct {
+		Driver string
+		Addr   string
+	}
+	Evidence struct {
+		MaxPages    int
+		MaxPageSide float64
+	}
 }
 
 // LoadConfig โหลดค่าคอนฟิกจากไฟล์ .env
@@ -27,15 +54,12 @@ func LoadConfig() *Config {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	// สร้าง DSN สำหรับการเชื่อมต่อฐานข้อมูล MySQL
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		getEnv("DB_USER", ""),          // ดึงค่า DB_USER จากไฟล์ .env
-		getEnv("DB_PASSWORD", ""),      // ดึงค่า DB_PASSWORD จากไฟล์ .env
-		getEnv("DB_HOST", "localhost"), // ค่าพื้นฐานถ้าไม่ได้ระบุ
-		getEnv("DB_PORT", "3306"),      // ค่าพื้นฐานถ้าไม่ได้ระบุ
-		getEnv("DB_NAME", ""),          // ดึงค่า DB_NAME จากไฟล์ .env
-	)
+	// สร้าง DSN สำหรับการเชื่อมต่อฐานข้อมูลตาม DB_DRIVER
+	dbDriver := getEnv("DB_DRIVER", "mysql")
+	dsn, err := buildDSN(dbDriver)
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
 
 	// ดึงค่า JWT_SECRET จากไฟล์ .env
 	jwtSecret := getEnv("JWT_SECRET", "")
@@ -52,7 +76,8 @@ func LoadConfig() *Config {
 	}
 
 	// คืนค่า Config struct ที่มีค่าคอนฟิกทั้งหมด
-	return &Config{
+	cfg := &Config{
+		DBDriver:   dbDriver,
 		DSN:        dsn,
 		JWTSecret:  jwtSecret,
 		ServerPort: serverPort,
@@ -63,6 +88,70 @@ func LoadConfig() *Config {
 			Email:    getEnv("USER", ""),
 			Password: getEnv("PASSWORD", ""),
 		},
+		ResetPasswordURL: getEnv("RESET_PASSWORD_URL", "http://localhost:3000/reset-password"),
+		VerifyURL:        getEnv("VERIFY_URL", "http://localhost:8080/verify"),
+	}
+
+	// การส่งอีเมล: log (ค่าเริ่มต้น) หรือ file
+	cfg.Mail.Driver = getEnv("MAIL_DRIVER", "log")
+	cfg.Mail.Dir = getEnv("MAIL_DIR", "./mails")
+
+	// ที่เก็บไฟล์หลักฐาน: local (ค่าเริ่มต้น) หรือ s3 (S3/MinIO ใช้ร่วมกันได้หลาย instance)
+	cfg.Storage.Driver = getEnv("STORAGE_DRIVER", "local")
+	cfg.Storage.Dir = getEnv("STORAGE_DIR", "./uploads")
+	cfg.Storage.PublicURL = getEnv("STORAGE_PUBLIC_URL", fmt.Sprintf("http://localhost:%d", serverPort))
+	cfg.Storage.Endpoint = getEnv("S3_ENDPOINT", "")
+	cfg.Storage.Region = getEnv("S3_REGION", "us-east-1")
+	cfg.Storage.Bucket = getEnv("S3_BUCKET", "")
+	cfg.Storage.AccessKey = getEnv("S3_ACCESS_KEY", "")
+	cfg.Storage.SecretKey = getEnv("S3_SECRET_KEY", "")
+	// MinIO ใช้ path-style (http://host/bucket/key) เป็นค่าเริ่มต้น
+	cfg.Storage.PathStyle = getEnv("S3_PATH_STYLE", "true") == "true"
+
+	// สแกนไวรัสไฟล์หลักฐาน: none (ค่าเริ่มต้น) หรือ clamd
+	cfg.Scanner.Driver = getEnv("SCANNER_DRIVER", "none")
+	cfg.Scanner.Addr = getEnv("CLAMD_ADDR", "tcp://localhost:3310")
+
+	// ข้อจำกัดของไฟล์ PDF หลักฐาน (ขนาดหน้าเป็น point, 1684 = ด้านยาวของ A2)
+	cfg.Evidence.MaxPages, err = strconv.Atoi(getEnv("EVIDENCE_MAX_PAGES", "20"))
+	if err != nil {
+		cfg.Evidence.MaxPages = 20
+		log.Printf("Invalid EVIDENCE_MAX_PAGES value, using default: %d", cfg.Evidence.MaxPages)
+	}
+	cfg.Evidence.MaxPageSide, err = strconv.ParseFloat(getEnv("EVIDENCE_MAX_PAGE_SIZE", "1684"), 64)
+	if err != nil {
+		cfg.Evidence.MaxPageSide = 1684
+		log.Printf("Invalid EVIDENCE_MAX_PAGE_SIZE value, using default: %.0f", cfg.Evidence.MaxPageSide)
+	}
+
+	return cfg
+}
+
+// สร้าง DSN ตามชนิดฐานข้อมูล (port เริ่มต้น MySQL 3306, PostgreSQL 5432)
+func buildDSN(driver string) (string, error) {
+	switch driver {
+	case "mysql":
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			getEnv("DB_USER", ""),          // ดึงค่า DB_USER จากไฟล์ .env
+			getEnv("DB_PASSWORD", ""),      // ดึงค่า DB_PASSWORD จากไฟล์ .env
+			getEnv("DB_HOST", "localhost"), // ค่าพื้นฐานถ้าไม่ได้ระบุ
+			getEnv("DB_PORT", "3306"),      // ค่าพื้นฐานถ้าไม่ได้ระบุ
+			getEnv("DB_NAME", ""),          // ดึงค่า DB_NAME จากไฟล์ .env
+		), nil
+	case "postgres":
+		return fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+			getEnv("DB_HOST", "localhost"),
+			getEnv("DB_USER", ""),
+			getEnv("DB_PASSWORD", ""),
+			getEnv("DB_NAME", ""),
+			getEnv("DB_PORT", "5432"),
+			getEnv("DB_SSLMODE", "disable"),
+			getEnv("DB_TIMEZONE", "Asia/Bangkok"),
+		), nil
+	default:
+		return "", fmt.Errorf("unsupported DB_DRIVER: %s (use mysql or postgres)", driver)
 	}
 }
 
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"go-clean-arch/structure/request"
+	"go-clean-arch/usecase"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	auditUsecase usecase.AuditUsecase
+}
+
+func NewAuditController(auditUsecase usecase.AuditUsecase) *AuditController {
+	return &AuditController{auditUsecase: auditUsecase}
+}
+
+// สถานะของ error จาก audit usecase: ตัวกรองผิดรูปแบบเป็น 400 นอกนั้นเป็น 500
+func auditErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "must not") || strings.Contains(err.Error(), "too many") {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// ค้นหา audit log เช่น /audit-logs?action=user.update_role&date_from=2024-01-01&page=2
+func (c *AuditController) GetAuditLogs(ctx *fiber.Ctx) error {
+	var filter request.AuditFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid query parameters",
+		})
+	}
+
+	page, err := c.auditUsecase.GetAuditLogs(filter)
+	if err != nil {
+		return ctx.Status(auditErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(page)
+}
+
+// ส่งออก audit log ตามตัวกรองเดียวกับ GetAuditLogs (?format=csv|xlsx)
+func (c *AuditController) ExportAuditLogs(ctx *fiber.Ctx) error {
+	var filter request.AuditFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid query parameters",
+		})
+	}
+
+	table, err := c.auditUsecase.ExportAuditLogs(filter)
+	if err != nil {
+		return ctx.Status(auditErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return sendExport(ctx, table)
+}
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"go-clean-arch/pkg/scanner"
 	"go-clean-arch/pkg/utility"
+	"go-clean-arch/pkg/utility/filesystem"
+	"mime/multipart"
 	"strconv"
+	"strings"
 
 	// "go-clean-arch/structure/entity"
 	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
 	"go-clean-arch/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -49,11 +55,26 @@ func (c *EventController) CreateEvent(ctx *fiber.Ctx) error {
 
 }
 
+// รองรับ ?page=&limit=&school_year=&branch=&year=&creator=&date_from=&date_to=&status=&search=&sort=&order=
+func parseEventFilter(ctx *fiber.Ctx) (request.EventFilter, error) {
+	var filter request.EventFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return filter, fmt.Errorf("invalid query parameters")
+	}
+	return filter, nil
+}
+
 func (c *EventController) GetAllEvent(ctx *fiber.Ctx) error {
-	events, err := c.eventUsecase.GetAllEvent()
+	filter, err := parseEventFilter(ctx)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Unable to retrieve events",
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	events, err := c.eventUsecase.GetAllEvent(filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(events)
@@ -187,23 +208,57 @@ func (c *EventController) MyEvent(ctx *fiber.Ctx) error {
 }
 
 func (c *EventController) AllAllowedEvent(ctx *fiber.Ctx) error {
-	events, err := c.eventUsecase.AllAllowedEvent()
+	filter, err := parseEventFilter(ctx)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Unable to retrieve events",
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	events, err := c.eventUsecase.AllAllowedEvent(filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(events)
 }
 func (c *EventController) AllCurrentEvent(ctx *fiber.Ctx) error {
-	events, err := c.eventUsecase.AllCurrentEvent()
+	filter, err := parseEventFilter(ctx)
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Unable to retrieve events",
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	events, err := c.eventUsecase.AllCurrentEvent(filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(events)
+}
+func (c *EventController) EligibleEvents(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	filter, err := parseEventFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	events, err := c.eventUsecase.EligibleEvents(claims, filter)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(events)
 }
+
 func (c *EventController) MyEventThisYear(ctx *fiber.Ctx) error {
 	yearStr := ctx.Params("year")
 	yearInt, err := strconv.Atoi(yearStr)
@@ -223,7 +278,7 @@ func (c *EventController) MyEventThisYear(ctx *fiber.Ctx) error {
 	}
 	userID := uint(userIDFloat)
 
-	insideEvents, outsideEvents,dones, err := c.eventUsecase.MyEventThisYear(userID, year)
+	insideEvents, outsideEvents,dones, evaluation, err := c.eventUsecase.MyEventThisYear(userID, year)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -235,6 +290,7 @@ func (c *EventController) MyEventThisYear(ctx *fiber.Ctx) error {
 		"inside_events":  insideEvents,
 		"outside_events": outsideEvents,
 		"dones":dones,
+		"rule": evaluation,
 	})
 }
 
@@ -256,7 +312,14 @@ func (c *EventController) AllSendEventThisYear(ctx *fiber.Ctx) error {
 	}
 	userID := uint(userInt)
 
-	insideEvents, outsideEvents, err := c.eventUsecase.SendEventThisYear(userID, year)
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	insideEvents, outsideEvents, err := c.eventUsecase.SendEventThisYear(userID, year, claims)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -326,6 +389,81 @@ func (c *EventController) UnJoinEvent(ctx *fiber.Ctx) error {
 	})
 }
 
+func (c *EventController) JoinWaitlist(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	eventID := uint(id)
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	position, err := c.eventUsecase.JoinWaitlist(eventID, claims)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Joined waitlist successfully",
+		"position": position,
+	})
+}
+
+func (c *EventController) LeaveWaitlist(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	eventID := uint(id)
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	if err := c.eventUsecase.LeaveWaitlist(eventID, claims); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Left waitlist successfully",
+	})
+}
+
+func (c *EventController) MyWaitlist(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	waitlist, err := c.eventUsecase.MyWaitlist(claims)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(waitlist)
+}
+
 func (c *EventController) UploadFile(ctx *fiber.Ctx) error {
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
@@ -343,15 +481,15 @@ func (c *EventController) UploadFile(ctx *fiber.Ctx) error {
 		})
 	}
 
-	file, err := ctx.FormFile("file")
+	files, err := evidenceFiles(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "failed to get file",
 		})
 	}
 
-	if err := c.eventUsecase.UploadFile(eventID, claims,file); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if err := c.eventUsecase.UploadFile(eventID, claims, files); err != nil {
+		return ctx.Status(uploadErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -380,16 +518,88 @@ func (c *EventController) GetFile(ctx *fiber.Ctx) error {
 	}
 	userID := uint(idInt)
 
-	filePath, err := c.eventUsecase.GetFile(eventID, userID)
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	file, err := c.eventUsecase.GetFile(eventID, userID, claims)
 	if err != nil {
-		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		return ctx.Status(evidenceErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
-	return ctx.SendFile(filePath, false)
+	ctx.Type("pdf")
+	return ctx.SendStream(file)
 
 }
 
+// ลิงก์ดาวน์โหลดชั่วคราว ใช้เปิดไฟล์ในแท็บใหม่โดยไม่ต้องแนบ token
+func (c *EventController) GetFileURL(ctx *fiber.Ctx) error {
+	eventID, err := strconv.Atoi(ctx.Params("eventid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	userID, err := strconv.Atoi(ctx.Params("userid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	url, err := c.eventUsecase.FileURL(uint(eventID), uint(userID), claims)
+	if err != nil {
+		return ctx.Status(evidenceErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"url":        url,
+		"expires_in": int(usecase.EvidenceURLExpiry.Seconds()),
+	})
+}
+
+// ไฟล์หลักฐานในฟิลด์ "file" ส่งได้หลายไฟล์เมื่อเป็นรูปถ่าย
+func evidenceFiles(ctx *fiber.Ctx) ([]*multipart.FileHeader, error) {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return nil, fmt.Errorf("file is required")
+	}
+	return files, nil
+}
+
+// ไฟล์ที่ไม่ผ่านการตรวจเนื้อไฟล์หรือการสแกนไวรัสตอบ 422
+func uploadErrorStatus(err error) int {
+	if errors.Is(err, filesystem.ErrInvalidPDF) || errors.Is(err, filesystem.ErrInvalidImage) ||
+		errors.Is(err, scanner.ErrInfected) {
+		return fiber.StatusUnprocessableEntity
+	}
+	return fiber.StatusInternalServerError
+}
+
+// สิทธิ์ไม่พอตอบ 403 นอกนั้นถือว่าไม่พบไฟล์
+func evidenceErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "permission") {
+		return fiber.StatusForbidden
+	}
+	return fiber.StatusNotFound
+}
+
 func (c *EventController) MyChecklist(ctx *fiber.Ctx) error {
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
@@ -409,14 +619,123 @@ func (c *EventController) MyChecklist(ctx *fiber.Ctx) error {
 	
 	checklist, err := c.eventUsecase.MyChecklist(eventID, claims)
 	if err != nil {
-		return err
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case strings.Contains(err.Error(), "permission"):
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(checklist)
 }
 
+// เขียนตารางลง response ตาม ?format=csv (ค่าเริ่มต้น) หรือ xlsx
+func sendExport(ctx *fiber.Ctx, table *response.ExportTable) error {
+	format := ctx.Query("format", "csv")
+	switch format {
+	case "csv":
+		ctx.Set("Content-Type", "text/csv; charset=utf-8")
+	case "xlsx":
+		ctx.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be csv or xlsx",
+		})
+	}
+	ctx.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", table.Name, format))
+
+	if format == "xlsx" {
+		return filesystem.WriteXLSX(ctx, *table)
+	}
+	return filesystem.WriteCSV(ctx, *table)
+}
+
+func (c *EventController) ExportChecklist(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Failed to get user claims",
+		})
+	}
+
+	table, err := c.eventUsecase.ExportChecklist(uint(id), claims)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case strings.Contains(err.Error(), "permission"):
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return sendExport(ctx, table)
+}
+
+// ผลส่งตรวจทั้งคณะในปีการศึกษา
+func (c *EventController) ExportFacultyDones(ctx *fiber.Ctx) error {
+	facultyID, err := strconv.Atoi(ctx.Params("facultyid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid faculty id format",
+		})
+	}
+	year, err := strconv.Atoi(ctx.Params("year"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid year",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Failed to get user claims",
+		})
+	}
+
+	table, err := c.eventUsecase.ExportFacultyDones(uint(facultyID), uint(year), claims)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case strings.Contains(err.Error(), "permission"):
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return sendExport(ctx, table)
+}
+
+// ตรวจผู้เข้าร่วมหนึ่งคน body: {"state": "approved|rejected|resubmission_requested", "comment"}
 func (c *EventController) UpdateEventStatusAndComment(ctx *fiber.Ctx) error {
 	var req struct {
-		Status  bool   `json:"status"`
+		State   string `json:"state"`
 		Comment string `json:"comment"`
 	}
 	idStr := ctx.Params("eventid")
@@ -437,14 +756,36 @@ func (c *EventController) UpdateEventStatusAndComment(ctx *fiber.Ctx) error {
 	}
 	userID := uint(idInt)
 
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
 	if err := ctx.BodyParser(&req); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request payload",
 		})
 	}
 
-	if err := c.eventUsecase.UpdateEventStatusAndComment(eventID, userID, req.Status, req.Comment); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if err := c.eventUsecase.UpdateEventStatusAndComment(eventID, userID, claims, req.State, req.Comment); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "permission") {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "failed") {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -454,6 +795,121 @@ func (c *EventController) UpdateEventStatusAndComment(ctx *fiber.Ctx) error {
 	})
 }
 
+// ตรวจผู้เข้าร่วมหลายคนพร้อมกัน body: {"items": [{"user_id", "state", "comment"}]}
+func (c *EventController) ReviewParticipants(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("eventid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	var req request.BatchReviewRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request payload",
+		})
+	}
+
+	outcomes, err := c.eventUsecase.ReviewParticipants(uint(id), claims, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "failed") {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	updated := 0
+	for _, outcome := range outcomes {
+		if outcome.Updated {
+			updated++
+		}
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"updated": updated,
+		"results": outcomes,
+	})
+}
+
+func (c *EventController) CreateCheckinQR(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	eventID := uint(id)
+
+	minutes := ctx.QueryInt("minutes", 0)
+	if minutes < 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid minutes",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	png, err := c.eventUsecase.CreateCheckinQR(eventID, claims, uint(minutes))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	ctx.Set("Content-Type", "image/png")
+	ctx.Set("Cache-Control", "no-store")
+	return ctx.Send(png)
+}
+
+func (c *EventController) CheckIn(ctx *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := ctx.BodyParser(&req); err != nil || req.Token == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request payload",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	if err := c.eventUsecase.CheckIn(req.Token, claims); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Checked in successfully",
+	})
+}
 
 // Outside
 func (c *EventController) CreateEventOutside(ctx *fiber.Ctx) error{
@@ -482,6 +938,12 @@ func (c *EventController) CreateEventOutside(ctx *fiber.Ctx) error{
 }
 
 func (c *EventController) DeleteEventOutsideByID(ctx *fiber.Ctx) error{
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -490,7 +952,7 @@ func (c *EventController) DeleteEventOutsideByID(ctx *fiber.Ctx) error{
 		})
 	}
 	eventID := uint(id)
-	if err := c.eventUsecase.DeleteEventOutsideByID(eventID); err != nil {
+	if err := c.eventUsecase.DeleteEventOutsideByID(eventID, claims); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -511,8 +973,20 @@ func (c *EventController) CreateFile(ctx *fiber.Ctx) error{
 	}
 	eventID := uint(id)
 
-	data, fileName, err := c.eventUsecase.CreateFile(eventID)
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	data, fileName, err := c.eventUsecase.CreateFile(eventID, claims)
 	if err != nil {
+		if strings.Contains(err.Error(), "permission") {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error: %v", err))
 	}
 
@@ -523,6 +997,95 @@ func (c *EventController) CreateFile(ctx *fiber.Ctx) error{
 	return ctx.Send(data)
 }
 
+// ตรวจสอบแบบฟอร์มจาก QR code (ไม่ต้องเข้าสู่ระบบ)
+func (c *EventController) VerifyForm(ctx *fiber.Ctx) error {
+	result, err := c.eventUsecase.VerifyForm(ctx.Params("serial"), ctx.Query("sig"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "invalid signature") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"valid": false,
+				"error": "document could not be verified",
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"valid": true,
+		"form":  result,
+	})
+}
+
+func (c *EventController) sendTranscript(ctx *fiber.Ctx, userID uint, year uint, claims map[string]interface{}) error {
+	data, fileName, err := c.eventUsecase.CreateTranscript(userID, year, claims)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case strings.Contains(err.Error(), "permission"):
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	ctx.Set("Content-Type", "application/pdf")
+	ctx.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	return ctx.Send(data)
+}
+
+// ใบรายงานผลกิจกรรมของตัวเอง
+func (c *EventController) MyTranscript(ctx *fiber.Ctx) error {
+	yearInt, err := strconv.Atoi(ctx.Params("year"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid year",
+		})
+	}
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid user_id in claims",
+		})
+	}
+	return c.sendTranscript(ctx, uint(userIDFloat), uint(yearInt), claims)
+}
+
+// ใบรายงานผลกิจกรรมของนักศึกษา (สำหรับอาจารย์)
+func (c *EventController) StudentTranscript(ctx *fiber.Ctx) error {
+	yearInt, err := strconv.Atoi(ctx.Params("year"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid year",
+		})
+	}
+	userInt, err := strconv.Atoi(ctx.Params("userid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid UserID",
+		})
+	}
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	return c.sendTranscript(ctx, uint(userInt), uint(yearInt), claims)
+}
+
 func (c *EventController) UploadFileOutside(ctx *fiber.Ctx) error {
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
@@ -540,15 +1103,15 @@ func (c *EventController) UploadFileOutside(ctx *fiber.Ctx) error {
 		})
 	}
 
-	file, err := ctx.FormFile("file")
+	files, err := evidenceFiles(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "failed to get file",
 		})
 	}
 
-	if err := c.eventUsecase.UploadFileOutside(eventID, claims,file); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if err := c.eventUsecase.UploadFileOutside(eventID, claims, files); err != nil {
+		return ctx.Status(uploadErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -577,12 +1140,120 @@ func (c *EventController) GetFileOutside(ctx *fiber.Ctx) error {
 	}
 	userID := uint(idInt)
 
-	filePath, err := c.eventUsecase.GetFileOutside(eventID, userID)
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	file, err := c.eventUsecase.GetFileOutside(eventID, userID, claims)
+	if err != nil {
+		return ctx.Status(evidenceErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	ctx.Type("pdf")
+	return ctx.SendStream(file)
+
+}
+
+// ลิงก์ดาวน์โหลดชั่วคราว ใช้เปิดไฟล์ในแท็บใหม่โดยไม่ต้องแนบ token
+func (c *EventController) GetFileOutsideURL(ctx *fiber.Ctx) error {
+	eventID, err := strconv.Atoi(ctx.Params("eventid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	userID, err := strconv.Atoi(ctx.Params("userid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
 	if err != nil {
-		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	url, err := c.eventUsecase.FileOutsideURL(uint(eventID), uint(userID), claims)
+	if err != nil {
+		return ctx.Status(evidenceErrorStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
-	return ctx.SendFile(filePath, false)
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"url":        url,
+		"expires_in": int(usecase.EvidenceURLExpiry.Seconds()),
+	})
+}
+
+// กิจกรรมภายนอกที่รอผู้ดูแลคณะตรวจ
+func (c *EventController) OutsideReviewQueue(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	queue, err := c.eventUsecase.OutsideReviewQueue(claims)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(queue)
+}
 
-}
\ No newline at end of file
+// ตรวจกิจกรรมภายนอก body: {"state": "approved|rejected|resubmission_requested", "comment"}
+func (c *EventController) ReviewOutside(ctx *fiber.Ctx) error {
+	var req struct {
+		State   string `json:"state"`
+		Comment string `json:"comment"`
+	}
+	id, err := strconv.Atoi(ctx.Params("eventid"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request payload",
+		})
+	}
+
+	if err := c.eventUsecase.ReviewOutside(uint(id), claims, req.State, req.Comment); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "not the certifier") {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "failed") {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Checking successfully",
+	})
+}
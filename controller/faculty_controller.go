@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"go-clean-arch/pkg/utility"
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/usecase"
 	"strconv"
@@ -19,6 +20,12 @@ func NewFacultyController(facultyUsecase usecase.FacultyBranchUsecase) *FacultyB
 }
 
 func (c *FacultyBranchController) CreateFaculty(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	var req entity.Faculty
 
 	if err := ctx.BodyParser(&req); err != nil {
@@ -27,7 +34,7 @@ func (c *FacultyBranchController) CreateFaculty(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.facultyUsecase.CreateFaculty(&req); err != nil {
+	if err := c.facultyUsecase.CreateFaculty(&req, claims); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -51,6 +58,12 @@ func (c *FacultyBranchController) GetAllFaculties(ctx *fiber.Ctx) error {
 }
 
 func (c *FacultyBranchController) UpdateFacultyByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -74,7 +87,7 @@ func (c *FacultyBranchController) UpdateFacultyByID(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.facultyUsecase.UpdateFacultyByID(&req); err != nil {
+	if err := c.facultyUsecase.UpdateFacultyByID(&req, claims); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": fmt.Sprintf("faculty with ID %d not found", facultyID),
@@ -92,6 +105,12 @@ func (c *FacultyBranchController) UpdateFacultyByID(ctx *fiber.Ctx) error {
 }
 
 func (c *FacultyBranchController) DeleteFacultyByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -100,7 +119,7 @@ func (c *FacultyBranchController) DeleteFacultyByID(ctx *fiber.Ctx) error {
 		})
 	}
 	facultyID := uint(id)
-	if err := c.facultyUsecase.DeleteFacultyByID(facultyID); err != nil {
+	if err := c.facultyUsecase.DeleteFacultyByID(facultyID, claims); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": fmt.Sprintf("faculty with ID %d not found", facultyID),
@@ -158,6 +177,12 @@ func (c *FacultyBranchController) DeleteFacultyByID(ctx *fiber.Ctx) error {
 
 // branch ------------------------------------------------------------------
 func (c *FacultyBranchController) CreateBranch(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	var req entity.Branch
 
 	if err := ctx.BodyParser(&req); err != nil {
@@ -166,7 +191,7 @@ func (c *FacultyBranchController) CreateBranch(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.facultyUsecase.CreateBranch(&req); err != nil {
+	if err := c.facultyUsecase.CreateBranch(&req, claims); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -193,6 +218,12 @@ func (c *FacultyBranchController) GetAllBranches(ctx *fiber.Ctx) error {
 }
 
 func (c *FacultyBranchController) UpdateBranchByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -216,7 +247,7 @@ func (c *FacultyBranchController) UpdateBranchByID(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.facultyUsecase.UpdateBranchByID(&req); err != nil {
+	if err := c.facultyUsecase.UpdateBranchByID(&req, claims); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": fmt.Sprintf("branch with ID %d not found", branchID),
@@ -234,6 +265,12 @@ func (c *FacultyBranchController) UpdateBranchByID(ctx *fiber.Ctx) error {
 }
 
 func (c *FacultyBranchController) DeleteBranchByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	idStr := ctx.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -243,7 +280,7 @@ func (c *FacultyBranchController) DeleteBranchByID(ctx *fiber.Ctx) error {
 	}
 	branchID := uint(id)
 
-	if err := c.facultyUsecase.DeleteBranchByID(branchID); err != nil {
+	if err := c.facultyUsecase.DeleteBranchByID(branchID, claims); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": fmt.Sprintf("branch with ID %d not found", branchID),
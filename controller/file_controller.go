@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"errors"
+	"go-clean-arch/pkg/storage"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileController struct {
+	local *storage.LocalStorage
+}
+
+func NewFileController(local *storage.LocalStorage) *FileController {
+	return &FileController{local: local}
+}
+
+// ดาวน์โหลดไฟล์จากลิงก์ที่ LocalStorage.SignedURL สร้าง (ไม่ต้องเข้าสู่ระบบ)
+func (c *FileController) ServeSigned(ctx *fiber.Ctx) error {
+	key := ctx.Params("*")
+	if err := c.local.Verify(key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	file, err := c.local.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to open file",
+		})
+	}
+	ctx.Type(strings.TrimPrefix(filepath.Ext(key), "."))
+	return ctx.SendStream(file)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RuleController struct {
+	ruleUsecase usecase.RuleUsecase
+}
+
+func NewRuleController(ruleUsecase usecase.RuleUsecase) *RuleController {
+	return &RuleController{ruleUsecase: ruleUsecase}
+}
+
+func (c *RuleController) CreateRule(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	var req request.RuleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "bad request",
+		})
+	}
+
+	if err := c.ruleUsecase.CreateRule(&req, claims); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "rule created successfully",
+	})
+}
+
+func (c *RuleController) GetAllRules(ctx *fiber.Ctx) error {
+	rules, err := c.ruleUsecase.GetAllRules()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Unable to retrieve rules",
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(rules)
+}
+
+func (c *RuleController) UpdateRuleByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	idStr := ctx.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	ruleID := uint(id)
+
+	var req request.RuleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := c.ruleUsecase.UpdateRuleByID(ruleID, &req, claims); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("rule with ID %d not found", ruleID),
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": fmt.Sprintf("rule with ID %d updated successfully", ruleID),
+	})
+}
+
+func (c *RuleController) DeleteRuleByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	idStr := ctx.Params("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	ruleID := uint(id)
+
+	if err := c.ruleUsecase.DeleteRuleByID(ruleID, claims); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("rule with ID %d not found", ruleID),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("failed to delete rule with ID %d : %s", ruleID, err),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": fmt.Sprintf("rule with ID %d deleted successfully", ruleID),
+	})
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/usecase"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatsController struct {
+	statsUsecase usecase.StatsUsecase
+}
+
+func NewStatsController(statsUsecase usecase.StatsUsecase) *StatsController {
+	return &StatsController{statsUsecase: statsUsecase}
+}
+
+// สถิติรายคณะ/สาขา/ชั้นปี เช่น /stats?school_year=2567&group_by=year
+func (c *StatsController) GetStats(ctx *fiber.Ctx) error {
+	var filter request.StatsFilter
+	if err := ctx.QueryParser(&filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid query parameters",
+		})
+	}
+
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	stats, err := c.statsUsecase.GetStats(filter, claims)
+	if err != nil {
+		if strings.Contains(err.Error(), "permission") {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "group_by") {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(stats)
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/usecase"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TemplateController struct {
+	templateUsecase usecase.TemplateUsecase
+}
+
+func NewTemplateController(templateUsecase usecase.TemplateUsecase) *TemplateController {
+	return &TemplateController{templateUsecase: templateUsecase}
+}
+
+// อัปโหลดแม่แบบ: multipart ฟิลด์ file (JSON), name และ school_year (ไม่ระบุ = ใช้กับทุกปี)
+func (c *TemplateController) UploadTemplate(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	var schoolYear *uint
+	if yearStr := ctx.FormValue("school_year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year <= 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid school_year format",
+			})
+		}
+		y := uint(year)
+		schoolYear = &y
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is required",
+		})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to open file",
+		})
+	}
+	defer src.Close()
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+
+	template, err := c.templateUsecase.UploadTemplate(ctx.FormValue("name"), schoolYear, content, claims)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     "template uploaded successfully",
+		"template_id": template.TemplateID,
+	})
+}
+
+func (c *TemplateController) GetAllTemplates(ctx *fiber.Ctx) error {
+	templates, err := c.templateUsecase.GetAllTemplates()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Unable to retrieve templates",
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(templates)
+}
+
+func (c *TemplateController) GetTemplateByID(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+
+	template, err := c.templateUsecase.GetTemplateByID(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(template)
+}
+
+func (c *TemplateController) DeleteTemplateByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id format",
+		})
+	}
+	templateID := uint(id)
+
+	if err := c.templateUsecase.DeleteTemplateByID(templateID, claims); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": fmt.Sprintf("template with ID %d deleted successfully", templateID),
+	})
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"go-clean-arch/pkg/utility"
 	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
 	"go-clean-arch/usecase"
+	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -59,6 +62,49 @@ func (c *UserController) RegisterStudent(ctx *fiber.Ctx) error {
 	})
 }
 
+// นำเข้านักศึกษาจากไฟล์ CSV (multipart ฟิลด์ file) ?dry_run=true เพื่อตรวจสอบอย่างเดียว
+func (c *UserController) ImportStudents(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file is required",
+		})
+	}
+	// จำกัดขนาดไฟล์ที่ 5MB
+	if file.Size > 5*1024*1024 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file size exceeds the 5MB limit",
+		})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to open file",
+		})
+	}
+	defer src.Close()
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to read file",
+		})
+	}
+
+	result, err := c.userUsecase.ImportStudents(content, ctx.QueryBool("dry_run"), claims)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(result)
+}
+
 func (c *UserController) Login(ctx *fiber.Ctx) error {
 	var req struct {
 		Email    string `json:"email"`
@@ -70,28 +116,177 @@ func (c *UserController) Login(ctx *fiber.Ctx) error {
 		})
 	}
 
-	token, role, err := c.userUsecase.GetUserByEmail(req.Email, req.Password)
+	tokens, role, err := c.userUsecase.GetUserByEmail(req.Email, req.Password)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	setAuthCookies(ctx, tokens)
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Login successful",
+		"role":    role,
+	})
+}
+
+func setAuthCookies(ctx *fiber.Ctx, tokens *response.AuthTokens) {
 	ctx.Cookie(&fiber.Cookie{
-		Name:     "token",                        // ชื่อคุกกี้
-		Value:    token,                          // ค่า JWT
-		Expires:  time.Now().Add(24 * time.Hour), // วันหมดอายุ (24 ชั่วโมง)
-		HTTPOnly: false,                          // ป้องกันการเข้าถึงผ่าน JavaScript
-		Secure:   false,                          // ใช้งานเฉพาะ HTTPS (แนะนำสำหรับ Production)
-		SameSite: "Lax",                          // นโยบาย SameSite
+		Name:     "token",                // ชื่อคุกกี้
+		Value:    tokens.AccessToken,     // ค่า JWT
+		Expires:  tokens.AccessExpiresAt, // วันหมดอายุ (อายุสั้น)
+		HTTPOnly: false,                  // ป้องกันการเข้าถึงผ่าน JavaScript
+		Secure:   false,                  // ใช้งานเฉพาะ HTTPS (แนะนำสำหรับ Production)
+		SameSite: "Lax",                  // นโยบาย SameSite
 	})
+	ctx.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    tokens.RefreshToken,
+		Expires:  tokens.RefreshExpiresAt,
+		HTTPOnly: true, // refresh token ไม่ให้ JavaScript อ่านได้
+		Secure:   false,
+		SameSite: "Lax",
+	})
+}
+
+func clearAuthCookies(ctx *fiber.Ctx) {
+	for _, name := range []string{"token", "refresh_token"} {
+		ctx.Cookie(&fiber.Cookie{
+			Name:     name,
+			Value:    "",
+			Expires:  time.Now().Add(-time.Hour),
+			SameSite: "Lax",
+		})
+	}
+}
+
+func (c *UserController) RefreshToken(ctx *fiber.Ctx) error {
+	tokens, role, err := c.userUsecase.RefreshToken(ctx.Cookies("refresh_token"))
+	if err != nil {
+		clearAuthCookies(ctx)
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	setAuthCookies(ctx, tokens)
 
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Login successful",
+		"message": "Token refreshed successfully",
 		"role":    role,
 	})
 }
 
+func (c *UserController) Logout(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	if err := c.userUsecase.Logout(claims); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	clearAuthCookies(ctx)
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Logout successful",
+	})
+}
+
+func (c *UserController) RevokeAllSessions(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+	idStr := ctx.Params("userid")
+	idInt, err := strconv.Atoi(idStr)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid UserID",
+		})
+	}
+
+	if err := c.userUsecase.RevokeAllSessions(uint(idInt), claims); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "all sessions revoked successfully",
+	})
+}
+
+func (c *UserController) ChangePassword(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
+
+	var req request.ChangePasswordRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.userUsecase.ChangePassword(claims, &req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password changed successfully",
+	})
+}
+
+func (c *UserController) ForgotPassword(ctx *fiber.Ctx) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.BodyParser(&req); err != nil || req.Email == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.userUsecase.ForgotPassword(req.Email); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	// ตอบเหมือนกันทุกกรณี ไม่ให้รู้ว่าอีเมลมีในระบบหรือไม่
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "If the email exists, a reset link has been sent",
+	})
+}
+
+func (c *UserController) ResetPassword(ctx *fiber.Ctx) error {
+	var req request.ResetPasswordRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.userUsecase.ResetPassword(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Password reset successfully",
+	})
+}
+
 func (c *UserController) GetUserByClaims(ctx *fiber.Ctx) error {
 	claims, err := utility.GetClaimsFromContext(ctx)
 	if err != nil {
@@ -178,6 +373,12 @@ func (c *UserController) UpdateStudent(ctx *fiber.Ctx) error {
 }
 
 func (c *UserController) UpdateRoleByID(ctx *fiber.Ctx) error {
+	claims, err := utility.GetClaimsFromContext(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "failed to retrieve claims",
+		})
+	}
 	var req struct {
 		UserID uint   `json:"user_id"`
 		Role   string `json:"role"`
@@ -187,7 +388,7 @@ func (c *UserController) UpdateRoleByID(ctx *fiber.Ctx) error {
 			"error":"bad request",
 		})
 	}
-	if err:= c.userUsecase.UpdateRoleByID(req.UserID,req.Role);err != nil {
+	if err:= c.userUsecase.UpdateRoleByID(req.UserID,req.Role,claims);err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":"Failed to update role",
 		})
@@ -213,13 +414,22 @@ func (c *UserController) SendEvent(ctx *fiber.Ctx) error{
 	}
 	year := uint(id)
 
-	if err := c.userUsecase.SendEvent(year,claims); err != nil {
+	evaluation, err := c.userUsecase.SendEvent(year, claims)
+	if err != nil {
+		// usecase คืน evaluation เฉพาะเมื่อไม่ผ่านเกณฑ์หรือสถานะส่งตรวจไม่ได้ ข้อผิดพลาดอื่นเป็นของระบบ
+		if evaluation != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+				"rule":  evaluation,
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Send all event successfully",
+		"rule":    evaluation,
 	})
 }
 
@@ -241,9 +451,10 @@ func (c *UserController) GetStudentsAndYearsByCertifier(ctx *fiber.Ctx) error{
 }
 
 
+// ตรวจผลส่งตรวจประจำปี body: {"state": "approved|rejected|resubmission_requested", "comment"}
 func (c *UserController) UpdateStatusDones(ctx *fiber.Ctx) error {
 	var req struct {
-		Status  bool   `json:"status"`
+		State   string `json:"state"`
 		Comment string `json:"comment"`
 	}
 
@@ -274,8 +485,18 @@ func (c *UserController) UpdateStatusDones(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := c.userUsecase.UpdateStatusDones(certifierID, userID, req.Status, req.Comment); err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if err := c.userUsecase.UpdateStatusDones(certifierID, userID, req.State, req.Comment, claims); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if strings.Contains(err.Error(), "failed") {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
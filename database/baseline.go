@@ -0,0 +1,136 @@
+package database
+
+import "time"
+
+// schema ตั้งต้นของ 0001_create_base_tables แยกจาก structure/entity เพื่อไม่ให้เปลี่ยนตาม entity
+// ห้ามแก้ไข การเปลี่ยน schema ให้เพิ่มเป็น migration ใหม่
+// ชื่อ field ของความสัมพันธ์ต้องตรงกับ entity เพราะ gorm ใช้ตั้งชื่อ foreign key (เช่น fk_events_teacher)
+
+type baselineUser struct {
+	UserID   uint             `gorm:"primaryKey;autoIncrement"`
+	Email    string           `gorm:"unique;not null"`
+	Password string           `gorm:"not null"`
+	Role     string           `gorm:"default:'user'"`
+	Student  *baselineStudent `gorm:"foreignKey:UserID"`
+	Teacher  *baselineTeacher `gorm:"foreignKey:UserID"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineTeacher struct {
+	UserID    uint   `gorm:"primaryKey"`
+	TitleName string `gorm:"not null"`
+	FirstName string `gorm:"not null"`
+	LastName  string `gorm:"not null"`
+	Phone     string `gorm:"unique"`
+	Code      string `gorm:"unique"`
+}
+
+func (baselineTeacher) TableName() string { return "teachers" }
+
+type baselineFaculty struct {
+	FacultyID   uint            `gorm:"primaryKey;autoIncrement"`
+	FacultyCode string          `gorm:"unique;not null"`
+	FacultyName string          `gorm:"unique;not null"`
+	SuperUser   *uint           `gorm:"default:null"`
+	Teacher     baselineTeacher `gorm:"foreignKey:SuperUser;references:UserID"`
+}
+
+func (baselineFaculty) TableName() string { return "faculties" }
+
+type baselineBranch struct {
+	BranchID   uint            `gorm:"primaryKey;autoIncrement"`
+	BranchCode string          `gorm:"unique;not null"`
+	BranchName string          `gorm:"unique;not null"`
+	FacultyId  uint            `gorm:"not null"`
+	Faculty    baselineFaculty `gorm:"foreignKey:FacultyId;references:FacultyID"`
+}
+
+func (baselineBranch) TableName() string { return "branches" }
+
+type baselineStudent struct {
+	UserID    uint           `gorm:"primaryKey"`
+	TitleName string         `gorm:"not null"`
+	FirstName string         `gorm:"not null"`
+	LastName  string         `gorm:"not null"`
+	Phone     string         `gorm:"unique"`
+	Code      string         `gorm:"unique"`
+	Year      uint           `gorm:"not null"`
+	BranchId  uint           `gorm:"not null"`
+	Branch    baselineBranch `gorm:"foreignKey:BranchId;references:BranchID"`
+}
+
+func (baselineStudent) TableName() string { return "students" }
+
+type baselineEvent struct {
+	EventID        uint      `gorm:"primaryKey;autoIncrement"`
+	EventName      string    `gorm:"not null"`
+	Creator        uint      `gorm:"not null"`
+	StartDate      time.Time `gorm:"not null"`
+	SchoolYear     uint      `gorm:"not null"`
+	WorkingHour    uint      `gorm:"not null"`
+	FreeSpace      uint      `gorm:"not null"`
+	Location       string    `gorm:"not null"`
+	Detail         string
+	BranchIDs      string `gorm:"type:json"`
+	Years          string `gorm:"type:json"`
+	AllowAllBranch bool
+	AllowAllYear   bool
+	Status         bool            `gorm:"default:true"`
+	Teacher        baselineTeacher `gorm:"foreignKey:Creator;references:UserID"`
+}
+
+func (baselineEvent) TableName() string { return "events" }
+
+type baselineEventInside struct {
+	EventId   uint            `gorm:"primaryKey"`
+	User      uint            `gorm:"primaryKey"`
+	Event     baselineEvent   `gorm:"foreignKey:EventId;references:EventID;constraint:OnDelete:CASCADE;"`
+	Student   baselineStudent `gorm:"foreignKey:User;references:UserID"`
+	Certifier uint            `gorm:"default:null"`
+	Teacher   baselineTeacher `gorm:"foreignKey:Certifier;references:UserID"`
+	Status    bool
+	Comment   string
+	File      string `gorm:"size:255"`
+}
+
+func (baselineEventInside) TableName() string { return "event_insides" }
+
+type baselineEventOutside struct {
+	EventID     uint            `gorm:"primaryKey;autoIncrement"`
+	User        uint            `gorm:"primaryKey"`
+	Student     baselineStudent `gorm:"foreignKey:User;references:UserID"`
+	EventName   string          `gorm:"not null"`
+	SchoolYear  uint            `gorm:"not null"`
+	StartDate   time.Time       `gorm:"not null"`
+	Intendant   string          `gorm:"not null"`
+	WorkingHour uint
+	Location    string `gorm:"not null"`
+	File        string `gorm:"size:255"`
+}
+
+func (baselineEventOutside) TableName() string { return "event_outsides" }
+
+type baselineDone struct {
+	User      uint            `gorm:"primaryKey"`
+	Student   baselineStudent `gorm:"foreignKey:User;references:UserID"`
+	Certifier uint            `gorm:"default:null"`
+	Teacher   baselineTeacher `gorm:"foreignKey:Certifier;references:UserID"`
+	Year      uint            `gorm:"not null"`
+	Status    bool
+	Comment   string
+}
+
+func (baselineDone) TableName() string { return "dones" }
+
+type baselineNews struct {
+	NewsID    uint `gorm:"primaryKey;autoIncrement"`
+	Title     string
+	UserID    uint
+	User      baselineUser `gorm:"foreignKey:UserID;references:UserID"`
+	Message   string
+	IsRead    bool `gorm:"default:false"`
+	CreatedAt time.Time
+}
+
+func (baselineNews) TableName() string { return "news" }
@@ -1,10 +1,68 @@
 package database
 
 import (
+	"fmt"
+	"go-clean-arch/config"
+	"go-clean-arch/pkg/hash"
+	"go-clean-arch/structure/entity"
+	"log"
+
 	"gorm.io/gorm"
 )
 
 type Database interface {
 	GetDB() *gorm.DB
-	AutoMigrate() error
+}
+
+// เลือกฐานข้อมูลตาม DB_DRIVER (mysql หรือ postgres)
+func NewDatabase(cfg *config.Config) (Database, error) {
+	switch cfg.DBDriver {
+	case "", "mysql":
+		return NewMySQLDatabase(cfg)
+	case "postgres":
+		return NewPostgresDatabase(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.DBDriver)
+	}
+}
+
+func SetupDatabase(cfg *config.Config) Database {
+
+	db, err := NewDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	// รัน migration ที่ยังไม่เคยรัน (จัดการเพิ่มเติมได้ด้วยคำสั่ง migrate)
+	if _, err := NewMigrator(db.GetDB()).Up(); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+
+	password, err := hash.HashPassword(cfg.Admin.Password)
+	if err != nil {
+		log.Fatalf("failed to hash password: %v", err)
+	}
+
+	var admin entity.User
+	result := db.GetDB().Where("email = ?", cfg.Admin.Email).First(&admin)
+	if result.Error == nil {
+		log.Println("Admin user already exists.")
+	} else if result.Error == gorm.ErrRecordNotFound {
+		user := entity.User{
+			Email:    cfg.Admin.Email,
+			Password: password,
+			Role:     "superadmin",
+		}
+		createResult := db.GetDB().Create(&user)
+		if createResult.Error != nil {
+			log.Fatalf("failed to create admin user: %v", createResult.Error)
+		} else {
+			log.Println("Admin user created successfully!")
+		}
+	} else {
+		log.Fatalf("failed to check admin user existence: %v", result.Error)
+	}
+
+	log.Printf("Database (%s) connected and migrated successfully!", db.GetDB().Dialector.Name())
+	return db
 }
@@ -0,0 +1,235 @@
+package database
+
+import "time"
+
+// schema ของ migration 0003 เป็นต้นไป ตามที่เป็นอยู่ตอน migration นั้นถูกเพิ่ม แยกจาก structure/entity เช่นเดียวกับ baseline.go
+// ห้ามแก้ไข การเปลี่ยน schema ให้เพิ่มเป็น migration ใหม่พร้อม struct ของตัวเอง
+
+// ตารางที่ถูกอ้างถึงใช้ struct ที่มีแค่ primary key เพราะ
+//   - AutoMigrate จะ migrate ตารางที่ถูกอ้างถึงด้วย จึงต้องไม่มีคอลัมน์อื่นให้เปลี่ยน
+//   - ชื่อ field ต้องไม่ตรงกับ foreign key ไม่อย่างนั้น gorm เดาเป็น has one และสร้าง foreign key กลับด้านบนตารางหลัก
+
+type userKey struct {
+	Key uint `gorm:"column:user_id;primaryKey;autoIncrement"`
+}
+
+func (userKey) TableName() string { return "users" }
+
+type teacherKey struct {
+	Key uint `gorm:"column:user_id;primaryKey"`
+}
+
+func (teacherKey) TableName() string { return "teachers" }
+
+type studentKey struct {
+	Key uint `gorm:"column:user_id;primaryKey"`
+}
+
+func (studentKey) TableName() string { return "students" }
+
+type facultyKey struct {
+	Key uint `gorm:"column:faculty_id;primaryKey;autoIncrement"`
+}
+
+func (facultyKey) TableName() string { return "faculties" }
+
+type branchKey struct {
+	Key uint `gorm:"column:branch_id;primaryKey;autoIncrement"`
+}
+
+func (branchKey) TableName() string { return "branches" }
+
+type eventKey struct {
+	Key uint `gorm:"column:event_id;primaryKey;autoIncrement"`
+}
+
+func (eventKey) TableName() string { return "events" }
+
+// 0003_add_activity_rules
+
+type m0003Event struct {
+	Category string `gorm:"size:100"`
+}
+
+func (m0003Event) TableName() string { return "events" }
+
+type m0003ActivityRule struct {
+	RuleID             uint       `gorm:"primaryKey;autoIncrement"`
+	FacultyID          *uint      `gorm:"default:null;index"`
+	Faculty            facultyKey `gorm:"foreignKey:FacultyID;references:Key;constraint:OnDelete:CASCADE;"`
+	BranchID           *uint      `gorm:"default:null;index"`
+	Branch             branchKey  `gorm:"foreignKey:BranchID;references:Key;constraint:OnDelete:CASCADE;"`
+	SchoolYear         *uint      `gorm:"default:null;index"`
+	MinInsideHour      uint       `gorm:"not null"`
+	MinTotalHour       uint       `gorm:"not null"`
+	MaxOutsideHour     *uint      `gorm:"default:null"`
+	RequiredCategories string     `gorm:"type:json"`
+}
+
+func (m0003ActivityRule) TableName() string { return "activity_rules" }
+
+// 0004_add_event_waitlist
+
+type m0004EventWaitlist struct {
+	EventId   uint       `gorm:"primaryKey"`
+	User      uint       `gorm:"primaryKey"`
+	Event     eventKey   `gorm:"foreignKey:EventId;references:Key;constraint:OnDelete:CASCADE;"`
+	Student   studentKey `gorm:"foreignKey:User;references:Key"`
+	Position  uint       `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (m0004EventWaitlist) TableName() string { return "event_waitlists" }
+
+// 0005_add_event_attendance
+
+type m0005EventInside struct {
+	Attended   bool       `gorm:"default:false"`
+	AttendedAt *time.Time `gorm:"default:null"`
+}
+
+func (m0005EventInside) TableName() string { return "event_insides" }
+
+// 0006_add_sessions
+
+type m0006Session struct {
+	SessionID         string     `gorm:"primaryKey;size:36"`
+	UserID            uint       `gorm:"not null;index"`
+	User              userKey    `gorm:"foreignKey:UserID;references:Key;constraint:OnDelete:CASCADE;"`
+	RefreshTokenHash  string     `gorm:"size:64;not null;uniqueIndex"`
+	PreviousTokenHash string     `gorm:"size:64;index"`
+	ExpiresAt         time.Time  `gorm:"not null"`
+	RevokedAt         *time.Time `gorm:"default:null"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (m0006Session) TableName() string { return "sessions" }
+
+// 0007_add_password_resets
+
+type m0007PasswordReset struct {
+	TokenHash string     `gorm:"primaryKey;size:64"`
+	UserID    uint       `gorm:"not null;index"`
+	User      userKey    `gorm:"foreignKey:UserID;references:Key;constraint:OnDelete:CASCADE;"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time
+}
+
+func (m0007PasswordReset) TableName() string { return "password_resets" }
+
+// 0008_move_event_permissions_to_tables
+
+type m0008EventBranch struct {
+	EventID  uint      `gorm:"primaryKey"`
+	BranchID uint      `gorm:"primaryKey;index"`
+	Event    eventKey  `gorm:"foreignKey:EventID;references:Key;constraint:OnDelete:CASCADE;"`
+	Branch   branchKey `gorm:"foreignKey:BranchID;references:Key;constraint:OnDelete:RESTRICT;"`
+}
+
+func (m0008EventBranch) TableName() string { return "event_branches" }
+
+type m0008EventYear struct {
+	EventID uint     `gorm:"primaryKey"`
+	Year    uint     `gorm:"primaryKey;index"`
+	Event   eventKey `gorm:"foreignKey:EventID;references:Key;constraint:OnDelete:CASCADE;"`
+}
+
+func (m0008EventYear) TableName() string { return "event_years" }
+
+// 0009_add_document_templates
+
+type m0009DocumentTemplate struct {
+	TemplateID uint   `gorm:"primaryKey;autoIncrement"`
+	Name       string `gorm:"size:100;not null;index"`
+	SchoolYear *uint  `gorm:"default:null;index"`
+	Content    string `gorm:"type:text;not null"`
+	UploadedBy uint   `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
+func (m0009DocumentTemplate) TableName() string { return "document_templates" }
+
+// 0010_add_generated_forms
+
+type m0010GeneratedForm struct {
+	Serial      string    `gorm:"primaryKey;size:36"`
+	OutsideID   uint      `gorm:"not null;index"`
+	UserID      uint      `gorm:"not null;index"`
+	GeneratedAt time.Time `gorm:"not null"`
+}
+
+func (m0010GeneratedForm) TableName() string { return "generated_forms" }
+
+// 0011_add_review_states
+
+type m0011ReviewTransition struct {
+	TransitionID uint   `gorm:"primaryKey;autoIncrement"`
+	Kind         string `gorm:"size:10;not null;index:idx_review_transition_ref"`
+	RefID        uint   `gorm:"not null;index:idx_review_transition_ref"`
+	UserID       uint   `gorm:"not null;index:idx_review_transition_ref"`
+	FromState    string `gorm:"size:30"`
+	ToState      string `gorm:"size:30;not null"`
+	ActorID      uint   `gorm:"not null"`
+	Comment      string
+	CreatedAt    time.Time
+}
+
+func (m0011ReviewTransition) TableName() string { return "review_transitions" }
+
+type m0011EventInside struct {
+	State          string     `gorm:"size:30;not null;default:joined;index"`
+	StateChangedAt *time.Time `gorm:"default:null"`
+}
+
+func (m0011EventInside) TableName() string { return "event_insides" }
+
+type m0011Done struct {
+	State          string     `gorm:"size:30;not null;default:evidence_submitted;index"`
+	StateChangedAt *time.Time `gorm:"default:null"`
+}
+
+func (m0011Done) TableName() string { return "dones" }
+
+// 0012_add_outside_review
+
+type m0012EventOutside struct {
+	Certifier      uint       `gorm:"default:null;index"`
+	Teacher        teacherKey `gorm:"foreignKey:Certifier;references:Key"`
+	State          string     `gorm:"size:30;not null;default:joined;index"`
+	StateChangedAt *time.Time `gorm:"default:null"`
+	Comment        string
+}
+
+func (m0012EventOutside) TableName() string { return "event_outsides" }
+
+// 0013_add_audit_logs
+
+type m0013AuditLog struct {
+	AuditID    uint      `gorm:"primaryKey;autoIncrement"`
+	ActorID    uint      `gorm:"not null;index"`
+	ActorRole  string    `gorm:"size:20"`
+	Action     string    `gorm:"size:50;not null;index"`
+	TargetType string    `gorm:"size:30;not null;index:idx_audit_target"`
+	TargetID   string    `gorm:"size:64;index:idx_audit_target"`
+	Before     string    `gorm:"type:text"`
+	After      string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (m0013AuditLog) TableName() string { return "audit_logs" }
+
+// 0015_add_evidence_thumbnails
+
+type m0015EventInside struct {
+	Thumbnail string `gorm:"size:255"`
+}
+
+func (m0015EventInside) TableName() string { return "event_insides" }
+
+type m0015EventOutside struct {
+	Thumbnail string `gorm:"size:255"`
+}
+
+func (m0015EventOutside) TableName() string { return "event_outsides" }
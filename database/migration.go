@@ -0,0 +1,140 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration คือการเปลี่ยนแปลง schema/ข้อมูล หนึ่งเวอร์ชัน
+// ID ต้องไม่ซ้ำและเรียงตามลำดับที่ต้องรัน (เช่น 0001_create_base_tables)
+type Migration struct {
+	ID   string
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+}
+
+// บันทึกเวอร์ชันที่รันแล้วในตาราง schema_migrations
+type SchemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// สร้าง Migrator จากรายการ migration ของระบบ
+func NewMigrator(db *gorm.DB) *Migrator {
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *gorm.DB, list []Migration) *Migrator {
+	sorted := make([]Migration, len(list))
+	copy(sorted, list)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+func (m *Migrator) applied() (map[string]time.Time, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// รัน migration ที่ยังไม่เคยรันตามลำดับ คืนรายการที่รันสำเร็จ
+func (m *Migrator) Up() ([]string, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.ID]; ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: migration.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", migration.ID, err)
+		}
+		log.Printf("Applied migration %s", migration.ID)
+		done = append(done, migration.ID)
+	}
+	return done, nil
+}
+
+// ย้อน migration ล่าสุดที่รันแล้วจำนวน steps รายการ
+func (m *Migrator) Down(steps int) ([]string, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.ID]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("migration %s cannot be rolled back", migration.ID)
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Where("id = ?", migration.ID).Delete(&SchemaMigration{}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback %s failed: %w", migration.ID, err)
+		}
+		log.Printf("Rolled back migration %s", migration.ID)
+		done = append(done, migration.ID)
+	}
+	return done, nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{ID: migration.ID}
+		if at, ok := applied[migration.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-clean-arch/structure/entity"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	// in-memory sqlite แยกฐานข้อมูลตาม connection
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+// จำนวน step ที่ต้อง Down เพื่อย้อน migration id (รวมรายการที่รันหลังจากนั้น)
+func stepsDownTo(t *testing.T, id string) int {
+	for i, migration := range migrations {
+		if migration.ID == id {
+			return len(migrations) - i
+		}
+	}
+	t.Fatalf("migration %s not found", id)
+	return 0
+}
+
+// TestMigrator tests applying and rolling back versioned migrations
+func TestMigrator(t *testing.T) {
+	t.Run("Up applies each migration exactly once", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+
+		applied, err := migrator.Up()
+		assert.NoError(t, err)
+		assert.Len(t, applied, len(migrations))
+		assert.True(t, db.Migrator().HasTable(&entity.EventBranch{}))
+
+		applied, err = migrator.Up()
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("Fresh install matches current entities", func(t *testing.T) {
+		db := newTestDB(t)
+		_, err := NewMigrator(db).Up()
+		assert.NoError(t, err)
+
+		models := []interface{}{
+			&entity.User{}, &entity.Teacher{}, &entity.Student{}, &entity.Faculty{}, &entity.Branch{},
+			&entity.Event{}, &entity.EventBranch{}, &entity.EventYear{}, &entity.EventInside{},
+			&entity.EventWaitlist{}, &entity.EventOutside{}, &entity.GeneratedForm{}, &entity.Done{},
+			&entity.News{}, &entity.ReviewTransition{}, &entity.ActivityRule{}, &entity.Session{},
+			&entity.PasswordReset{}, &entity.DocumentTemplate{}, &entity.AuditLog{},
+		}
+		for _, model := range models {
+			stmt := &gorm.Statement{DB: db}
+			assert.NoError(t, stmt.Parse(model))
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+				}
+			}
+		}
+		// foreign key อยู่บนตารางลูก ไม่มีตัวที่กลับด้านบนตารางหลัก
+		for _, fk := range [][2]string{
+			{"event_outsides", "fk_event_outsides_teacher"},
+			{"activity_rules", "fk_activity_rules_faculty"},
+			{"event_branches", "fk_event_branches_branch"},
+			{"event_waitlists", "fk_event_waitlists_event"},
+			{"sessions", "fk_sessions_user"},
+		} {
+			assert.True(t, db.Migrator().HasConstraint(fk[0], fk[1]), "%s.%s", fk[0], fk[1])
+		}
+		for _, fk := range reversedForeignKeys {
+			assert.False(t, db.Migrator().HasConstraint(fk.table, fk.name), "%s.%s", fk.table, fk.name)
+		}
+		// คอลัมน์เดิมที่ถูกแทนที่ต้องถูกลบโดย migration ที่เกี่ยวข้อง
+		assert.False(t, db.Migrator().HasColumn("events", "branch_ids"))
+		assert.False(t, db.Migrator().HasColumn("event_insides", "status"))
+		assert.False(t, db.Migrator().HasColumn("dones", "status"))
+	})
+
+	t.Run("Reversed foreign keys are moved to the child table", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+		_, err := migrator.Up()
+		assert.NoError(t, err)
+		_, err = migrator.Down(stepsDownTo(t, "0017_fix_reversed_foreign_keys"))
+		assert.NoError(t, err)
+
+		// entity.Session ถูกเดาเป็น has one จึงสร้าง fk_sessions_user บน users แบบที่ 0006 เดิมทำ
+		assert.NoError(t, db.Migrator().DropConstraint("sessions", "fk_sessions_user"))
+		assert.NoError(t, db.Migrator().CreateConstraint(&entity.Session{}, "User"))
+		assert.True(t, db.Migrator().HasConstraint("users", "fk_sessions_user"))
+
+		_, err = migrator.Up()
+		assert.NoError(t, err)
+		assert.False(t, db.Migrator().HasConstraint("users", "fk_sessions_user"))
+		assert.True(t, db.Migrator().HasConstraint("sessions", "fk_sessions_user"))
+	})
+
+	t.Run("Down rolls back the latest migrations", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+		_, err := migrator.Up()
+		assert.NoError(t, err)
+
+		rolledBack, err := migrator.Down(stepsDownTo(t, "0008_move_event_permissions_to_tables"))
+		assert.NoError(t, err)
+		assert.Equal(t, "0008_move_event_permissions_to_tables", rolledBack[len(rolledBack)-1])
+		assert.False(t, db.Migrator().HasTable(&entity.EventBranch{}))
+		assert.True(t, db.Migrator().HasColumn("events", "branch_ids"))
+
+		statuses, err := migrator.Status()
+		assert.NoError(t, err)
+		assert.False(t, statuses[len(statuses)-1].Applied)
+
+		_, err = migrator.Down(len(migrations))
+		assert.NoError(t, err)
+		assert.False(t, db.Migrator().HasTable(&entity.User{}))
+	})
+
+	t.Run("Legacy JSON permissions are backfilled", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+		_, err := migrator.Up()
+		assert.NoError(t, err)
+		_, err = migrator.Down(stepsDownTo(t, "0008_move_event_permissions_to_tables"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, db.Create(&entity.Faculty{FacultyCode: "SCI", FacultyName: "Science"}).Error)
+		assert.NoError(t, db.Create(&entity.Branch{BranchCode: "CS", BranchName: "Computer Science", FacultyId: 1}).Error)
+		assert.NoError(t, db.Exec(`INSERT INTO events (event_name, creator, start_date, school_year, working_hour, free_space, location, branch_ids, years)
+			VALUES ('event', 1, '2025-01-01', 2568, 3, 10, 'hall', '"[1,99]"', '[1,2]')`).Error)
+
+		_, err = migrator.Up()
+		assert.NoError(t, err)
+
+		var branches []entity.EventBranch
+		var years []entity.EventYear
+		assert.NoError(t, db.Find(&branches).Error)
+		assert.NoError(t, db.Order("year").Find(&years).Error)
+		// สาขา 99 ไม่มีอยู่จริงจึงถูกข้าม
+		assert.Equal(t, []entity.EventBranch{{EventID: 1, BranchID: 1}}, branches)
+		assert.Len(t, years, 2)
+		assert.False(t, db.Migrator().HasColumn("events", "branch_ids"))
+	})
+
+	t.Run("Legacy review status is converted to state", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+		_, err := migrator.Up()
+		assert.NoError(t, err)
+		_, err = migrator.Down(stepsDownTo(t, "0011_add_review_states"))
+		assert.NoError(t, err)
+		assert.True(t, db.Migrator().HasColumn("event_insides", "status"))
+		assert.False(t, db.Migrator().HasColumn("event_insides", "state"))
+
+		assert.NoError(t, db.Exec(`INSERT INTO event_insides (event_id, user, status, comment, file) VALUES
+			(1, 1, true, '', 'a.pdf'), (1, 2, false, 'no evidence', ''), (1, 3, false, '', 'c.pdf'), (1, 4, false, '', '')`).Error)
+		assert.NoError(t, db.Exec(`INSERT INTO dones (user, year, status, comment) VALUES
+			(1, 2567, true, ''), (2, 2567, false, 'missing hours'), (3, 2567, false, '')`).Error)
+
+		_, err = migrator.Up()
+		assert.NoError(t, err)
+		assert.False(t, db.Migrator().HasColumn("event_insides", "status"))
+
+		var insides []entity.EventInside
+		var dones []entity.Done
+		assert.NoError(t, db.Order("user").Find(&insides).Error)
+		assert.NoError(t, db.Order("user").Find(&dones).Error)
+		if assert.Len(t, insides, 4) && assert.Len(t, dones, 3) {
+			assert.Equal(t, entity.StateApproved, insides[0].State)
+			assert.Equal(t, entity.StateResubmissionRequested, insides[1].State)
+			assert.Equal(t, entity.StateEvidenceSubmitted, insides[2].State)
+			assert.Equal(t, entity.StateJoined, insides[3].State)
+			assert.Equal(t, entity.StateApproved, dones[0].State)
+			assert.Equal(t, entity.StateResubmissionRequested, dones[1].State)
+			assert.Equal(t, entity.StateEvidenceSubmitted, dones[2].State)
+		}
+	})
+
+	t.Run("Legacy upload paths become storage keys", func(t *testing.T) {
+		db := newTestDB(t)
+		migrator := NewMigrator(db)
+		_, err := migrator.Up()
+		assert.NoError(t, err)
+		_, err = migrator.Down(stepsDownTo(t, "0014_use_storage_keys"))
+		assert.NoError(t, err)
+
+		assert.NoError(t, db.Exec(`INSERT INTO event_insides (event_id, user, file) VALUES
+			(1, 1, './uploads/1/a.pdf'), (1, 2, '')`).Error)
+		_, err = migrator.Up()
+		assert.NoError(t, err)
+
+		var insides []entity.EventInside
+		assert.NoError(t, db.Order("user").Find(&insides).Error)
+		if assert.Len(t, insides, 2) {
+			assert.Equal(t, "1/a.pdf", insides[0].File)
+			assert.Equal(t, "", insides[1].File)
+		}
+
+		_, err = migrator.Down(stepsDownTo(t, "0014_use_storage_keys"))
+		assert.NoError(t, err)
+		var file string
+		assert.NoError(t, db.Table("event_insides").Where("user = ?", 1).Pluck("file", &file).Error)
+		assert.Equal(t, "./uploads/1/a.pdf", file)
+	})
+}
@@ -0,0 +1,637 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/structure/entity"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// รายการ migration ทั้งหมดของระบบ เพิ่มรายการใหม่ต่อท้ายเสมอ ห้ามแก้ไขรายการที่ deploy ไปแล้ว
+var migrations = []Migration{
+	{
+		// ใช้ schema ตั้งต้นใน baseline.go ไม่ใช่ entity ปัจจุบัน เพื่อให้ migration ถัดไปทำงานเหมือนกันทุกฐานข้อมูล
+		ID: "0001_create_base_tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(
+				&baselineUser{},
+				&baselineTeacher{},
+				&baselineFaculty{},
+				&baselineBranch{},
+				&baselineStudent{},
+				&baselineEvent{},
+				&baselineEventInside{},
+				&baselineEventOutside{},
+				&baselineDone{},
+				&baselineNews{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(
+				&baselineNews{},
+				&baselineDone{},
+				&baselineEventOutside{},
+				&baselineEventInside{},
+				&baselineEvent{},
+				&baselineStudent{},
+				&baselineBranch{},
+				&baselineFaculty{},
+				&baselineTeacher{},
+				&baselineUser{},
+			)
+		},
+	},
+	{
+		// ป้องกัน user_id ซ้ำกันระหว่าง students และ teachers (MySQL เท่านั้น)
+		ID: "0002_create_user_role_triggers",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			for _, stmt := range []string{
+				"DROP TRIGGER IF EXISTS before_insert_students",
+				`CREATE TRIGGER before_insert_students
+				BEFORE INSERT ON students
+				FOR EACH ROW
+				BEGIN
+					IF EXISTS (SELECT 1 FROM teachers WHERE user_id = NEW.user_id) THEN
+						SIGNAL SQLSTATE '45000'
+						SET MESSAGE_TEXT = 'User ID already exists in teachers';
+					END IF;
+				END`,
+				"DROP TRIGGER IF EXISTS before_insert_teachers",
+				`CREATE TRIGGER before_insert_teachers
+				BEFORE INSERT ON teachers
+				FOR EACH ROW
+				BEGIN
+					IF EXISTS (SELECT 1 FROM students WHERE user_id = NEW.user_id) THEN
+						SIGNAL SQLSTATE '45000'
+						SET MESSAGE_TEXT = 'User ID already exists in students';
+					END IF;
+				END`,
+			} {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if err := tx.Exec("DROP TRIGGER IF EXISTS before_insert_students").Error; err != nil {
+				return err
+			}
+			return tx.Exec("DROP TRIGGER IF EXISTS before_insert_teachers").Error
+		},
+	},
+	{
+		ID: "0003_add_activity_rules",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumnIfMissing(tx, &m0003Event{}, "Category"); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&m0003ActivityRule{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&m0003ActivityRule{}); err != nil {
+				return err
+			}
+			return dropColumnIfExists(tx, &m0003Event{}, "Category")
+		},
+	},
+	{
+		ID: "0004_add_event_waitlist",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&m0004EventWaitlist{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&m0004EventWaitlist{})
+		},
+	},
+	{
+		ID: "0005_add_event_attendance",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumnIfMissing(tx, &m0005EventInside{}, "Attended"); err != nil {
+				return err
+			}
+			return addColumnIfMissing(tx, &m0005EventInside{}, "AttendedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumnIfExists(tx, &m0005EventInside{}, "AttendedAt"); err != nil {
+				return err
+			}
+			return dropColumnIfExists(tx, &m0005EventInside{}, "Attended")
+		},
+	},
+	{
+		ID: "0006_add_sessions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&m0006Session{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&m0006Session{})
+		},
+	},
+	{
+		ID: "0007_add_password_resets",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&m0007PasswordReset{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&m0007PasswordReset{})
+		},
+	},
+	{
+		ID: "0008_move_event_permissions_to_tables",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&m0008EventBranch{}, &m0008EventYear{}); err != nil {
+				return err
+			}
+			return migrateEventPermissions(tx)
+		},
+		Down: restoreEventPermissionColumns,
+	},
+	{
+		ID: "0009_add_document_templates",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&m0009DocumentTemplate{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&m0009DocumentTemplate{})
+		},
+	},
+	{
+		ID: "0010_add_generated_forms",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&m0010GeneratedForm{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&m0010GeneratedForm{})
+		},
+	},
+	{
+		ID: "0011_add_review_states",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&m0011ReviewTransition{}); err != nil {
+				return err
+			}
+			for _, model := range []interface{}{&m0011EventInside{}, &m0011Done{}} {
+				if err := addColumnIfMissing(tx, model, "State"); err != nil {
+					return err
+				}
+				if err := addColumnIfMissing(tx, model, "StateChangedAt"); err != nil {
+					return err
+				}
+			}
+			return migrateReviewStates(tx)
+		},
+		Down: restoreReviewStatusColumns,
+	},
+	{
+		ID: "0012_add_outside_review",
+		Up: func(tx *gorm.DB) error {
+			backfill := !tx.Migrator().HasColumn(&m0012EventOutside{}, "State")
+			for _, field := range []string{"Certifier", "State", "StateChangedAt", "Comment"} {
+				if err := addColumnIfMissing(tx, &m0012EventOutside{}, field); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasConstraint(&m0012EventOutside{}, "Teacher") {
+				if err := tx.Migrator().CreateConstraint(&m0012EventOutside{}, "Teacher"); err != nil {
+					return err
+				}
+			}
+			if !backfill {
+				return nil
+			}
+			// ชั่วโมงภายนอกที่บันทึกไว้ก่อนมีการตรวจถูกนับไปแล้ว จึงถือว่าอนุมัติ
+			return tx.Model(&m0012EventOutside{}).Where("1 = 1").
+				Update("state", entity.StateApproved).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasConstraint(&m0012EventOutside{}, "Teacher") {
+				if err := tx.Migrator().DropConstraint(&m0012EventOutside{}, "Teacher"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"Comment", "StateChangedAt", "State", "Certifier"} {
+				if err := dropColumnIfExists(tx, &m0012EventOutside{}, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// audit_logs เพิ่มได้อย่างเดียว (MySQL ป้องกันการแก้ไข/ลบด้วย trigger)
+		ID: "0013_add_audit_logs",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&m0013AuditLog{}); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			for _, stmt := range []string{
+				"DROP TRIGGER IF EXISTS audit_logs_no_update",
+				`CREATE TRIGGER audit_logs_no_update
+				BEFORE UPDATE ON audit_logs
+				FOR EACH ROW
+				SIGNAL SQLSTATE '45000'
+				SET MESSAGE_TEXT = 'audit_logs is append-only'`,
+				"DROP TRIGGER IF EXISTS audit_logs_no_delete",
+				`CREATE TRIGGER audit_logs_no_delete
+				BEFORE DELETE ON audit_logs
+				FOR EACH ROW
+				SIGNAL SQLSTATE '45000'
+				SET MESSAGE_TEXT = 'audit_logs is append-only'`,
+			} {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() == "mysql" {
+				if err := tx.Exec("DROP TRIGGER IF EXISTS audit_logs_no_update").Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("DROP TRIGGER IF EXISTS audit_logs_no_delete").Error; err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&m0013AuditLog{})
+		},
+	},
+	{
+		// คอลัมน์ file เก็บ key ของ storage ("12/<uuid>.pdf") แทน path บนเครื่อง ("./uploads/12/<uuid>.pdf")
+		ID: "0014_use_storage_keys",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range []string{"event_insides", "event_outsides"} {
+				err := tx.Table(table).Where("file LIKE ?", legacyUploadDir+"%").
+					Update("file", gorm.Expr("SUBSTR(file, ?)", len(legacyUploadDir)+1)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range []string{"event_insides", "event_outsides"} {
+				err := tx.Table(table).Where("file <> ''").
+					Update("file", gorm.Expr("CONCAT(?, file)", legacyUploadDir)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID: "0015_add_evidence_thumbnails",
+		Up: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&m0015EventInside{}, &m0015EventOutside{}} {
+				if err := addColumnIfMissing(tx, model, "Thumbnail"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&m0015EventInside{}, &m0015EventOutside{}} {
+				if err := dropColumnIfExists(tx, model, "Thumbnail"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// trigger ของ 0002 และ 0013 ในรูปแบบ PostgreSQL (ฐานข้อมูลอื่นไม่ทำอะไร)
+		ID: "0016_create_postgres_triggers",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "postgres" {
+				return nil
+			}
+			for _, stmt := range postgresTriggers {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "postgres" {
+				return nil
+			}
+			for _, stmt := range []string{
+				"DROP TRIGGER IF EXISTS before_insert_students ON students",
+				"DROP TRIGGER IF EXISTS before_insert_teachers ON teachers",
+				"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+				"DROP FUNCTION IF EXISTS before_insert_students()",
+				"DROP FUNCTION IF EXISTS before_insert_teachers()",
+				"DROP FUNCTION IF EXISTS audit_logs_append_only()",
+			} {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// 0003-0008 เดิมสร้างจาก entity ซึ่ง gorm เดาความสัมพันธ์เป็น has one และสร้าง foreign key กลับด้านไว้บนตารางหลัก
+		// (ฐานข้อมูลที่ติดตั้งใหม่ไม่มีปัญหานี้ ขั้นตอนนี้จึงไม่ทำอะไร)
+		ID:   "0017_fix_reversed_foreign_keys",
+		Up:   fixReversedForeignKeys,
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+}
+
+// PostgreSQL สร้าง trigger จาก function แยกกัน และรันได้ครั้งละหนึ่งคำสั่ง
+var postgresTriggers = []string{
+	`CREATE OR REPLACE FUNCTION before_insert_students() RETURNS TRIGGER AS $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM teachers WHERE user_id = NEW.user_id) THEN
+			RAISE EXCEPTION 'User ID already exists in teachers';
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql`,
+	"DROP TRIGGER IF EXISTS before_insert_students ON students",
+	`CREATE TRIGGER before_insert_students
+	BEFORE INSERT ON students
+	FOR EACH ROW EXECUTE FUNCTION before_insert_students()`,
+	`CREATE OR REPLACE FUNCTION before_insert_teachers() RETURNS TRIGGER AS $$
+	BEGIN
+		IF EXISTS (SELECT 1 FROM students WHERE user_id = NEW.user_id) THEN
+			RAISE EXCEPTION 'User ID already exists in students';
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql`,
+	"DROP TRIGGER IF EXISTS before_insert_teachers ON teachers",
+	`CREATE TRIGGER before_insert_teachers
+	BEFORE INSERT ON teachers
+	FOR EACH ROW EXECUTE FUNCTION before_insert_teachers()`,
+	`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_logs is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+	"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+	`CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+}
+
+// โฟลเดอร์ที่ใช้เก็บไฟล์หลักฐานก่อนมี storage
+const legacyUploadDir = "./uploads/"
+
+func addColumnIfMissing(tx *gorm.DB, model interface{}, field string) error {
+	if tx.Migrator().HasColumn(model, field) {
+		return nil
+	}
+	return tx.Migrator().AddColumn(model, field)
+}
+
+func dropColumnIfExists(tx *gorm.DB, model interface{}, field string) error {
+	if !tx.Migrator().HasColumn(model, field) {
+		return nil
+	}
+	return tx.Migrator().DropColumn(model, field)
+}
+
+// foreign key ที่ 0003-0008 เดิมสร้างผิดตาราง (ชื่อ constraint อยู่บนตาราง table)
+var reversedForeignKeys = []struct {
+	table string
+	name  string
+}{
+	{"users", "fk_sessions_user"},
+	{"users", "fk_password_resets_user"},
+	{"faculties", "fk_activity_rules_faculty"},
+	{"branches", "fk_activity_rules_branch"},
+	{"branches", "fk_event_branches_branch"},
+	{"events", "fk_event_branches_event"},
+	{"events", "fk_event_years_event"},
+	// ทิศทางถูกแต่ชื่อต่างจากที่ฐานข้อมูลใหม่ได้ จึงลบแล้วสร้างใหม่ด้วยชื่อเดียวกัน
+	{"event_branches", "fk_events_branches"},
+	{"event_years", "fk_events_years"},
+}
+
+// ลบ foreign key ที่กลับด้าน แล้วสร้างที่ถูกต้องบนตารางลูกถ้ายังไม่มี
+func fixReversedForeignKeys(tx *gorm.DB) error {
+	for _, fk := range reversedForeignKeys {
+		if !tx.Migrator().HasConstraint(fk.table, fk.name) {
+			continue
+		}
+		if err := tx.Migrator().DropConstraint(fk.table, fk.name); err != nil {
+			return err
+		}
+	}
+	relations := []struct {
+		model interface{}
+		field string
+	}{
+		{&m0006Session{}, "User"},
+		{&m0007PasswordReset{}, "User"},
+		{&m0003ActivityRule{}, "Faculty"},
+		{&m0003ActivityRule{}, "Branch"},
+		{&m0008EventBranch{}, "Branch"},
+		{&m0008EventBranch{}, "Event"},
+		{&m0008EventYear{}, "Event"},
+	}
+	for _, relation := range relations {
+		if tx.Migrator().HasConstraint(relation.model, relation.field) {
+			continue
+		}
+		if err := tx.Migrator().CreateConstraint(relation.model, relation.field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ย้ายสิทธิ์สาขา/ชั้นปีจากคอลัมน์ JSON เดิม (events.branch_ids, events.years)
+// ไปยังตาราง event_branches และ event_years แล้วลบคอลัมน์เดิมทิ้ง
+func migrateEventPermissions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	hasBranches := migrator.HasColumn("events", "branch_ids")
+	hasYears := migrator.HasColumn("events", "years")
+	if !hasBranches && !hasYears {
+		return nil
+	}
+
+	columns := []string{"event_id"}
+	if hasBranches {
+		columns = append(columns, "branch_ids")
+	}
+	if hasYears {
+		columns = append(columns, "years")
+	}
+	var rows []struct {
+		EventID   uint
+		BranchIDs sql.NullString
+		Years     sql.NullString
+	}
+	if err := db.Table("events").Select(columns).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		branchIDs, err := utility.DecodeIDs(row.BranchIDs.String)
+		if err != nil {
+			return fmt.Errorf("event %d: invalid branch_ids: %w", row.EventID, err)
+		}
+		for _, branchID := range branchIDs {
+			var count int64
+			if err := db.Model(&baselineBranch{}).Where("branch_id = ?", branchID).Count(&count).Error; err != nil {
+				return err
+			}
+			// สาขาที่ถูกลบไปแล้วไม่สามารถผูก foreign key ได้
+			if count == 0 {
+				log.Printf("event %d: branch %d no longer exists, skipped", row.EventID, branchID)
+				continue
+			}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&m0008EventBranch{EventID: row.EventID, BranchID: branchID}).Error; err != nil {
+				return err
+			}
+		}
+
+		years, err := utility.DecodeIDs(row.Years.String)
+		if err != nil {
+			return fmt.Errorf("event %d: invalid years: %w", row.EventID, err)
+		}
+		for _, year := range years {
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&m0008EventYear{EventID: row.EventID, Year: year}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if hasBranches {
+		if err := db.Exec("ALTER TABLE events DROP COLUMN branch_ids").Error; err != nil {
+			return err
+		}
+	}
+	if hasYears {
+		if err := db.Exec("ALTER TABLE events DROP COLUMN years").Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Migrated permissions of %d events to event_branches/event_years", len(rows))
+	return nil
+}
+
+// ย้อนกลับ 0008: สร้างคอลัมน์ JSON เดิมจากตาราง event_branches/event_years แล้วลบตารางทิ้ง
+func restoreEventPermissionColumns(tx *gorm.DB) error {
+	if err := tx.Exec("ALTER TABLE events ADD COLUMN branch_ids JSON").Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("ALTER TABLE events ADD COLUMN years JSON").Error; err != nil {
+		return err
+	}
+
+	var eventIDs []uint
+	if err := tx.Table("events").Pluck("event_id", &eventIDs).Error; err != nil {
+		return err
+	}
+	for _, eventID := range eventIDs {
+		var branchIDs, years []uint
+		if err := tx.Model(&m0008EventBranch{}).Where("event_id = ?", eventID).Order("branch_id").Pluck("branch_id", &branchIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&m0008EventYear{}).Where("event_id = ?", eventID).Order("year").Pluck("year", &years).Error; err != nil {
+			return err
+		}
+		branchData, err := json.Marshal(branchIDs)
+		if err != nil {
+			return err
+		}
+		yearData, err := json.Marshal(years)
+		if err != nil {
+			return err
+		}
+		if err := tx.Table("events").Where("event_id = ?", eventID).
+			Updates(map[string]interface{}{"branch_ids": string(branchData), "years": string(yearData)}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Migrator().DropTable(&m0008EventYear{}, &m0008EventBranch{})
+}
+
+// แปลง status (bool) + comment เดิมเป็น state แล้วลบคอลัมน์ status ทิ้ง
+//   - event_insides: อนุมัติ -> approved, มี comment -> resubmission_requested,
+//     มีไฟล์หลักฐาน -> evidence_submitted, นอกนั้น -> joined
+//   - dones: อนุมัติ -> approved, มี comment -> resubmission_requested, นอกนั้น -> evidence_submitted
+//
+// ระบบเดิมให้ส่งหลักฐาน/ส่งตรวจใหม่ได้หลังไม่อนุมัติ จึงไม่แปลงเป็น rejected ซึ่งเป็นสถานะสุดท้าย
+func migrateReviewStates(db *gorm.DB) error {
+	if db.Migrator().HasColumn("event_insides", "status") {
+		if err := db.Exec(`UPDATE event_insides SET state = CASE
+			WHEN status = ? THEN ?
+			WHEN COALESCE(comment, '') <> '' THEN ?
+			WHEN COALESCE(file, '') <> '' THEN ?
+			ELSE ? END`,
+			true, entity.StateApproved, entity.StateResubmissionRequested, entity.StateEvidenceSubmitted, entity.StateJoined).Error; err != nil {
+			return err
+		}
+		if err := dropColumnIfExists(db, &m0011EventInside{}, "status"); err != nil {
+			return err
+		}
+	}
+	if db.Migrator().HasColumn("dones", "status") {
+		if err := db.Exec(`UPDATE dones SET state = CASE
+			WHEN status = ? THEN ?
+			WHEN COALESCE(comment, '') <> '' THEN ?
+			ELSE ? END`,
+			true, entity.StateApproved, entity.StateResubmissionRequested, entity.StateEvidenceSubmitted).Error; err != nil {
+			return err
+		}
+		if err := dropColumnIfExists(db, &m0011Done{}, "status"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ย้อนกลับ 0011: สร้างคอลัมน์ status จาก state (approved = true) แล้วลบคอลัมน์ state
+func restoreReviewStatusColumns(tx *gorm.DB) error {
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"event_insides", &m0011EventInside{}},
+		{"dones", &m0011Done{}},
+	}
+	for _, table := range tables {
+		if !tx.Migrator().HasColumn(table.model, "status") {
+			if err := tx.Exec("ALTER TABLE ? ADD COLUMN ? BOOLEAN DEFAULT FALSE",
+				clause.Table{Name: table.name}, clause.Column{Name: "status"}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(table.model).Where("1 = 1").
+			Update("status", gorm.Expr("state = ?", entity.StateApproved)).Error; err != nil {
+			return err
+		}
+		if err := dropColumnIfExists(tx, table.model, "StateChangedAt"); err != nil {
+			return err
+		}
+		if err := dropColumnIfExists(tx, table.model, "State"); err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().DropTable(&m0011ReviewTransition{})
+}
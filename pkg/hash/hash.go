@@ -1,7 +1,13 @@
 package hash
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math/big"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,3 +27,36 @@ func CheckPasswordHash(password, hash string) bool {
     err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
     return err == nil
 }
+
+// สร้าง token แบบสุ่มสำหรับ refresh token / reset token
+func GenerateRandomToken(size int) (string, error) {
+    buf := make([]byte, size)
+    if _, err := rand.Read(buf); err != nil {
+        return "", fmt.Errorf("failed to generate token: %w", err)
+    }
+    return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// token ที่เก็บในฐานข้อมูลเก็บเป็นค่า SHA-256 เท่านั้น
+func HashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// ตัวอักษรของรหัสผ่านเริ่มต้น (ตัด 0/O, 1/l/I ที่อ่านสับสนออก)
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// สร้างรหัสผ่านแบบสุ่มสำหรับบัญชีที่ผู้ดูแลสร้างให้
+// ใช้ rand.Int แทนการ mod ไบต์สุ่ม เพื่อให้ทุกตัวอักษรมีโอกาสเท่ากัน
+func GeneratePassword(length int) (string, error) {
+    max := big.NewInt(int64(len(passwordAlphabet)))
+    buf := make([]byte, length)
+    for i := range buf {
+        n, err := rand.Int(rand.Reader, max)
+        if err != nil {
+            return "", fmt.Errorf("failed to generate password: %w", err)
+        }
+        buf[i] = passwordAlphabet[n.Int64()]
+    }
+    return string(buf), nil
+}
@@ -1,6 +1,9 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"time"
 
@@ -18,14 +21,28 @@ func NewJWTService(cfg *config.Config) *JWTService {
 	}
 }
 
+// อายุของ access token และ refresh token
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 func (j *JWTService) GenerateJWT(userID uint, role string) (string, error) {
+	return j.GenerateAccessToken(userID, role, "")
+}
+
+// access token อายุสั้น ผูกกับ session (sid) เพื่อให้เพิกถอนได้จากฝั่ง server
+func (j *JWTService) GenerateAccessToken(userID uint, role string, sessionID string) (string, error) {
 
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"exp":     time.Now().Add(AccessTokenTTL).Unix(),
 		"iat":     time.Now().Unix(), 
 	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -38,6 +55,46 @@ func (j *JWTService) GenerateJWT(userID uint, role string) (string, error) {
 	return signedToken, nil
 }
 
+// token สำหรับเช็คชื่อเข้าร่วมกิจกรรมผ่าน QR code ใช้ secret เดียวกับ token เข้าสู่ระบบ
+// แต่ไม่มี role จึงใช้ผ่าน JWTMiddleware ไม่ได้
+func (j *JWTService) GenerateCheckinToken(eventID uint, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"event_id": eventID,
+		"purpose":  "checkin",
+		"exp":      time.Now().Add(ttl).Unix(),
+		"iat":      time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(j.SecretKey))
+}
+
+func (j *JWTService) ValidateCheckinToken(tokenString string) (uint, error) {
+	claims, err := j.ValidateJWT(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != "checkin" {
+		return 0, errors.New("invalid check-in token")
+	}
+	eventID, ok := claims["event_id"].(float64)
+	if !ok {
+		return 0, errors.New("invalid check-in token")
+	}
+	return uint(eventID), nil
+}
+
+// ลายเซ็นของเลขเอกสาร ใช้ใน URL ตรวจสอบเอกสารที่พิมพ์ออกไป (ไม่มีวันหมดอายุ)
+func (j *JWTService) SignSerial(serial string) string {
+	mac := hmac.New(sha256.New, []byte(j.SecretKey))
+	mac.Write([]byte("form:" + serial))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (j *JWTService) VerifySerial(serial string, signature string) bool {
+	return hmac.Equal([]byte(j.SignSerial(serial)), []byte(signature))
+}
+
 func (j *JWTService) ValidateJWT(tokenString string) (jwt.MapClaims, error) {
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
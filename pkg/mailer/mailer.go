@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"fmt"
+	"go-clean-arch/config"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Mailer ส่งอีเมลออกจากระบบ (เช่น ลิงก์รีเซ็ตรหัสผ่าน)
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// เลือก Mailer ตาม MAIL_DRIVER (log หรือ file) สำหรับใช้งานในเครื่อง
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "", "log":
+		return &logMailer{}, nil
+	case "file":
+		return NewFileMailer(cfg.Mail.Dir)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Mail.Driver)
+	}
+}
+
+type logMailer struct{}
+
+func (m *logMailer) Send(to string, subject string, body string) error {
+	log.Printf("mail to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// เขียนอีเมลแต่ละฉบับเป็นไฟล์ .eml ในโฟลเดอร์ที่กำหนด
+type fileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) (Mailer, error) {
+	if dir == "" {
+		dir = "./mails"
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &fileMailer{dir: dir}, nil
+}
+
+func (m *fileMailer) Send(to string, subject string, body string) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.Format("20060102-150405"), now.UnixNano())
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		to, subject, now.Format(time.RFC1123Z), body)
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
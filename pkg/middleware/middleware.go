@@ -7,7 +7,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func JWTMiddleware(jwt *jwt.JWTService) fiber.Handler {
+// revocation เป็น nil ได้ (ไม่ตรวจการเพิกถอน session)
+func JWTMiddleware(jwt *jwt.JWTService, revocation RevocationChecker) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		// ดึง token จาก Cookie
 		tokenString := ctx.Cookies("token")
@@ -33,6 +34,27 @@ func JWTMiddleware(jwt *jwt.JWTService) fiber.Handler {
 			})
 		}
 
+		// ตรวจสอบว่า session ยังไม่ถูกเพิกถอน (logout / เปลี่ยน role)
+		if revocation != nil {
+			sessionID, _ := claims["sid"].(string)
+			if sessionID == "" {
+				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Invalid token: session not found",
+				})
+			}
+			revoked, err := revocation.IsRevoked(sessionID)
+			if err != nil {
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Unable to verify session",
+				})
+			}
+			if revoked {
+				return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Session has been revoked",
+				})
+			}
+		}
+
 		// เก็บ claims และ role ลงใน Locals
 		ctx.Locals("claims", claims)
 		ctx.Locals("role", role) // ใช้ใน RoleMiddleware
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +20,7 @@ func TestJWTMiddleware(t *testing.T) {
 
 	// Create a Fiber app
 	app := fiber.New()
-	app.Use(JWTMiddleware(jwtService))
+	app.Use(JWTMiddleware(jwtService, nil))
 
 	// Create a protected route
 	app.Get("/protected", func(c *fiber.Ctx) error {
@@ -50,6 +51,90 @@ func TestJWTMiddleware(t *testing.T) {
 		resp, _ := app.Test(req, -1)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
+
+	t.Run("Check-in token is not a login token", func(t *testing.T) {
+		token, _ := jwtService.GenerateCheckinToken(1, time.Minute)
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Cookie", "token="+token)
+
+		resp, _ := app.Test(req, -1)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+type fakeRevocation map[string]bool
+
+func (f fakeRevocation) IsRevoked(sessionID string) (bool, error) {
+	return f[sessionID], nil
+}
+
+// TestJWTMiddlewareRevocation tests the session revocation check
+func TestJWTMiddlewareRevocation(t *testing.T) {
+	jwtService := &jwt.JWTService{
+		SecretKey: "test-secret",
+	}
+	revocation := fakeRevocation{"revoked-session": true}
+	cache := NewRevocationCache(revocation, time.Minute)
+
+	app := fiber.New()
+	app.Use(JWTMiddleware(jwtService, cache))
+	app.Get("/protected", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message": "Authorized",
+		})
+	})
+
+	t.Run("Active session", func(t *testing.T) {
+		token, _ := jwtService.GenerateAccessToken(1, "admin", "active-session")
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Cookie", "token="+token)
+		resp, _ := app.Test(req, -1)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Revoked session", func(t *testing.T) {
+		token, _ := jwtService.GenerateAccessToken(1, "admin", "revoked-session")
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Cookie", "token="+token)
+		resp, _ := app.Test(req, -1)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Token without session", func(t *testing.T) {
+		token, _ := jwtService.GenerateJWT(1, "admin")
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Cookie", "token="+token)
+		resp, _ := app.Test(req, -1)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Eviction applies revocation immediately", func(t *testing.T) {
+		status := func(sessionID string) int {
+			token, _ := jwtService.GenerateAccessToken(1, "admin", sessionID)
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Cookie", "token="+token)
+			resp, _ := app.Test(req, -1)
+			return resp.StatusCode
+		}
+		assert.Equal(t, http.StatusOK, status("logout-session"))
+		assert.Equal(t, http.StatusOK, status("role-session"))
+
+		revocation["logout-session"] = true
+		revocation["role-session"] = true
+		// ยังใช้ผลใน cache จนกว่าจะ evict
+		assert.Equal(t, http.StatusOK, status("logout-session"))
+
+		cache.Evict("logout-session")
+		assert.Equal(t, http.StatusUnauthorized, status("logout-session"))
+		assert.Equal(t, http.StatusOK, status("role-session"))
+
+		cache.EvictAll()
+		assert.Equal(t, http.StatusUnauthorized, status("role-session"))
+	})
 }
 
 // TestRoleMiddleware tests the RoleMiddleware
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"go-clean-arch/pkg/jwt"
+	"sync"
+	"time"
+)
+
+// ตรวจสอบว่า session ของ token ถูกเพิกถอนแล้วหรือไม่
+type RevocationChecker interface {
+	IsRevoked(sessionID string) (bool, error)
+}
+
+type revocationEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// cache ในหน่วยความจำเพื่อไม่ต้อง query ฐานข้อมูลทุก request
+// ผลที่ยังไม่ถูกเพิกถอนเก็บไว้ไม่นาน (ttl) ส่วนผลที่ถูกเพิกถอนเก็บไว้จน access token หมดอายุ
+// ผู้ที่เพิกถอน session ต้องเรียก Evict/EvictAll เพื่อให้มีผลทันทีโดยไม่ต้องรอ ttl
+type RevocationCache struct {
+	checker    RevocationChecker
+	ttl        time.Duration
+	revokedTTL time.Duration
+	mu         sync.Mutex
+	entries    map[string]revocationEntry
+	// เพิ่มทุกครั้งที่ evict ผลที่ query มาก่อน evict จะไม่ถูกเก็บลง cache
+	generation uint64
+}
+
+func NewRevocationCache(checker RevocationChecker, ttl time.Duration) *RevocationCache {
+	return &RevocationCache{
+		checker:    checker,
+		ttl:        ttl,
+		revokedTTL: jwt.AccessTokenTTL,
+		entries:    make(map[string]revocationEntry),
+	}
+}
+
+func (c *RevocationCache) IsRevoked(sessionID string) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[sessionID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.checker.IsRevoked(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := c.ttl
+	if revoked {
+		ttl = c.revokedTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return revoked, nil
+	}
+	// ลบรายการที่หมดอายุแล้วเป็นครั้งคราวไม่ให้ map โตเรื่อยๆ
+	if len(c.entries) >= 10000 {
+		for id, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.entries[sessionID] = revocationEntry{revoked: revoked, expiresAt: now.Add(ttl)}
+	return revoked, nil
+}
+
+// ลบผลของ session ที่เพิ่งถูกเพิกถอน (เช่น logout)
+func (c *RevocationCache) Evict(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.entries, sessionID)
+}
+
+// ลบผลทั้งหมด ใช้เมื่อเพิกถอนทุก session ของผู้ใช้ (เปลี่ยน role/รหัสผ่าน) เพราะ cache ไม่ได้เก็บว่า session เป็นของใคร
+func (c *RevocationCache) EvictAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = make(map[string]revocationEntry)
+}
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamdScanner ส่งไฟล์ให้ clamd (ClamAV daemon) ตรวจด้วยคำสั่ง INSTREAM
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// ขนาดข้อมูลต่อ chunk ที่ส่งให้ clamd (ต้องไม่เกิน StreamMaxLength ของ clamd)
+const clamdChunkSize = 64 * 1024
+
+// addr รูปแบบ "tcp://host:3310", "unix:///run/clamav/clamd.ctl" หรือ "host:3310"
+func NewClamdScanner(addr string) (*ClamdScanner, error) {
+	s := &ClamdScanner{network: "tcp", address: addr, timeout: time.Minute}
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		s.network, s.address = "unix", strings.TrimPrefix(addr, "unix://")
+	case strings.HasPrefix(addr, "tcp://"):
+		s.address = strings.TrimPrefix(addr, "tcp://")
+	}
+	if s.address == "" {
+		return nil, fmt.Errorf("clamd address is required")
+	}
+	return s, nil
+}
+
+func (s *ClamdScanner) Scan(content io.Reader) error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return err
+	}
+
+	// INSTREAM: ส่งข้อมูลเป็น chunk นำหน้าด้วยความยาว 4 ไบต์ (big-endian) แล้วปิดด้วย chunk ยาว 0
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, buf[:n]...)); err != nil {
+				return fmt.Errorf("failed to send to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamdReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// คำตอบเช่น "stream: OK", "stream: Eicar-Signature FOUND", "INSTREAM size limit exceeded. ERROR"
+func parseClamdReply(reply string) error {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSuffix(result, " FOUND"))
+	default:
+		return fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
@@ -0,0 +1,36 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/config"
+	"io"
+)
+
+// ErrInfected โปรแกรมสแกนพบมัลแวร์ในไฟล์
+var ErrInfected = errors.New("file is infected")
+
+// Scanner ตรวจไฟล์ที่อัปโหลดก่อนเก็บลง storage คืน ErrInfected (wrap ชื่อที่ตรวจพบ) ถ้าไม่ผ่าน
+// error อื่นหมายถึงสแกนไม่สำเร็จ เช่น ต่อโปรแกรมสแกนไม่ได้
+type Scanner interface {
+	Scan(content io.Reader) error
+}
+
+// เลือก Scanner ตาม SCANNER_DRIVER (none หรือ clamd)
+func NewScanner(cfg *config.Config) (Scanner, error) {
+	switch cfg.Scanner.Driver {
+	case "", "none":
+		return &nopScanner{}, nil
+	case "clamd":
+		return NewClamdScanner(cfg.Scanner.Addr)
+	default:
+		return nil, fmt.Errorf("unsupported scanner driver: %s", cfg.Scanner.Driver)
+	}
+}
+
+// ไม่สแกน ใช้เมื่อไม่ได้ติดตั้งโปรแกรมสแกนไวรัส
+type nopScanner struct{}
+
+func (s *nopScanner) Scan(content io.Reader) error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clamd จำลอง: รับ INSTREAM แล้วตอบว่าติดไวรัสถ้าข้อมูลมีคำว่า EICAR
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				cmd := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var body bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&body, conn, int64(size)); err != nil {
+						return
+					}
+				}
+				if strings.Contains(body.String(), "EICAR") {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// TestClamdScanner tests scanning uploads through the clamd INSTREAM protocol
+func TestClamdScanner(t *testing.T) {
+	s, err := NewClamdScanner("tcp://" + fakeClamd(t))
+	assert.NoError(t, err)
+
+	t.Run("Clean file passes", func(t *testing.T) {
+		// ใหญ่กว่า chunk เดียวเพื่อให้ส่งหลาย chunk
+		assert.NoError(t, s.Scan(bytes.NewReader(bytes.Repeat([]byte("%PDF-1.7 "), clamdChunkSize))))
+	})
+
+	t.Run("Infected file is reported with signature", func(t *testing.T) {
+		err := s.Scan(strings.NewReader("X5O!P%@AP EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+		assert.ErrorIs(t, err, ErrInfected)
+		assert.ErrorContains(t, err, "Eicar-Signature")
+	})
+
+	t.Run("Unreachable daemon is an error but not infected", func(t *testing.T) {
+		down, err := NewClamdScanner("127.0.0.1:1")
+		assert.NoError(t, err)
+		err = down.Scan(strings.NewReader("x"))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInfected)
+	})
+
+	t.Run("Error replies are not treated as clean", func(t *testing.T) {
+		assert.Error(t, parseClamdReply("INSTREAM size limit exceeded. ERROR"))
+		assert.NoError(t, parseClamdReply("stream: OK"))
+	})
+}
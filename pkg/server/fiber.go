@@ -5,6 +5,9 @@ import (
 	"go-clean-arch/config"
 	"go-clean-arch/database"
 	"go-clean-arch/pkg/jwt"
+	"go-clean-arch/pkg/mailer"
+	"go-clean-arch/pkg/scanner"
+	"go-clean-arch/pkg/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -27,16 +30,29 @@ func NewServer(cfg *config.Config, db database.Database, jwt *jwt.JWTService) (S
 	})
 	setupCors(app)
 
-	// กำหนด static files
-	app.Static("/uploads", "./uploads")
-
 	// กำหนด middleware สำหรับการกู้คืนจาก panic
 	app.Use(recover.New())
 
 	// กำหนด middleware สำหรับการบันทึก log ของการร้องขอ
 	app.Use(logger.New())
 
-	SetupRoutes(app,jwt,db)
+	mail, err := mailer.NewMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// ไฟล์หลักฐานไม่เปิดเป็น static แล้ว ดาวน์โหลดผ่าน endpoint ที่ตรวจสิทธิ์หรือลิงก์ที่มีลายเซ็น
+	store, err := storage.NewStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	scan, err := scanner.NewScanner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	SetupRoutes(app, cfg, jwt, db, mail, store, scan)
 
 	return &fiberServer{
 		app:  app,
@@ -1,39 +1,72 @@
 package server
 
 import (
+	"go-clean-arch/config"
 	"go-clean-arch/controller"
 	"go-clean-arch/database"
 	"go-clean-arch/pkg/jwt"
+	"go-clean-arch/pkg/mailer"
 	"go-clean-arch/pkg/middleware"
+	"go-clean-arch/pkg/scanner"
+	"go-clean-arch/pkg/storage"
+	"go-clean-arch/pkg/utility/filesystem"
 	"go-clean-arch/repository"
 	"go-clean-arch/usecase"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRoutes(app *fiber.App, jwt *jwt.JWTService, db database.Database) {
+func SetupRoutes(app *fiber.App, cfg *config.Config, jwt *jwt.JWTService, db database.Database, mail mailer.Mailer, store storage.Storage, scan scanner.Scanner) {
 	// repository
 	userRepo := repository.NewUserRepository(db.GetDB())
 	facBranRepo := repository.NewFacultyRepositiry(db.GetDB())
 	eventRepo := repository.NewEventRepository(db.GetDB())
+	ruleRepo := repository.NewRuleRepository(db.GetDB())
+	sessionRepo := repository.NewSessionRepository(db.GetDB())
+	templateRepo := repository.NewTemplateRepository(db.GetDB())
+	statsRepo := repository.NewStatsRepository(db.GetDB())
+	auditRepo := repository.NewAuditRepository(db.GetDB())
+
+	// cache การเพิกถอน session ใช้ร่วมกันระหว่าง middleware และ usecase ที่เพิกถอน session
+	revocation := middleware.NewRevocationCache(sessionRepo, 30*time.Second)
 
 	// usecase
-	userUsecase := usecase.NewUserUsecase(userRepo, *jwt)
-	facBranUsecase := usecase.NewFacultyUsecase(facBranRepo)
-	eventUsecase := usecase.NewEventUsecase(userRepo, facBranRepo, eventRepo)
+	userUsecase := usecase.NewUserUsecase(userRepo, ruleRepo, sessionRepo, revocation, facBranRepo, auditRepo, mail, cfg.ResetPasswordURL, *jwt)
+	facBranUsecase := usecase.NewFacultyUsecase(facBranRepo, auditRepo)
+	pdfLimits := filesystem.PDFLimits{MaxPages: cfg.Evidence.MaxPages, MaxPageSide: cfg.Evidence.MaxPageSide}
+	eventUsecase := usecase.NewEventUsecase(userRepo, facBranRepo, eventRepo, ruleRepo, templateRepo, auditRepo, store, scan, pdfLimits, cfg.VerifyURL, *jwt)
+	ruleUsecase := usecase.NewRuleUsecase(ruleRepo, userRepo, facBranRepo, auditRepo)
+	templateUsecase := usecase.NewTemplateUsecase(templateRepo, auditRepo)
+	statsUsecase := usecase.NewStatsUsecase(statsRepo, ruleRepo, facBranRepo)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
 
 	// controller
 	userContro := controller.NewUserController(userUsecase)
 	facBranContro := controller.NewFacultyController(facBranUsecase)
 	eventContro := controller.NewEventController(eventUsecase)
+	ruleContro := controller.NewRuleController(ruleUsecase)
+	templateContro := controller.NewTemplateController(templateUsecase)
+	statsContro := controller.NewStatsController(statsUsecase)
+	auditContro := controller.NewAuditController(auditUsecase)
 
 	// login&register
 	app.Post("/register/teacher", userContro.RegisterTeacher)
 	app.Post("/register/student", userContro.RegisterStudent)
 	app.Post("/login", userContro.Login)
+	app.Post("/refresh", userContro.RefreshToken)
+	app.Post("/password/forgot", userContro.ForgotPassword)
+	app.Post("/password/reset", userContro.ResetPassword)
+
+	// ลิงก์ดาวน์โหลดหลักฐานแบบมีลายเซ็น (เฉพาะ STORAGE_DRIVER=local, S3 ให้ดาวน์โหลดจาก bucket โดยตรง)
+	if local, ok := store.(*storage.LocalStorage); ok {
+		app.Get(storage.LocalPrefix+"*", controller.NewFileController(local).ServeSigned)
+	}
 
 	// middleware
-	protected := app.Group("/protected", middleware.JWTMiddleware(jwt))
+	protected := app.Group("/protected", middleware.JWTMiddleware(jwt, revocation))
+	protected.Post("/logout", userContro.Logout)
+	protected.Put("/password", userContro.ChangePassword)
 	admin := protected.Group("/admin", middleware.RoleMiddleware("superadmin", "admin"))
 	admin.Get("/admin", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -65,6 +98,25 @@ func SetupRoutes(app *fiber.App, jwt *jwt.JWTService, db database.Database) {
 	admin.Put("/branch/:id", facBranContro.UpdateBranchByID)
 	admin.Delete("/branch/:id", facBranContro.DeleteBranchByID)
 
+	// activity rule
+	admin.Post("/rule", ruleContro.CreateRule)
+	admin.Get("/rules", ruleContro.GetAllRules)
+	admin.Put("/rule/:id", ruleContro.UpdateRuleByID)
+	admin.Delete("/rule/:id", ruleContro.DeleteRuleByID)
+
+	// document template
+	admin.Post("/template", templateContro.UploadTemplate)
+	admin.Get("/templates", templateContro.GetAllTemplates)
+	admin.Get("/template/:id", templateContro.GetTemplateByID)
+	admin.Delete("/template/:id", templateContro.DeleteTemplateByID)
+
+	// statistics (แอดมินดูได้ทุกคณะ ผู้ดูแลคณะดูได้เฉพาะคณะตนเอง)
+	teacher.Get("/stats", statsContro.GetStats)
+
+	// audit log (บันทึกอย่างเดียว แก้ไข/ลบไม่ได้)
+	admin.Get("/audit-logs", auditContro.GetAuditLogs)
+	admin.Get("/audit-logs/export", auditContro.ExportAuditLogs)
+
 	// user
 	protected.Get("/userbyclaim", userContro.GetUserByClaims)
 	teacher.Get("/allteacher", userContro.GetAllTeacher)
@@ -72,6 +124,8 @@ func SetupRoutes(app *fiber.App, jwt *jwt.JWTService, db database.Database) {
 	teacher.Put("/personalinfo", userContro.UpdateTeacher)
 	student.Put("/personalinfo", userContro.UpdateStudent)
 	admin.Put("/role", userContro.UpdateRoleByID)
+	admin.Post("/students/import", userContro.ImportStudents)
+	admin.Delete("/sessions/:userid", userContro.RevokeAllSessions)
 
 	// events
 	teacher.Post("/event", eventContro.CreateEvent)
@@ -86,19 +140,36 @@ func SetupRoutes(app *fiber.App, jwt *jwt.JWTService, db database.Database) {
 	student.Get("myevents/:year", eventContro.MyEventThisYear)
 
 	// inside
+	student.Get("/eligible-events", eventContro.EligibleEvents)
 	student.Post("/joinevent/:id", eventContro.JoinEvent)
 	student.Delete("/unjoinevent/:id", eventContro.UnJoinEvent)
+	student.Post("/waitlist/:id", eventContro.JoinWaitlist)
+	student.Delete("/waitlist/:id", eventContro.LeaveWaitlist)
+	student.Get("/waitlist", eventContro.MyWaitlist)
 	student.Put("/upload/:id", eventContro.UploadFile)
 	protected.Get("/file/:eventid/:userid", eventContro.GetFile)
+	protected.Get("/file-url/:eventid/:userid", eventContro.GetFileURL)
 	teacher.Get("/checklist/:id", eventContro.MyChecklist)
+	teacher.Get("/checklist/:id/export", eventContro.ExportChecklist)
+	teacher.Get("/export/dones/:facultyid/:year", eventContro.ExportFacultyDones)
 	teacher.Put("/check/:eventid/:userid", eventContro.UpdateEventStatusAndComment)
+	teacher.Put("/check/:eventid", eventContro.ReviewParticipants)
+	teacher.Get("/checkin-qr/:id", eventContro.CreateCheckinQR)
+	student.Post("/checkin", eventContro.CheckIn)
 
 	// outside
 	student.Post("/outside", eventContro.CreateEventOutside)
 	student.Delete("/outside/:id", eventContro.DeleteEventOutsideByID)
 	student.Get("/download/:id", eventContro.CreateFile)
+	teacher.Get("/download/:id", eventContro.CreateFile)
+	app.Get("/verify/:serial", eventContro.VerifyForm)
+	student.Get("/transcript/:year", eventContro.MyTranscript)
+	teacher.Get("/transcript/:userid/:year", eventContro.StudentTranscript)
 	student.Put("/upload-outside/:id", eventContro.UploadFileOutside)
 	protected.Get("/file-outside/:eventid/:userid", eventContro.GetFileOutside)
+	protected.Get("/file-outside-url/:eventid/:userid", eventContro.GetFileOutsideURL)
+	teacher.Get("/outside-check", eventContro.OutsideReviewQueue)
+	teacher.Put("/outside-check/:eventid", eventContro.ReviewOutside)
 
 	student.Post("/send-event/:year",userContro.SendEvent)
 
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalPrefix path ของลิงก์ดาวน์โหลดที่ LocalStorage สร้าง (ต้องมี route รองรับ)
+const LocalPrefix = "/files/"
+
+// LocalStorage เก็บไฟล์ในโฟลเดอร์ของเครื่อง ใช้ได้เมื่อมีเซิร์ฟเวอร์เครื่องเดียว
+type LocalStorage struct {
+	dir       string
+	publicURL string
+	secret    []byte
+}
+
+func NewLocalStorage(dir string, publicURL string, secret []byte) (*LocalStorage, error) {
+	if dir == "" {
+		dir = "./uploads"
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("local storage requires a signing secret")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return &LocalStorage{dir: dir, publicURL: strings.TrimRight(publicURL, "/"), secret: secret}, nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// เขียนลงไฟล์ชั่วคราวก่อนแล้วค่อยเปลี่ยนชื่อ เพื่อไม่ให้มีไฟล์ที่เขียนไม่ครบ
+func (s *LocalStorage) Put(key string, body io.Reader, contentType string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) sign(key string, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ลิงก์ไปยัง LocalPrefix ของเซิร์ฟเวอร์นี้ พร้อมเวลาหมดอายุและลายเซ็น
+func (s *LocalStorage) SignedURL(key string, expiry time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, expires))
+	return s.publicURL + LocalPrefix + key + "?" + query.Encode(), nil
+}
+
+// ตรวจลิงก์จาก SignedURL
+func (s *LocalStorage) Verify(key string, expires string, signature string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid signature")
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return fmt.Errorf("invalid signature")
+	}
+	if time.Now().Unix() > unix {
+		return fmt.Errorf("link has expired")
+	}
+	return nil
+}
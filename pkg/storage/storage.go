@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/config"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound ไม่มีไฟล์ของ key ที่ระบุ
+var ErrNotFound = errors.New("file not found")
+
+// Storage เก็บไฟล์หลักฐานที่อัปโหลด อ้างถึงไฟล์ด้วย key เช่น "12/<uuid>.pdf"
+// ค่า key นี้คือค่าที่บันทึกในคอลัมน์ file ของฐานข้อมูล
+type Storage interface {
+	Put(key string, body io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	// ลบไฟล์ ถ้าไม่มีไฟล์อยู่แล้วถือว่าสำเร็จ
+	Delete(key string) error
+	// ลิงก์ดาวน์โหลดที่ใช้ได้โดยไม่ต้องเข้าสู่ระบบจนถึงเวลาหมดอายุ
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+// เลือก Storage ตาม STORAGE_DRIVER (local หรือ s3)
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "local":
+		local, err := NewLocalStorage(cfg.Storage.Dir, cfg.Storage.PublicURL, []byte(cfg.JWTSecret))
+		if err != nil {
+			return nil, err
+		}
+		return local, nil
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  cfg.Storage.Endpoint,
+			Region:    cfg.Storage.Region,
+			Bucket:    cfg.Storage.Bucket,
+			AccessKey: cfg.Storage.AccessKey,
+			SecretKey: cfg.Storage.SecretKey,
+			PathStyle: cfg.Storage.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Storage.Driver)
+	}
+}
+
+// ตรวจว่า key เป็น path สัมพัทธ์ที่ไม่ออกนอกที่เก็บ
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid storage key: %q", key)
+	}
+	return nil
+}
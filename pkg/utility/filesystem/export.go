@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// สถานะการตรวจของอาจารย์เป็นข้อความ
+func ReviewStatusText(state entity.ReviewState) string {
+	switch state {
+	case entity.StateApproved:
+		return "อนุมัติ"
+	case entity.StateRejected:
+		return "ไม่อนุมัติ"
+	case entity.StateResubmissionRequested:
+		return "ขอให้ส่งหลักฐานใหม่"
+	case entity.StateJoined:
+		return "รอส่งหลักฐาน"
+	default:
+		return "รอตรวจสอบ"
+	}
+}
+
+// เขียนตารางเป็น CSV (ขึ้นต้นด้วย UTF-8 BOM ให้ Excel อ่านภาษาไทยได้)
+func WriteCSV(w io.Writer, table response.ExportTable) error {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Header); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		if err := writer.Write(escapeFormulas(row)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ข้อมูลจากผู้ใช้ที่ขึ้นต้นด้วย = + - @ (รวมถึง tab และ CR) จะถูก Excel ตีความเป็นสูตร จึงนำหน้าด้วย ' ให้เป็นข้อความ
+func escapeFormulas(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, value := range row {
+		if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		escaped[i] = value
+	}
+	return escaped
+}
+
+// เขียนตารางเป็น XLSX หนึ่ง sheet พร้อมหัวตารางตัวหนา
+func WriteXLSX(w io.Writer, table response.ExportTable) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := sheetName(table.Name)
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	header, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	for i := range table.Header {
+		if err := stream.SetColWidth(i+1, i+1, 20); err != nil {
+			return err
+		}
+	}
+	if err := stream.SetRow("A1", cells(table.Header), excelize.RowOpts{StyleID: header}); err != nil {
+		return err
+	}
+	for i, row := range table.Rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := stream.SetRow(cell, cells(row)); err != nil {
+			return err
+		}
+	}
+	if err := stream.Flush(); err != nil {
+		return fmt.Errorf("failed to write sheet: %v", err)
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+func cells(row []string) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+	return values
+}
+
+// ชื่อ sheet ยาวได้ไม่เกิน 31 ตัวอักษร และห้ามมีอักขระ : \ / ? * [ ]
+func sheetName(name string) string {
+	runes := []rune(strings.NewReplacer(":", "-", "\\", "-", "/", "-", "?", "", "*", "", "[", "(", "]", ")").Replace(name))
+	if len(runes) == 0 {
+		return "Sheet1"
+	}
+	if len(runes) > 31 {
+		runes = runes[:31]
+	}
+	return string(runes)
+}
@@ -0,0 +1,45 @@
+package filesystem
+
+import (
+	"bytes"
+	"go-clean-arch/structure/response"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// TestExport tests writing export tables as CSV and XLSX
+func TestExport(t *testing.T) {
+	table := response.ExportTable{
+		Name:   "checklist/1",
+		Header: []string{"รหัสนักศึกษา", "ชื่อ-สกุล"},
+		Rows:   [][]string{{"6501", "นายสมชาย ใจดี"}, {"6502", "a,b"}},
+	}
+
+	t.Run("CSV starts with BOM and quotes commas", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, WriteCSV(&buf, table))
+		assert.Equal(t, "\xEF\xBB\xBFรหัสนักศึกษา,ชื่อ-สกุล\n6501,นายสมชาย ใจดี\n6502,\"a,b\"\n", buf.String())
+	})
+
+	t.Run("CSV escapes cells that start a formula", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, WriteCSV(&buf, response.ExportTable{
+			Header: []string{"หมายเหตุ"},
+			Rows:   [][]string{{"=HYPERLINK(\"http://x\")"}, {"+1"}, {"-1"}, {"@SUM(A1)"}, {"ปกติ"}},
+		}))
+		assert.Equal(t, "\xEF\xBB\xBFหมายเหตุ\n\"'=HYPERLINK(\"\"http://x\"\")\"\n'+1\n'-1\n'@SUM(A1)\nปกติ\n", buf.String())
+	})
+
+	t.Run("XLSX contains header and rows", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, WriteXLSX(&buf, table))
+
+		f, err := excelize.OpenReader(&buf)
+		assert.NoError(t, err)
+		rows, err := f.GetRows("checklist-1")
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{table.Header, table.Rows[0], table.Rows[1]}, rows)
+	})
+}
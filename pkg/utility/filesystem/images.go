@@ -0,0 +1,247 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/signintech/gopdf"
+	"golang.org/x/image/draw"
+)
+
+// ErrInvalidImage ไฟล์รูปที่อ่านไม่ได้หรือเป็นชนิดที่ระบบไม่รองรับ
+var ErrInvalidImage = errors.New("invalid image")
+
+const (
+	// ด้านยาวสูงสุดของรูปที่ใส่ใน PDF ยังอ่านตัวอักษรในรูปถ่ายเอกสารได้
+	maxEvidenceImageSide = 2000
+	// ด้านยาวของภาพย่อสำหรับแสดงในหน้ารายชื่อผู้ตรวจ
+	thumbnailSide = 320
+	// กันรูปที่ประกาศขนาดใหญ่ผิดปกติจนถอดรหัสแล้วใช้หน่วยความจำมาก
+	maxImagePixels = 50_000_000
+	imageMargin    = 20.0
+)
+
+// IsEvidenceImage ตรวจจาก magic number ว่าเป็น JPEG หรือ PNG
+func IsEvidenceImage(content []byte) bool {
+	return imageFormat(content) != ""
+}
+
+func imageFormat(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	}
+	return ""
+}
+
+// ImagesToPDF รวมรูปถ่ายหลักฐานเป็น PDF หน้า A4 รูปละหน้า คืน PDF และภาพย่อ (JPEG) ของรูปแรก
+// รูปจะถูกย่อ หมุนตาม EXIF orientation แล้วเข้ารหัสใหม่ จึงไม่มีข้อมูล EXIF (เช่น พิกัด GPS) ติดไปด้วย
+func ImagesToPDF(images [][]byte) ([]byte, []byte, error) {
+	if len(images) == 0 {
+		return nil, nil, fmt.Errorf("%w: no images", ErrInvalidImage)
+	}
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: portraitSize})
+	var thumbnail []byte
+	for i, content := range images {
+		img, orientation, err := decodeEvidenceImage(content)
+		if err != nil {
+			return nil, nil, fmt.Errorf("image %d: %w", i+1, err)
+		}
+
+		// ย่อก่อนหมุนเพื่อไม่ต้องวนทุกพิกเซลของรูปขนาดเต็ม
+		scaled := orientImage(fitImage(img, maxEvidenceImageSide), orientation)
+		var encoded bytes.Buffer
+		if err := jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if i == 0 {
+			var thumb bytes.Buffer
+			if err := jpeg.Encode(&thumb, fitImage(scaled, thumbnailSide), &jpeg.Options{Quality: 75}); err != nil {
+				return nil, nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+			}
+			thumbnail = thumb.Bytes()
+		}
+
+		if err := addImagePage(pdf, encoded.Bytes(), scaled.Bounds().Dx(), scaled.Bounds().Dy()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pdfBytes, err := pdf.GetBytesPdfReturnErr()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating PDF: %v", err)
+	}
+	return pdfBytes, thumbnail, nil
+}
+
+// หน้า A4 แนวเดียวกับรูป วางรูปกลางหน้าให้ใหญ่ที่สุดภายในขอบ
+func addImagePage(pdf *gopdf.GoPdf, jpegBytes []byte, width int, height int) error {
+	page := portraitSize
+	if width > height {
+		page = gopdf.Rect{W: portraitSize.H, H: portraitSize.W}
+	}
+	pdf.AddPageWithOption(gopdf.PageOption{PageSize: &page})
+
+	scale := min((page.W-2*imageMargin)/float64(width), (page.H-2*imageMargin)/float64(height))
+	w, h := float64(width)*scale, float64(height)*scale
+	holder, err := gopdf.ImageHolderByBytes(jpegBytes)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	if err := pdf.ImageByHolder(holder, (page.W-w)/2, (page.H-h)/2, &gopdf.Rect{W: w, H: h}); err != nil {
+		return fmt.Errorf("failed to add image: %w", err)
+	}
+	return nil
+}
+
+// ถอดรหัส JPEG/PNG โดยตรวจขนาดจาก header ก่อนถอดรหัสทั้งรูป คืนค่า EXIF orientation ของรูป JPEG ด้วย
+func decodeEvidenceImage(content []byte) (image.Image, int, error) {
+	var decodeConfig func([]byte) (image.Config, error)
+	var decode func([]byte) (image.Image, error)
+	switch imageFormat(content) {
+	case "jpeg":
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case "png":
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, 0, fmt.Errorf("%w: only JPEG and PNG images are allowed", ErrInvalidImage)
+	}
+
+	cfg, err := decodeConfig(content)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, 0, fmt.Errorf("%w: image dimensions %dx%d are not allowed", ErrInvalidImage, cfg.Width, cfg.Height)
+	}
+	img, err := decode(content)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	orientation := 1
+	if imageFormat(content) == "jpeg" {
+		orientation = jpegOrientation(content)
+	}
+	return img, orientation, nil
+}
+
+// ย่อให้ด้านยาวไม่เกิน maxSide (ไม่ขยายรูปเล็ก) บนพื้นขาว เพราะ JPEG ไม่มีความโปร่งใส
+func fitImage(img image.Image, maxSide int) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/b.Dx())
+		} else {
+			w, h = max(1, w*maxSide/b.Dy()), maxSide
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// หมุน/กลับรูปตามค่า EXIF orientation (1-8) ค่าอื่นคืนรูปเดิม
+func orientImage(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// อ่านค่า Orientation (tag 0x0112) จาก EXIF ใน segment APP1 ของ JPEG คืน 1 ถ้าไม่มี
+func jpegOrientation(content []byte) int {
+	pos := 2
+	for pos+4 <= len(content) {
+		if content[pos] != 0xFF {
+			return 1
+		}
+		marker := content[pos+1]
+		// SOS: ข้อมูลรูปเริ่มแล้ว ไม่มี metadata ต่อจากนี้
+		if marker == 0xDA {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(content[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(content) {
+			return 1
+		}
+		segment := content[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+	return 1
+}
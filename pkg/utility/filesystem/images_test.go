@@ -0,0 +1,94 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(w int, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// JPEG ที่มี segment EXIF ระบุ orientation และพิกัด GPS ปลอม
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatalf("failed to encode jpeg: %v", err)
+	}
+
+	var tiff bytes.Buffer
+	tiff.WriteString("II*\x00")
+	binary.Write(&tiff, binary.LittleEndian, uint32(8))
+	binary.Write(&tiff, binary.LittleEndian, uint16(1))
+	binary.Write(&tiff, binary.LittleEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.LittleEndian, uint32(1))
+	binary.Write(&tiff, binary.LittleEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.LittleEndian, uint32(0))
+	tiff.WriteString("GPS 13.7563N 100.5018E")
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(encoded.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(encoded.Bytes()[2:])
+	return out.Bytes()
+}
+
+// TestImagesToPDF tests converting photo evidence into a single PDF with a thumbnail
+func TestImagesToPDF(t *testing.T) {
+	t.Run("Photos become one page each and pass PDF inspection", func(t *testing.T) {
+		var pngBytes bytes.Buffer
+		assert.NoError(t, png.Encode(&pngBytes, testImage(300, 200)))
+		photo := jpegWithExif(t, testImage(2400, 1200), 1)
+
+		pdf, thumbnail, err := ImagesToPDF([][]byte{photo, pngBytes.Bytes()})
+		assert.NoError(t, err)
+		info, err := InspectPDF(pdf, DefaultPDFLimits)
+		assert.NoError(t, err)
+		if assert.NotNil(t, info) {
+			assert.Equal(t, 2, info.Pages)
+		}
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumbnail))
+		assert.NoError(t, err)
+		assert.Equal(t, thumbnailSide, cfg.Width)
+		assert.Equal(t, thumbnailSide/2, cfg.Height)
+	})
+
+	t.Run("EXIF is stripped and orientation applied", func(t *testing.T) {
+		// orientation 6 = หมุนตามเข็ม 90 องศา รูปแนวนอนกลายเป็นแนวตั้ง
+		pdf, thumbnail, err := ImagesToPDF([][]byte{jpegWithExif(t, testImage(400, 100), 6)})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(pdf), "GPS 13.7563N")
+		assert.NotContains(t, string(thumbnail), "Exif")
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumbnail))
+		assert.NoError(t, err)
+		assert.Less(t, cfg.Width, cfg.Height)
+	})
+
+	t.Run("Unsupported or broken images are rejected", func(t *testing.T) {
+		_, _, err := ImagesToPDF(nil)
+		assert.ErrorIs(t, err, ErrInvalidImage)
+		_, _, err = ImagesToPDF([][]byte{[]byte("GIF89a not supported")})
+		assert.ErrorIs(t, err, ErrInvalidImage)
+		_, _, err = ImagesToPDF([][]byte{{0xFF, 0xD8, 0xFF, 0xE0, 0x00}})
+		assert.ErrorIs(t, err, ErrInvalidImage)
+	})
+}
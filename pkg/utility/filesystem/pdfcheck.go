@@ -0,0 +1,135 @@
+package filesystem
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrInvalidPDF ไฟล์ไม่ใช่ PDF ที่ระบบยอมรับเป็นหลักฐาน
+var ErrInvalidPDF = errors.New("invalid pdf")
+
+// PDFLimits ข้อจำกัดของไฟล์หลักฐาน (ขนาดหน้าเป็นหน่วย point, 72 point = 1 นิ้ว)
+type PDFLimits struct {
+	MaxPages    int
+	MaxPageSide float64
+}
+
+// ค่าเริ่มต้น: ไม่เกิน 20 หน้า และด้านยาวของหน้าไม่เกิน A2 (1684 pt)
+var DefaultPDFLimits = PDFLimits{MaxPages: 20, MaxPageSide: 1684}
+
+// PDFInfo ข้อมูลของไฟล์ที่ผ่านการตรวจ
+type PDFInfo struct {
+	Pages int
+}
+
+// ชื่อ action ที่ทำให้ PDF รันโค้ดหรือเปิดโปรแกรมอื่นได้
+var activeContentNames = map[pdfName]bool{
+	"JavaScript": true,
+	"JS":         true,
+	"Launch":     true,
+}
+
+// จำกัดขนาดข้อมูลที่แตกจาก object stream กันไฟล์ที่บีบอัดไว้ให้ขยายใหญ่ผิดปกติ
+const maxInflatedSize = 64 * 1024 * 1024
+
+// InspectPDF ตรวจเนื้อไฟล์จริงว่าเป็น PDF ที่อ่านโครงสร้างได้ จำนวนหน้าและขนาดหน้าอยู่ในเกณฑ์
+// และไม่มี JavaScript หรือ Launch action ทั้งใน object ปกติและใน object stream ที่บีบอัดไว้
+func InspectPDF(content []byte, limits PDFLimits) (*PDFInfo, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: file is not a PDF document", ErrInvalidPDF)
+	}
+	// ไฟล์ที่อัปโหลดไม่ครบจะไม่มี %%EOF ปิดท้าย
+	tail := content[len(content)-min(len(content), 1024):]
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return nil, fmt.Errorf("%w: file is truncated", ErrInvalidPDF)
+	}
+
+	file, err := parsePDF(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unreadable PDF structure: %v", ErrInvalidPDF, err)
+	}
+	// เนื้อหาที่เข้ารหัสไว้ตรวจไม่ได้ และผู้ตรวจอาจเปิดไม่ได้
+	if file.trailerValue("Encrypt") != nil {
+		return nil, fmt.Errorf("%w: encrypted PDF files are not accepted", ErrInvalidPDF)
+	}
+	if err := file.expandObjectStreams(maxInflatedSize); err != nil {
+		return nil, fmt.Errorf("%w: unreadable PDF structure: %v", ErrInvalidPDF, err)
+	}
+	for _, v := range file.values {
+		if name, found := findNameIn(v, func(name pdfName) bool { return activeContentNames[name] }); found {
+			return nil, fmt.Errorf("%w: PDF contains active content (/%s)", ErrInvalidPDF, name)
+		}
+	}
+
+	pages, err := file.pageSizes(limits.MaxPages)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unreadable page tree: %v", ErrInvalidPDF, err)
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: PDF has no pages", ErrInvalidPDF)
+	}
+	if limits.MaxPages > 0 && len(pages) > limits.MaxPages {
+		return nil, fmt.Errorf("%w: PDF has more than %d pages", ErrInvalidPDF, limits.MaxPages)
+	}
+	for i, size := range pages {
+		if size[0] <= 0 || size[1] <= 0 {
+			return nil, fmt.Errorf("%w: page %d has an empty media box", ErrInvalidPDF, i+1)
+		}
+		if limits.MaxPageSide > 0 && (size[0] > limits.MaxPageSide || size[1] > limits.MaxPageSide) {
+			return nil, fmt.Errorf("%w: page %d is larger than %.0f pt", ErrInvalidPDF, i+1, limits.MaxPageSide)
+		}
+	}
+	return &PDFInfo{Pages: len(pages)}, nil
+}
+
+var errInflateLimit = errors.New("inflated stream too large")
+
+// แตก stream แบบ zlib คืน error ถ้าข้อมูลเสียหรือขยายเกิน limit
+func inflate(data []byte, limit int64) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var out bytes.Buffer
+	n, err := io.Copy(&out, io.LimitReader(reader, limit+1))
+	if n > limit {
+		return nil, errInflateLimit
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// ถอดรหัส #xx ในชื่อ กันการซ่อนชื่อเช่น /J#61vaScript
+func decodeName(raw []byte) string {
+	if bytes.IndexByte(raw, '#') < 0 {
+		return string(raw)
+	}
+	var name []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8); err == nil {
+				name = append(name, byte(b))
+				i += 2
+				continue
+			}
+		}
+		name = append(name, raw[i])
+	}
+	return string(name)
+}
+
+// whitespace และ delimiter ตามข้อกำหนด PDF ที่ใช้จบ name และ keyword
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ', '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
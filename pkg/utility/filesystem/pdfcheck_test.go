@@ -0,0 +1,111 @@
+package filesystem
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/signintech/gopdf"
+	"github.com/stretchr/testify/assert"
+)
+
+// ประกอบไฟล์ PDF จาก object ที่ให้มา (object แรกคือ catalog) พร้อม xref ที่ offset ถูกต้อง
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func pagesPDF(count int, mediaBox string, catalogExtra string) []byte {
+	kids := make([]string, count)
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R " + catalogExtra + ">>", ""}
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", i+3)
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox "+mediaBox+" >>")
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), count)
+	return buildPDF(objects...)
+}
+
+func deflate(s string) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.String()
+}
+
+// TestInspectPDF tests server-side validation of uploaded evidence files
+func TestInspectPDF(t *testing.T) {
+	limits := PDFLimits{MaxPages: 3, MaxPageSide: 1684}
+	a4 := "[0 0 595.28 841.89]"
+
+	t.Run("PDF generated by gopdf is accepted", func(t *testing.T) {
+		pdf := &gopdf.GoPdf{}
+		pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+		pdf.AddPage()
+		pdf.Line(10, 10, 200, 200)
+		pdf.AddPage()
+		var buf bytes.Buffer
+		_, err := pdf.WriteTo(&buf)
+		assert.NoError(t, err)
+
+		info, err := InspectPDF(buf.Bytes(), limits)
+		assert.NoError(t, err)
+		if assert.NotNil(t, info) {
+			assert.Equal(t, 2, info.Pages)
+		}
+	})
+
+	t.Run("Non-PDF content is rejected", func(t *testing.T) {
+		_, err := InspectPDF([]byte("MZ\x90\x00 not a pdf"), limits)
+		assert.ErrorIs(t, err, ErrInvalidPDF)
+	})
+
+	t.Run("Broken structure is rejected", func(t *testing.T) {
+		_, err := InspectPDF([]byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\n"), limits)
+		assert.ErrorIs(t, err, ErrInvalidPDF)
+	})
+
+	t.Run("Page count and size limits", func(t *testing.T) {
+		_, err := InspectPDF(pagesPDF(3, a4, ""), limits)
+		assert.NoError(t, err)
+		_, err = InspectPDF(pagesPDF(4, a4, ""), limits)
+		assert.ErrorContains(t, err, "more than 3 pages")
+		_, err = InspectPDF(pagesPDF(1, "[0 0 5000 5000]", ""), limits)
+		assert.ErrorContains(t, err, "larger than")
+	})
+
+	t.Run("JavaScript and launch actions are rejected", func(t *testing.T) {
+		for _, action := range []string{
+			"/OpenAction << /S /JavaScript /JS (app.alert(1)) >> ",
+			"/OpenAction << /S /Launch /F (cmd.exe) >> ",
+			"/OpenAction << /S /J#61va#53cript /J#53 (app.alert(1)) >> ",
+		} {
+			_, err := InspectPDF(pagesPDF(1, a4, action), limits)
+			assert.ErrorContains(t, err, "active content", action)
+		}
+	})
+
+	t.Run("Actions hidden in compressed object streams are rejected", func(t *testing.T) {
+		hidden := deflate("9 0 << /S /JavaScript /JS (app.alert(1)) >>")
+		content := append(pagesPDF(1, a4, ""), []byte(fmt.Sprintf(
+			"9 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+			len(hidden), hidden))...)
+		_, err := InspectPDF(content, limits)
+		assert.ErrorContains(t, err, "active content")
+	})
+}
@@ -0,0 +1,545 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ตัวอ่านโครงสร้าง PDF แบบย่อสำหรับตรวจไฟล์หลักฐาน อ่าน object ทุกตัวตามลำดับในไฟล์
+// (ไม่พึ่ง xref จึงอ่านไฟล์ที่ xref เพี้ยนได้) แล้วแตก object stream เพิ่ม ไม่ได้ใช้แสดงผลหน้า
+
+type pdfName string
+type pdfString []byte
+type pdfArray []interface{}
+type pdfDict map[pdfName]interface{}
+
+type pdfRef struct {
+	num, gen int
+}
+
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+// ความลึกสูงสุดของ array/dict ซ้อนกัน กัน stack overflow จากไฟล์ที่สร้างมาโจมตี
+const maxPDFNesting = 64
+
+var errPDFEOF = errors.New("unexpected end of file")
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func (l *pdfLexer) eof() bool {
+	return l.pos >= len(l.data)
+}
+
+// ข้าม whitespace และ comment
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// อ่าน token ที่ไม่ใช่ delimiter เช่น ตัวเลข true obj R (คืนค่าว่างถ้าตัวถัดไปเป็น delimiter)
+func (l *pdfLexer) readKeyword() string {
+	l.skipSpace()
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+func isPDFInt(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		if tok[i] < '0' || tok[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePDFNumber(tok string) (float64, bool) {
+	if tok == "" || (tok[0] != '+' && tok[0] != '-' && tok[0] != '.' && (tok[0] < '0' || tok[0] > '9')) {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		// PDF ยอมให้เขียน "5." หรือ "-.5" ซึ่ง ParseFloat อ่านได้อยู่แล้ว ที่เหลือถือว่าผิดรูปแบบ
+		return 0, false
+	}
+	return n, true
+}
+
+// อ่านค่าหนึ่งค่า (number, name, string, array, dict, ref, true/false/null)
+func (l *pdfLexer) readValue(depth int) (interface{}, error) {
+	if depth > maxPDFNesting {
+		return nil, fmt.Errorf("objects are nested too deeply")
+	}
+	l.skipSpace()
+	if l.eof() {
+		return nil, errPDFEOF
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteral()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.readDict(depth + 1)
+	case c == '<':
+		return l.readHex()
+	case c == '[':
+		l.pos++
+		return l.readArray(depth + 1)
+	case isPDFDelimiter(c):
+		return nil, fmt.Errorf("unexpected %q at offset %d", c, l.pos)
+	}
+
+	tok := l.readKeyword()
+	// "12 0 R" คือการอ้างถึง object อื่น (gopdf เขียน "-1 0 R" แทน object ที่ไม่มี จึงยอมให้ติดลบ)
+	if isPDFInt(strings.TrimPrefix(tok, "-")) {
+		save := l.pos
+		if gen := l.readKeyword(); isPDFInt(gen) && l.readKeyword() == "R" {
+			num, _ := strconv.Atoi(tok)
+			g, _ := strconv.Atoi(gen)
+			return pdfRef{num: num, gen: g}, nil
+		}
+		l.pos = save
+	}
+	if n, ok := parsePDFNumber(tok); ok {
+		return n, nil
+	}
+	switch tok {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected token %q at offset %d", tok, l.pos-len(tok))
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return pdfName(decodeName(l.data[start:l.pos]))
+}
+
+// string แบบ (...) วงเล็บซ้อนกันได้ และ \ ใช้ escape ตัวถัดไป
+func (l *pdfLexer) readLiteral() (pdfString, error) {
+	l.pos++
+	start, depth := l.pos, 1
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case '\\':
+			l.pos++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return pdfString(l.data[start : l.pos-1]), nil
+			}
+		}
+		l.pos++
+	}
+	return nil, errPDFEOF
+}
+
+func (l *pdfLexer) readHex() (pdfString, error) {
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return nil, errPDFEOF
+	}
+	s := pdfString(l.data[l.pos+1 : l.pos+end])
+	l.pos += end + 1
+	return s, nil
+}
+
+func (l *pdfLexer) readArray(depth int) (pdfArray, error) {
+	arr := pdfArray{}
+	for {
+		l.skipSpace()
+		if l.eof() {
+			return nil, errPDFEOF
+		}
+		if l.data[l.pos] == ']' {
+			l.pos++
+			return arr, nil
+		}
+		v, err := l.readValue(depth)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+}
+
+func (l *pdfLexer) readDict(depth int) (pdfDict, error) {
+	dict := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.eof() {
+			return nil, errPDFEOF
+		}
+		if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+			l.pos += 2
+			return dict, nil
+		}
+		if l.data[l.pos] != '/' {
+			return nil, fmt.Errorf("dictionary key is not a name at offset %d", l.pos)
+		}
+		key := l.readName()
+		// โปรแกรมบางตัวเขียน key โดยไม่มีค่า (เช่น "/Contents >>") ถือว่าเป็น null แบบเดียวกับโปรแกรมอ่าน PDF
+		l.skipSpace()
+		if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+			dict[key] = nil
+			continue
+		}
+		v, err := l.readValue(depth)
+		if err != nil {
+			return nil, err
+		}
+		dict[key] = v
+	}
+}
+
+// pdfFile object ทั้งหมดที่อ่านได้จากไฟล์
+type pdfFile struct {
+	objects map[int]interface{}
+	// ทุกค่าที่อ่านได้ รวม object ที่ถูกแทนด้วยฉบับแก้ไขภายหลัง ใช้ตรวจหา action ต้องห้าม
+	values []interface{}
+	// dictionary ของ trailer หรือ xref stream (ฉบับล่าสุดอยู่ท้าย)
+	trailers []pdfDict
+}
+
+// อ่าน object ระดับบนสุดทั้งไฟล์ ส่วนอื่นเช่นตาราง xref จะถูกข้ามไป
+func parsePDF(data []byte) (*pdfFile, error) {
+	f := &pdfFile{objects: map[int]interface{}{}}
+	l := &pdfLexer{data: data}
+	for {
+		l.skipSpace()
+		if l.eof() {
+			break
+		}
+		start := l.pos
+		tok := l.readKeyword()
+		switch {
+		case tok == "":
+			// delimiter ที่อยู่นอก object ไม่มีความหมาย ข้ามไป
+			l.pos = start + 1
+		case tok == "trailer":
+			v, err := l.readValue(0)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trailer: %w", err)
+			}
+			if dict, ok := v.(pdfDict); ok {
+				f.trailers = append(f.trailers, dict)
+			}
+		case isPDFInt(tok):
+			save := l.pos
+			gen := l.readKeyword()
+			if !isPDFInt(gen) || l.readKeyword() != "obj" {
+				l.pos = save
+				continue
+			}
+			num, _ := strconv.Atoi(tok)
+			v, err := l.readObjectBody()
+			if err != nil {
+				return nil, fmt.Errorf("invalid object %d: %w", num, err)
+			}
+			f.objects[num] = v
+			f.values = append(f.values, v)
+			if stream, ok := v.(*pdfStream); ok && stream.dict["Type"] == pdfName("XRef") {
+				f.trailers = append(f.trailers, stream.dict)
+			}
+		}
+	}
+	if len(f.objects) == 0 {
+		return nil, fmt.Errorf("no objects found")
+	}
+	return f, nil
+}
+
+// อ่านค่าของ object ต่อจาก "N G obj" ถ้ามี stream ตามมาจะอ่านข้อมูลดิบของ stream ด้วย
+func (l *pdfLexer) readObjectBody() (interface{}, error) {
+	v, err := l.readValue(0)
+	if err != nil {
+		return nil, err
+	}
+	save := l.pos
+	switch l.readKeyword() {
+	case "endobj":
+		return v, nil
+	case "stream":
+	default:
+		// บางโปรแกรมไม่เขียน endobj ปิดท้าย
+		l.pos = save
+		return v, nil
+	}
+
+	dict, ok := v.(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("stream without dictionary")
+	}
+	if bytes.HasPrefix(l.data[l.pos:], []byte("\r\n")) {
+		l.pos += 2
+	} else if l.pos < len(l.data) && (l.data[l.pos] == '\n' || l.data[l.pos] == '\r') {
+		l.pos++
+	}
+	start := l.pos
+
+	// ใช้ /Length ถ้าชี้ตรงกับ endstream ไม่เช่นนั้นหา endstream เอง (Length อาจเป็น ref หรือผิด)
+	end := -1
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(l.data) {
+		after := &pdfLexer{data: l.data, pos: start + int(length)}
+		if after.readKeyword() == "endstream" {
+			end = start + int(length)
+		}
+	}
+	if end < 0 {
+		idx := bytes.Index(l.data[start:], []byte("endstream"))
+		if idx < 0 {
+			return nil, fmt.Errorf("stream is not terminated")
+		}
+		end = start + idx
+	}
+	l.pos = end
+	l.readKeyword()
+	save = l.pos
+	if l.readKeyword() != "endobj" {
+		l.pos = save
+	}
+	return &pdfStream{dict: dict, data: l.data[start:end]}, nil
+}
+
+// แตก object stream (/Type /ObjStm) เป็น object ปกติ ใช้ข้อมูลแตกรวมไม่เกิน budget ไบต์
+func (f *pdfFile) expandObjectStreams(budget int64) error {
+	for _, v := range f.values {
+		stream, ok := v.(*pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data := stream.data
+		switch filter := stream.dict["Filter"]; filter {
+		case nil:
+		case pdfName("FlateDecode"):
+			inflated, err := inflate(data, budget)
+			if err != nil {
+				return fmt.Errorf("invalid object stream: %w", err)
+			}
+			budget -= int64(len(inflated))
+			data = inflated
+		default:
+			if arr, ok := filter.(pdfArray); ok && len(arr) == 1 && arr[0] == pdfName("FlateDecode") {
+				inflated, err := inflate(data, budget)
+				if err != nil {
+					return fmt.Errorf("invalid object stream: %w", err)
+				}
+				budget -= int64(len(inflated))
+				data = inflated
+				break
+			}
+			return fmt.Errorf("unsupported object stream filter %v", filter)
+		}
+
+		count, _ := stream.dict["N"].(float64)
+		first, _ := stream.dict["First"].(float64)
+		if first < 0 || int(first) > len(data) {
+			return fmt.Errorf("invalid object stream header")
+		}
+		header := &pdfLexer{data: data[:int(first)]}
+		for i := 0; i < int(count); i++ {
+			num, offset := header.readKeyword(), header.readKeyword()
+			if !isPDFInt(num) || !isPDFInt(offset) {
+				return fmt.Errorf("invalid object stream header")
+			}
+			n, _ := strconv.Atoi(num)
+			off, _ := strconv.Atoi(offset)
+			if int(first)+off > len(data) {
+				return fmt.Errorf("invalid object stream offset")
+			}
+			body := &pdfLexer{data: data, pos: int(first) + off}
+			obj, err := body.readValue(0)
+			if err != nil {
+				return fmt.Errorf("invalid object %d in object stream: %w", n, err)
+			}
+			f.values = append(f.values, obj)
+			// object ระดับบนสุดที่มีเลขเดียวกันถือเป็นฉบับแก้ไข
+			if _, exists := f.objects[n]; !exists {
+				f.objects[n] = obj
+			}
+		}
+	}
+	return nil
+}
+
+func (f *pdfFile) resolve(v interface{}) interface{} {
+	if ref, ok := v.(pdfRef); ok {
+		return f.objects[ref.num]
+	}
+	return v
+}
+
+// ค่าจาก trailer ฉบับล่าสุดที่มี key นั้น
+func (f *pdfFile) trailerValue(key pdfName) interface{} {
+	for i := len(f.trailers) - 1; i >= 0; i-- {
+		if v, ok := f.trailers[i][key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func (f *pdfFile) catalog() (pdfDict, error) {
+	if root, ok := f.resolve(f.trailerValue("Root")).(pdfDict); ok {
+		return root, nil
+	}
+	// ไม่มี trailer ที่ใช้ได้ หา catalog จาก object แทน
+	for _, v := range f.objects {
+		if dict, ok := v.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			return dict, nil
+		}
+	}
+	return nil, fmt.Errorf("document catalog not found")
+}
+
+// ขนาด (กว้าง, สูง) ของทุกหน้าตามลำดับใน page tree หยุดอ่านเมื่อเกิน maxPages
+func (f *pdfFile) pageSizes(maxPages int) ([][2]float64, error) {
+	root, err := f.catalog()
+	if err != nil {
+		return nil, err
+	}
+	var sizes [][2]float64
+	visited := map[pdfRef]bool{}
+
+	var walk func(node interface{}, box pdfArray, depth int) error
+	walk = func(node interface{}, box pdfArray, depth int) error {
+		if depth > maxPDFNesting {
+			return fmt.Errorf("page tree is nested too deeply")
+		}
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return fmt.Errorf("page tree contains a cycle")
+			}
+			visited[ref] = true
+		}
+		dict, ok := f.resolve(node).(pdfDict)
+		if !ok {
+			return fmt.Errorf("page tree node is missing")
+		}
+		if b, ok := f.resolve(dict["MediaBox"]).(pdfArray); ok {
+			box = b
+		}
+		kids, isPages := f.resolve(dict["Kids"]).(pdfArray)
+		if dict["Type"] == pdfName("Pages") || (dict["Type"] == nil && isPages) {
+			for _, kid := range kids {
+				if err := walk(kid, box, depth+1); err != nil {
+					return err
+				}
+				if maxPages > 0 && len(sizes) > maxPages {
+					return nil
+				}
+			}
+			return nil
+		}
+
+		size, err := f.boxSize(box)
+		if err != nil {
+			return err
+		}
+		if unit, ok := f.resolve(dict["UserUnit"]).(float64); ok && unit > 0 {
+			size[0], size[1] = size[0]*unit, size[1]*unit
+		}
+		sizes = append(sizes, size)
+		return nil
+	}
+
+	if err := walk(root["Pages"], nil, 0); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+func (f *pdfFile) boxSize(box pdfArray) ([2]float64, error) {
+	if len(box) != 4 {
+		return [2]float64{}, fmt.Errorf("page has no media box")
+	}
+	var n [4]float64
+	for i, v := range box {
+		value, ok := f.resolve(v).(float64)
+		if !ok {
+			return [2]float64{}, fmt.Errorf("invalid media box")
+		}
+		n[i] = value
+	}
+	abs := func(x float64) float64 {
+		if x < 0 {
+			return -x
+		}
+		return x
+	}
+	return [2]float64{abs(n[2] - n[0]), abs(n[3] - n[1])}, nil
+}
+
+// หาชื่อที่ตรงกับ match ใน key หรือค่าของทุก dictionary และ array
+func findNameIn(v interface{}, match func(name pdfName) bool) (pdfName, bool) {
+	switch value := v.(type) {
+	case pdfName:
+		if match(value) {
+			return value, true
+		}
+	case pdfArray:
+		for _, item := range value {
+			if name, found := findNameIn(item, match); found {
+				return name, true
+			}
+		}
+	case pdfDict:
+		for key, item := range value {
+			if match(key) {
+				return key, true
+			}
+			if name, found := findNameIn(item, match); found {
+				return name, true
+			}
+		}
+	case *pdfStream:
+		return findNameIn(value.dict, match)
+	}
+	return "", false
+}
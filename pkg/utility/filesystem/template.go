@@ -0,0 +1,287 @@
+package filesystem
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/structure/response"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/signintech/gopdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// ชื่อแม่แบบที่ระบบใช้
+const OutsideFormTemplate = "outside_form"
+
+//go:embed templates/outside_form.json
+var defaultOutsideForm []byte
+
+// ฟอนต์ที่แม่แบบเรียกใช้ได้ (ชื่อ -> ไฟล์ใน assets)
+var templateFonts = map[string]string{
+	"THSarabunNew":     assetDir + "/THSarabunNew/THSarabunNew.ttf",
+	"THSarabunNewBold": assetDir + "/THSarabunNew/THSarabunNew Bold.ttf",
+}
+
+// แม่แบบเอกสาร PDF: ขนาดหน้า และองค์ประกอบในแต่ละหน้า
+// ข้อความใช้ {{field}} เพื่อดึงข้อมูล เช่น {{event_name}}, {{student.first_name}}
+type PdfTemplate struct {
+	PageSize struct {
+		W float64 `json:"w"`
+		H float64 `json:"h"`
+	} `json:"page_size"`
+	Pages []TemplatePage `json:"pages"`
+}
+
+type TemplatePage struct {
+	Elements []TemplateElement `json:"elements"`
+}
+
+// type: text, image, rect, table หรือ qrcode (เข้ารหัสข้อความใน text)
+type TemplateElement struct {
+	Type      string           `json:"type"`
+	X         float64          `json:"x"`
+	Y         float64          `json:"y"`
+	W         float64          `json:"w"`
+	H         float64          `json:"h"`
+	Font      string           `json:"font"`
+	Size      float64          `json:"size"`
+	Text      string           `json:"text"`
+	Align     string           `json:"align"` // left, center, right (ภายในความกว้าง w)
+	Image     string           `json:"image"` // ชื่อไฟล์ใน assets/image
+	LineWidth float64          `json:"line_width"`
+	RowHeight float64          `json:"row_height"`
+	Columns   []TemplateColumn `json:"columns"`
+}
+
+type TemplateColumn struct {
+	Title string  `json:"title"`
+	Width float64 `json:"width"`
+	Align string  `json:"align"`
+	Value string  `json:"value"`
+}
+
+// แปลงและตรวจสอบแม่แบบก่อนบันทึกหรือใช้งาน
+func ParseTemplate(content []byte) (*PdfTemplate, error) {
+	var tpl PdfTemplate
+	if err := json.Unmarshal(content, &tpl); err != nil {
+		return nil, fmt.Errorf("invalid template JSON: %v", err)
+	}
+	if tpl.PageSize.W <= 0 || tpl.PageSize.H <= 0 {
+		return nil, fmt.Errorf("template page_size is required")
+	}
+	if len(tpl.Pages) == 0 {
+		return nil, fmt.Errorf("template must have at least one page")
+	}
+	for p, page := range tpl.Pages {
+		for i, el := range page.Elements {
+			if err := validateElement(el); err != nil {
+				return nil, fmt.Errorf("page %d element %d: %v", p+1, i+1, err)
+			}
+		}
+	}
+	return &tpl, nil
+}
+
+func validateElement(el TemplateElement) error {
+	switch el.Type {
+	case "text", "table":
+		if _, ok := templateFonts[el.Font]; !ok {
+			return fmt.Errorf("unknown font %q", el.Font)
+		}
+		if el.Size <= 0 {
+			return fmt.Errorf("font size is required")
+		}
+		if el.Type == "table" && len(el.Columns) == 0 {
+			return fmt.Errorf("table must have columns")
+		}
+	case "image":
+		// อนุญาตเฉพาะไฟล์ใน assets/image เท่านั้น
+		if el.Image == "" || filepath.Base(el.Image) != el.Image {
+			return fmt.Errorf("invalid image %q", el.Image)
+		}
+		if el.W <= 0 || el.H <= 0 {
+			return fmt.Errorf("image size is required")
+		}
+	case "rect":
+		if el.W <= 0 || el.H <= 0 {
+			return fmt.Errorf("rect size is required")
+		}
+	case "qrcode":
+		if el.Text == "" {
+			return fmt.Errorf("qrcode text is required")
+		}
+		if el.W <= 0 || el.H <= 0 {
+			return fmt.Errorf("qrcode size is required")
+		}
+	default:
+		return fmt.Errorf("unknown element type %q", el.Type)
+	}
+	return nil
+}
+
+func DefaultTemplate(name string) (*PdfTemplate, error) {
+	switch name {
+	case OutsideFormTemplate:
+		return ParseTemplate(defaultOutsideForm)
+	default:
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+}
+
+// เลขเอกสารและ URL ตรวจสอบที่พิมพ์ลงบนแบบฟอร์ม
+type FormStamp struct {
+	Serial    string
+	VerifyURL string
+}
+
+// ข้อมูลที่แม่แบบ outside_form อ้างอิงได้ (รวมทุก field ของ OutsideResponse/StudentResponse ตาม json tag)
+// และ {{serial}}, {{verify_url}}
+func OutsideFormFields(data response.OutsideResponse, stamp FormStamp) map[string]string {
+	fields := map[string]string{}
+	flattenFields("", data, fields)
+	fields["serial"] = stamp.Serial
+	fields["verify_url"] = stamp.VerifyURL
+	fields["start_date"] = utility.FormatToThaiDate(data.StartDate)
+	fields["start_time"] = utility.FormatToThaiTime(data.StartDate)
+	fields["end_time"] = utility.AddHoursToTime(data.StartDate, data.WorkingHour)
+	fields["student.full_name"] = data.Student.TitleName + data.Student.FirstName + " " + data.Student.LastName
+	return fields
+}
+
+func flattenFields(prefix string, v interface{}, fields map[string]string) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return
+	}
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenFields(prefix+key+".", nested, fields)
+			continue
+		}
+		fields[prefix+key] = fmt.Sprint(value)
+	}
+}
+
+var placeholder = regexp.MustCompile(`{{\s*([a-zA-Z0-9_.]+)\s*}}`)
+
+func bindText(text string, fields map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		key := strings.TrimSpace(strings.Trim(match, "{}"))
+		return fields[key]
+	})
+}
+
+// สร้าง PDF จากแม่แบบและข้อมูล
+func RenderTemplate(tpl *PdfTemplate, fields map[string]string) ([]byte, error) {
+	pdf := &gopdf.GoPdf{}
+	pageSize := gopdf.Rect{W: tpl.PageSize.W, H: tpl.PageSize.H}
+	pdf.Start(gopdf.Config{PageSize: pageSize})
+
+	for name, path := range templateFonts {
+		if err := pdf.AddTTFFont(name, path); err != nil {
+			return nil, fmt.Errorf("error adding font: %v", err)
+		}
+	}
+
+	for _, page := range tpl.Pages {
+		pdf.AddPage()
+		for _, el := range page.Elements {
+			if err := drawElement(pdf, el, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pdf.GetBytesPdfReturnErr()
+}
+
+func cellAlign(align string) int {
+	switch align {
+	case "center":
+		return gopdf.Center
+	case "right":
+		return gopdf.Right
+	default:
+		return gopdf.Left
+	}
+}
+
+func drawElement(pdf *gopdf.GoPdf, el TemplateElement, fields map[string]string) error {
+	switch el.Type {
+	case "text":
+		if err := pdf.SetFont(el.Font, "", el.Size); err != nil {
+			return err
+		}
+		pdf.SetXY(el.X, el.Y)
+		text := bindText(el.Text, fields)
+		if el.W > 0 {
+			return pdf.CellWithOption(&gopdf.Rect{W: el.W, H: el.Size}, text, gopdf.CellOption{Align: cellAlign(el.Align) | gopdf.Top})
+		}
+		return pdf.Cell(nil, text)
+	case "image":
+		return pdf.Image(assetDir+"/image/"+el.Image, el.X, el.Y, &gopdf.Rect{W: el.W, H: el.H})
+	case "rect":
+		pdf.SetStrokeColor(0, 0, 0)
+		pdf.SetLineWidth(lineWidth(el))
+		pdf.RectFromUpperLeftWithStyle(el.X, el.Y, el.W, el.H, "D")
+		return nil
+	case "table":
+		return drawTable(pdf, el, fields)
+	case "qrcode":
+		return drawQRCode(pdf, el, fields)
+	}
+	return fmt.Errorf("unknown element type %q", el.Type)
+}
+
+func lineWidth(el TemplateElement) float64 {
+	if el.LineWidth > 0 {
+		return el.LineWidth
+	}
+	return 0.5
+}
+
+// ตารางหัวคอลัมน์ + ข้อมูลหนึ่งแถว
+func drawTable(pdf *gopdf.GoPdf, el TemplateElement, fields map[string]string) error {
+	if err := pdf.SetFont(el.Font, "", el.Size); err != nil {
+		return err
+	}
+	rowHeight := el.RowHeight
+	if rowHeight <= 0 {
+		rowHeight = 30
+	}
+	table := pdf.NewTableLayout(el.X, el.Y, rowHeight, 1)
+	row := make([]string, 0, len(el.Columns))
+	for _, column := range el.Columns {
+		align := column.Align
+		if align == "" {
+			align = "center"
+		}
+		table.AddColumn(column.Title, column.Width, align)
+		row = append(row, bindText(column.Value, fields))
+	}
+	table.AddRow(row)
+	return table.DrawTable()
+}
+
+func drawQRCode(pdf *gopdf.GoPdf, el TemplateElement, fields map[string]string) error {
+	content := bindText(el.Text, fields)
+	if content == "" {
+		return fmt.Errorf("qrcode content is empty")
+	}
+	png, err := qrcode.Encode(content, qrcode.Medium, 256)
+	if err != nil {
+		return fmt.Errorf("failed to create QR code: %v", err)
+	}
+	holder, err := gopdf.ImageHolderByBytes(png)
+	if err != nil {
+		return err
+	}
+	return pdf.ImageByHolder(holder, el.X, el.Y, &gopdf.Rect{W: el.W, H: el.H})
+}
@@ -0,0 +1,308 @@
+package filesystem
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+
+	"github.com/signintech/gopdf"
+)
+
+const (
+	assetDir       = "./pkg/utility/filesystem/assets"
+	transcriptLine = 18.0 // ความสูงต่อบรรทัดในตาราง
+	transcriptTop  = 40.0
+	transcriptLeft = 30.0
+	transcriptEnd  = 800.0 // ขึ้นหน้าใหม่เมื่อเกินบรรทัดนี้
+)
+
+var portraitSize = gopdf.Rect{W: 595.28, H: 841.89}
+
+type transcriptColumn struct {
+	title string
+	width float64
+	align int
+}
+
+// ใบรายงานผลการเข้าร่วมกิจกรรมประจำปีการศึกษา (หลายหน้า)
+type transcriptWriter struct {
+	pdf  *gopdf.GoPdf
+	y    float64
+	page int
+}
+
+func CreateTranscriptPDF(data response.Transcript) ([]byte, string, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: portraitSize})
+
+	if err := pdf.AddTTFFont("THSarabunNew", assetDir+"/THSarabunNew/THSarabunNew.ttf"); err != nil {
+		return nil, "", fmt.Errorf("error adding font: %v", err)
+	}
+	if err := pdf.AddTTFFont("THSarabunNewBold", assetDir+"/THSarabunNew/THSarabunNew Bold.ttf"); err != nil {
+		return nil, "", fmt.Errorf("error adding font: %v", err)
+	}
+
+	w := &transcriptWriter{pdf: pdf}
+	if err := w.newPage(); err != nil {
+		return nil, "", err
+	}
+	if err := w.studentInfo(data); err != nil {
+		return nil, "", err
+	}
+	if err := w.insideTable(data.Inside); err != nil {
+		return nil, "", err
+	}
+	if err := w.outsideTable(data.Outside); err != nil {
+		return nil, "", err
+	}
+	if err := w.summary(data); err != nil {
+		return nil, "", err
+	}
+
+	pdfBytes, err := pdf.GetBytesPdfReturnErr()
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating PDF: %v", err)
+	}
+	fileName := fmt.Sprintf("transcript-%s-%d.pdf", data.Student.Code, data.SchoolYear)
+	return pdfBytes, fileName, nil
+}
+
+// หน้าใหม่: โลโก้ หัวกระดาษ และเลขหน้า
+func (w *transcriptWriter) newPage() error {
+	w.pdf.AddPage()
+	w.page++
+
+	if err := w.pdf.Image(assetDir+"/image/logo.png", transcriptLeft, transcriptTop-10, &gopdf.Rect{W: 35.4, H: 66.3}); err != nil {
+		return fmt.Errorf("error adding logo: %v", err)
+	}
+
+	w.pdf.SetFont("THSarabunNewBold", "", 20)
+	w.text(80, transcriptTop, "ใบรายงานผลการเข้าร่วมกิจกรรม/โครงการจิตอาสา")
+	w.pdf.SetFont("THSarabunNew", "", 16)
+	w.text(80, transcriptTop+22, "มหาวิทยาลัยเทคโนโลยีราชมงคลอีสาน")
+	w.text(portraitSize.W-transcriptLeft-40, portraitSize.H-30, fmt.Sprintf("หน้า %d", w.page))
+
+	w.y = transcriptTop + 65
+	return nil
+}
+
+func (w *transcriptWriter) text(x, y float64, s string) {
+	w.pdf.SetXY(x, y)
+	w.pdf.Cell(nil, s)
+}
+
+// ขึ้นหน้าใหม่หากพื้นที่เหลือไม่พอสำหรับความสูง h
+func (w *transcriptWriter) ensureSpace(h float64) (bool, error) {
+	if w.y+h <= transcriptEnd {
+		return false, nil
+	}
+	return true, w.newPage()
+}
+
+func (w *transcriptWriter) studentInfo(data response.Transcript) error {
+	student := data.Student
+	w.pdf.SetFont("THSarabunNewBold", "", 16)
+	w.text(transcriptLeft, w.y, fmt.Sprintf("ปีการศึกษา %d", data.SchoolYear))
+	w.y += transcriptLine
+	w.pdf.SetFont("THSarabunNew", "", 16)
+	w.text(transcriptLeft, w.y, fmt.Sprintf("ชื่อ-สกุล %s%s %s   รหัสนักศึกษา %s", student.TitleName, student.FirstName, student.LastName, student.Code))
+	w.y += transcriptLine
+	w.text(transcriptLeft, w.y, fmt.Sprintf("สาขา %s   คณะ %s", student.BranchName, student.FacultyName))
+	w.y += transcriptLine * 2
+	return nil
+}
+
+func (w *transcriptWriter) insideTable(rows []response.TranscriptInside) error {
+	columns := []transcriptColumn{
+		{"ลำดับ", 35, gopdf.Center},
+		{"กิจกรรมภายใน", 170, gopdf.Left},
+		{"วันที่", 85, gopdf.Center},
+		{"ชั่วโมง", 45, gopdf.Center},
+		{"ผู้รับรอง", 120, gopdf.Left},
+		{"สถานะ", 80, gopdf.Center},
+	}
+	data := make([][]string, 0, len(rows))
+	for i, row := range rows {
+		data = append(data, []string{
+			fmt.Sprint(i + 1),
+			row.EventName,
+			utility.FormatToThaiDate(row.StartDate),
+			fmt.Sprint(row.WorkingHour),
+			row.Certifier,
+			insideStatusText(row),
+		})
+	}
+	return w.table(columns, data)
+}
+
+func insideStatusText(row response.TranscriptInside) string {
+	return ReviewStatusText(entity.ReviewState(row.State))
+}
+
+func (w *transcriptWriter) outsideTable(rows []response.TranscriptOutside) error {
+	columns := []transcriptColumn{
+		{"ลำดับ", 35, gopdf.Center},
+		{"กิจกรรมภายนอก", 140, gopdf.Left},
+		{"วันที่", 85, gopdf.Center},
+		{"ชั่วโมง", 45, gopdf.Center},
+		{"สถานที่", 70, gopdf.Left},
+		{"ผู้รับรอง", 80, gopdf.Left},
+		{"สถานะ", 80, gopdf.Center},
+	}
+	data := make([][]string, 0, len(rows))
+	for i, row := range rows {
+		data = append(data, []string{
+			fmt.Sprint(i + 1),
+			row.EventName,
+			utility.FormatToThaiDate(row.StartDate),
+			fmt.Sprint(row.WorkingHour),
+			row.Location,
+			row.Intendant,
+			ReviewStatusText(entity.ReviewState(row.State)),
+		})
+	}
+	return w.table(columns, data)
+}
+
+// วาดตารางที่ตัดคำในแต่ละช่อง และพิมพ์หัวตารางซ้ำเมื่อขึ้นหน้าใหม่
+func (w *transcriptWriter) table(columns []transcriptColumn, rows [][]string) error {
+	w.pdf.SetLineWidth(0.5)
+	if _, err := w.ensureSpace(transcriptLine * 2); err != nil {
+		return err
+	}
+	if err := w.tableRow(columns, headerCells(columns), true); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		rows = [][]string{{"", "- ไม่มีข้อมูล -"}}
+	}
+	for _, row := range rows {
+		w.pdf.SetFont("THSarabunNew", "", 14)
+		lines, err := w.splitRow(columns, row)
+		if err != nil {
+			return err
+		}
+		newPage, err := w.ensureSpace(rowHeight(lines))
+		if err != nil {
+			return err
+		}
+		if newPage {
+			if err := w.tableRow(columns, headerCells(columns), true); err != nil {
+				return err
+			}
+		}
+		if err := w.tableRow(columns, row, false); err != nil {
+			return err
+		}
+	}
+	w.y += transcriptLine
+	return nil
+}
+
+func headerCells(columns []transcriptColumn) []string {
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		cells[i] = column.title
+	}
+	return cells
+}
+
+func (w *transcriptWriter) splitRow(columns []transcriptColumn, row []string) ([][]string, error) {
+	lines := make([][]string, len(columns))
+	for i, column := range columns {
+		if i >= len(row) || row[i] == "" {
+			continue
+		}
+		split, err := w.pdf.SplitText(row[i], column.width-6)
+		if err != nil {
+			return nil, err
+		}
+		lines[i] = split
+	}
+	return lines, nil
+}
+
+func rowHeight(lines [][]string) float64 {
+	max := 1
+	for _, cell := range lines {
+		if len(cell) > max {
+			max = len(cell)
+		}
+	}
+	return float64(max)*transcriptLine + 4
+}
+
+func (w *transcriptWriter) tableRow(columns []transcriptColumn, row []string, header bool) error {
+	if header {
+		w.pdf.SetFont("THSarabunNewBold", "", 14)
+	} else {
+		w.pdf.SetFont("THSarabunNew", "", 14)
+	}
+	lines, err := w.splitRow(columns, row)
+	if err != nil {
+		return err
+	}
+	h := rowHeight(lines)
+
+	x := transcriptLeft
+	for i, column := range columns {
+		w.pdf.RectFromUpperLeftWithStyle(x, w.y, column.width, h, "D")
+		for j, line := range lines[i] {
+			w.pdf.SetXY(x+3, w.y+2+float64(j)*transcriptLine)
+			if err := w.pdf.CellWithOption(&gopdf.Rect{W: column.width - 6, H: transcriptLine}, line, gopdf.CellOption{Align: column.align | gopdf.Middle}); err != nil {
+				return err
+			}
+		}
+		x += column.width
+	}
+	w.y += h
+	return nil
+}
+
+func (w *transcriptWriter) summary(data response.Transcript) error {
+	if _, err := w.ensureSpace(transcriptLine * 6); err != nil {
+		return err
+	}
+	w.pdf.SetFont("THSarabunNewBold", "", 16)
+	w.text(transcriptLeft, w.y, "สรุปชั่วโมงกิจกรรม")
+	w.y += transcriptLine
+	w.pdf.SetFont("THSarabunNew", "", 16)
+	w.text(transcriptLeft, w.y, fmt.Sprintf("กิจกรรมภายใน (อนุมัติแล้ว) %d ชั่วโมง", data.InsideHour))
+	w.y += transcriptLine
+	w.text(transcriptLeft, w.y, fmt.Sprintf("กิจกรรมภายนอก %d ชั่วโมง", data.OutsideHour))
+	w.y += transcriptLine
+	w.text(transcriptLeft, w.y, fmt.Sprintf("รวมทั้งหมด %d ชั่วโมง", data.TotalHour))
+	w.y += transcriptLine * 1.5
+
+	w.pdf.SetFont("THSarabunNewBold", "", 16)
+	w.text(transcriptLeft, w.y, "ผลการตรวจสอบประจำปี: "+doneStatusText(data.Done))
+	w.y += transcriptLine
+	w.pdf.SetFont("THSarabunNew", "", 14)
+	if data.Done != nil && data.Done.Certifier != "" {
+		w.text(transcriptLeft, w.y, "ผู้ตรวจสอบ "+data.Done.Certifier)
+		w.y += transcriptLine
+	}
+	if data.Done != nil && data.Done.Comment != "" {
+		w.text(transcriptLeft, w.y, "หมายเหตุ "+data.Done.Comment)
+		w.y += transcriptLine
+	}
+	w.text(transcriptLeft, w.y, fmt.Sprintf("ออกเอกสารเมื่อ %s %s น.", utility.FormatToThaiDate(data.GeneratedAt), utility.FormatToThaiTime(data.GeneratedAt)))
+	return nil
+}
+
+func doneStatusText(done *response.TranscriptDone) string {
+	if done == nil {
+		return "ยังไม่ส่งตรวจ"
+	}
+	switch entity.ReviewState(done.State) {
+	case entity.StateApproved:
+		return "ผ่านการอนุมัติ"
+	case entity.StateRejected:
+		return "ไม่ผ่านการอนุมัติ"
+	case entity.StateResubmissionRequested:
+		return "ขอให้ส่งตรวจใหม่"
+	default:
+		return "รอการตรวจสอบ"
+	}
+}
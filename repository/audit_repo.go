@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// audit log เพิ่มได้อย่างเดียว จึงไม่มีเมธอดแก้ไขหรือลบ
+type AuditRepository interface {
+	CreateAuditLog(log *entity.AuditLog) error
+	FindAuditLogs(filter request.AuditFilter) ([]entity.AuditLog, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) CreateAuditLog(log *entity.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+// บันทึก audit log ใน transaction เดียวกับการเปลี่ยนแปลง ถ้าบันทึกไม่ได้การเปลี่ยนแปลงจะถูกยกเลิกด้วย (nil = ไม่บันทึก)
+func createAuditLog(tx *gorm.DB, log *entity.AuditLog) error {
+	if log == nil {
+		return nil
+	}
+	if err := tx.Create(log).Error; err != nil {
+		return fmt.Errorf("failed to record audit log: %w", err)
+	}
+	return nil
+}
+
+// ค้นหาตามตัวกรอง เรียงจากล่าสุด คืนจำนวนทั้งหมดก่อนแบ่งหน้า
+func (r *auditRepository) FindAuditLogs(filter request.AuditFilter) ([]entity.AuditLog, int64, error) {
+	db := r.db.Model(&entity.AuditLog{})
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		db = db.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		db = db.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.DateFrom != "" {
+		if from, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local); err == nil {
+			db = db.Where("created_at >= ?", from)
+		}
+	}
+	if filter.DateTo != "" {
+		if to, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local); err == nil {
+			db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+		}
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	var logs []entity.AuditLog
+	err := db.Order("created_at DESC").Order("audit_id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	return logs, total, nil
+}
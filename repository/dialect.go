@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// อักขระ escape ของ LIKE ใช้ ! แทน \ เพราะ MySQL ตีความ '\' ใน string literal ต่างจาก PostgreSQL
+// เมื่อระบุ ESCAPE แล้ว \ จะไม่ใช่อักขระพิเศษอีก จึงค้นหาได้ตรงตัวทั้งสองฐานข้อมูล
+const likeEscapeClause = "ESCAPE '!'"
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// ค่าสำหรับ LIKE ที่ใช้คู่กับ likeEscapeClause ให้ % และ _ ที่ผู้ใช้พิมพ์ถูกค้นหาตรงตัว
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// ตั้งเวลารอ lock ของ transaction ปัจจุบันตามชนิดฐานข้อมูล
+// MySQL ตั้งระดับ session ส่วน PostgreSQL ใช้ SET LOCAL ซึ่งหมดผลเมื่อจบ transaction
+// SQLite ล็อกทั้งไฟล์และไม่มีค่านี้ จึงไม่ต้องตั้ง
+func setLockTimeout(tx *gorm.DB, seconds int) error {
+	switch tx.Dialector.Name() {
+	case "mysql":
+		return tx.Exec(fmt.Sprintf("SET innodb_lock_wait_timeout = %d", seconds)).Error
+	case "postgres":
+		return tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%ds'", seconds)).Error
+	}
+	return nil
+}
@@ -6,7 +6,8 @@ import (
 	"go-clean-arch/pkg/utility"
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/structure/request"
-	"os"
+	"go-clean-arch/structure/response"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -16,35 +17,56 @@ import (
 type EventRepository interface {
 	CreateEvent(event *entity.Event) error
 	NewsForUser(news *entity.News) error
-	GetAllEvent() ([]entity.Event, error)
+	ReviewEventInsides(eventID uint, transitions []entity.ReviewTransition, audits []*entity.AuditLog) ([]response.ReviewOutcome, error)
+	GetAllEvent(filter request.EventFilter) ([]entity.Event, int64, error)
 	CountEventInside(eventID uint) (uint, error)
+	CountEventInsideByIDs(eventIDs []uint) (map[uint]uint, error)
 	GetEventByID(id uint) (*entity.Event, error)
 	ToggleEventStatus(eventID uint) (bool, error)
 	// UpdateEventByID(event *entity.Event) error
 	// DeleteEventByID(eventID uint) error
 	// CreateEventWithTransaction(req *request.EventRequest, userID uint) error
-	UpdateEventWithTransaction(eventID, userID uint, req request.EventRequest) error
+	UpdateEventWithTransaction(eventID, userID uint, req request.EventRequest, audit func(after *entity.Event) *entity.AuditLog) error
 	DeleteEventWithTransaction(eventID, userID uint) error
 
 	GroupByEvent(eventID uint) ([]uint, error)
 	JoinEvent(eventInside *entity.EventInside) error
 	UnJoinEvent(eventID uint, userID uint) error
-	GetFilePath(eventID uint, userID uint) (string, error)
-	UploadFile(eventID uint, userID uint, filePath string) error
+	JoinWaitlist(eventID uint, userID uint) (uint, error)
+	LeaveWaitlist(eventID uint, userID uint) error
+	MyWaitlist(userID uint) ([]WaitlistEntry, error)
+	UploadFile(transition *entity.ReviewTransition, filePath string, thumbnail string, audit *entity.AuditLog) error
 	MyEvent(userID uint) ([]entity.Event, error)
-	AllAllowedEvent() ([]entity.Event, error)
-	AllCurrentEvent() ([]entity.Event, error)
-	MyChecklist(userID uint, eventID uint) ([]entity.EventInside, error)
-	UpdateEventStatusAndComment(eventID uint, userID uint, status bool, comment string) error
+	AllAllowedEvent(filter request.EventFilter) ([]entity.Event, int64, error)
+	AllCurrentEvent(filter request.EventFilter) ([]entity.Event, int64, error)
+	OpenUpcomingEvent(filter request.EventFilter) ([]entity.Event, int64, error)
+	JoinedEventIDs(userID uint, eventIDs []uint) (map[uint]bool, error)
+	HasEventPermission(eventID uint, branchID uint, year uint) (bool, error)
+	MyChecklist(eventID uint) ([]entity.EventInside, error)
+	GetEventInsides(eventID uint, userIDs []uint) ([]entity.EventInside, error)
+	UpdateInsideState(transition *entity.ReviewTransition, audit *entity.AuditLog) error
+	MarkAttended(eventID uint, userID uint, attendedAt time.Time) error
 	AllEventInsideThisYear(userID uint, year uint) ([]entity.EventInside, error)
 
-	CreateEventOutside(outside entity.EventOutside) error
+	CreateEventOutside(outside *entity.EventOutside) error
 	DeleteEventOutsideByID(eventID uint) error
 	GetEventOutsideByID(id uint) (*entity.EventOutside, error)
-	GetFilePathOutside(eventID uint, userID uint) (string, error)
-	UploadFileOutside(eventID uint, userID uint, filePath string) error
+	UploadFileOutside(transition *entity.ReviewTransition, filePath string, thumbnail string, certifierID uint, audit *entity.AuditLog) error
+	PendingOutsides(certifierID uint) ([]entity.EventOutside, error)
+	UpdateOutsideState(transition *entity.ReviewTransition, audit *entity.AuditLog) error
 	AllEventOutsideThisYear(userID uint, year uint) ([]entity.EventOutside, error)
 	EventOutsideExists(eventID uint, userID uint) (bool, error)
+	CreateGeneratedForm(form *entity.GeneratedForm) error
+	GetGeneratedForm(serial string) (*entity.GeneratedForm, error)
+}
+
+// ลำดับในรายชื่อสำรองของนักศึกษาแต่ละกิจกรรม
+type WaitlistEntry struct {
+	EventID   uint
+	EventName string
+	StartDate time.Time
+	Position  uint
+	Total     uint
 }
 
 type eventRepository struct {
@@ -55,7 +77,8 @@ func NewEventRepository(db *gorm.DB) EventRepository {
 	return &eventRepository{db: db}
 }
 
-func (r *eventRepository) UpdateEventWithTransaction(eventID, userID uint, req request.EventRequest) error {
+// audit สร้าง audit log จากกิจกรรมหลังแก้ไข และบันทึกใน transaction เดียวกัน (nil = ไม่บันทึก)
+func (r *eventRepository) UpdateEventWithTransaction(eventID, userID uint, req request.EventRequest, audit func(after *entity.Event) *entity.AuditLog) error {
 	// เริ่ม Transaction
 	tx := r.db.Begin()
 	defer func() {
@@ -64,9 +87,16 @@ func (r *eventRepository) UpdateEventWithTransaction(eventID, userID uint, req r
 		}
 	}()
 
-	// ดึงข้อมูลกิจกรรม
+	if err := setLockTimeout(tx, 5); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+
+	// ดึงข้อมูลกิจกรรม ล็อกแถวเพราะอาจเปลี่ยน FreeSpace
 	var event entity.Event
-	if err := tx.Where("event_id = ?", eventID).First(&event).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ?", eventID).
+		First(&event).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("event not found: %w", err)
 	}
@@ -89,19 +119,32 @@ func (r *eventRepository) UpdateEventWithTransaction(eventID, userID uint, req r
 	event.WorkingHour = req.WorkingHour
 	event.Location = req.Location
 	event.Detail = req.Detail
+	event.Category = req.Category
 
-	if err := tx.Save(&event).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to update event: %w", err)
-	}
-
-	// แจ้งเตือนผู้ใช้ที่เข้าร่วม
 	var userIDs []uint
 	if err := tx.Model(&entity.EventInside{}).Where("event_id = ?", eventID).Pluck("user", &userIDs).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to get users for event: %w", err)
 	}
 
+	// free_space ไม่ถูกใช้ตอนแก้ไข จำนวนที่รับเปลี่ยนได้ผ่าน req.Limit เท่านั้น (nil = ไม่เปลี่ยน)
+	raised := false
+	if req.Limit != nil {
+		if *req.Limit < uint(len(userIDs)) {
+			tx.Rollback()
+			return fmt.Errorf("limit cannot be lower than the %d joined participants", len(userIDs))
+		}
+		freeSpace := *req.Limit - uint(len(userIDs))
+		raised = freeSpace > event.FreeSpace
+		event.FreeSpace = freeSpace
+	}
+
+	if err := tx.Save(&event).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+
+	// แจ้งเตือนผู้ใช้ที่เข้าร่วม
 	for _, uid := range userIDs {
 		news := entity.News{
 			Title:   "กิจกรรมมีการแก้ไขรายละเอียด",
@@ -114,6 +157,26 @@ func (r *eventRepository) UpdateEventWithTransaction(eventID, userID uint, req r
 		}
 	}
 
+	// ที่นั่งว่างเพิ่มขึ้น เลื่อนรายชื่อสำรองเข้ามาจนเต็มหรือหมดคิว
+	if raised {
+		if err := fillFromWaitlist(tx, &event); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if audit != nil {
+		var after entity.Event
+		if err := tx.Preload("Branches").Preload("Years").First(&after, "event_id = ?", eventID).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to reload event: %w", err)
+		}
+		if err := createAuditLog(tx, audit(&after)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	// Commit Transaction
 	return tx.Commit().Error
 }
@@ -129,12 +192,85 @@ func (r *eventRepository) NewsForUser(news *entity.News) error {
 	return nil
 }
 
-func (r *eventRepository) GetAllEvent() ([]entity.Event, error) {
+func (r *eventRepository) GetAllEvent(filter request.EventFilter) ([]entity.Event, int64, error) {
+	return r.findEventPage(r.db.Model(&entity.Event{}), filter)
+}
+
+// เงื่อนไขสิทธิ์สาขา/ชั้นปี จากตาราง event_branches และ event_years
+const (
+	branchAllowedClause = "(allow_all_branch = ? OR EXISTS (SELECT 1 FROM event_branches WHERE event_branches.event_id = events.event_id AND event_branches.branch_id = ?))"
+	yearAllowedClause   = "(allow_all_year = ? OR EXISTS (SELECT 1 FROM event_years WHERE event_years.event_id = events.event_id AND event_years.year = ?))"
+)
+
+// ตรวจสอบว่านักศึกษาสาขา branchID ชั้นปี year มีสิทธิ์เข้าร่วมกิจกรรมหรือไม่
+func (r *eventRepository) HasEventPermission(eventID uint, branchID uint, year uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&entity.Event{}).
+		Where("event_id = ?", eventID).
+		Where(branchAllowedClause, true, branchID).
+		Where(yearAllowedClause, true, year).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ใช้ตัวกรองจาก query string กับ query ของ events
+// filter ต้องผ่านการตรวจสอบจาก usecase มาแล้ว (วันที่ถูกรูปแบบ, sort อยู่ใน whitelist)
+func applyEventFilter(db *gorm.DB, filter request.EventFilter) *gorm.DB {
+	if filter.SchoolYear != 0 {
+		db = db.Where("school_year = ?", filter.SchoolYear)
+	}
+	if filter.Creator != 0 {
+		db = db.Where("creator = ?", filter.Creator)
+	}
+	if filter.Branch != 0 {
+		db = db.Where(branchAllowedClause, true, filter.Branch)
+	}
+	if filter.Year != 0 {
+		db = db.Where(yearAllowedClause, true, filter.Year)
+	}
+	if filter.DateFrom != "" {
+		if from, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local); err == nil {
+			db = db.Where("start_date >= ?", from)
+		}
+	}
+	if filter.DateTo != "" {
+		if to, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local); err == nil {
+			db = db.Where("start_date < ?", to.AddDate(0, 0, 1))
+		}
+	}
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+	if filter.Search != "" {
+		// LOWER ให้ค้นหาไม่สนตัวพิมพ์เหมือนกันทั้ง MySQL และ PostgreSQL
+		keyword := "%" + escapeLike(strings.ToLower(filter.Search)) + "%"
+		db = db.Where("(LOWER(event_name) LIKE ? "+likeEscapeClause+" OR LOWER(location) LIKE ? "+likeEscapeClause+")", keyword, keyword)
+	}
+	return db
+}
+
+// นับจำนวนทั้งหมดด้วย COUNT ครั้งเดียว แล้วดึงเฉพาะหน้าที่ต้องการ
+func (r *eventRepository) findEventPage(db *gorm.DB, filter request.EventFilter) ([]entity.Event, int64, error) {
+	db = applyEventFilter(db, filter)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var events []entity.Event
-	if err := r.db.Preload("Teacher").Find(&events).Error; err != nil {
-		return nil, err
+	err := db.Preload("Teacher").Preload("Branches").Preload("Years").
+		Order(clause.OrderByColumn{Column: clause.Column{Name: filter.Sort}, Desc: filter.Order == "desc"}).
+		Order("event_id").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, err
 	}
-	return events, nil
+	return events, total, nil
 }
 
 func (r *eventRepository) CountEventInside(eventID uint) (uint, error) {
@@ -145,9 +281,34 @@ func (r *eventRepository) CountEventInside(eventID uint) (uint, error) {
 	return uint(count), nil
 }
 
+// นับผู้เข้าร่วมของหลายกิจกรรมใน query เดียว (GROUP BY) แทนการนับทีละกิจกรรม
+// กิจกรรมที่ไม่มีผู้เข้าร่วมจะไม่มีใน map (ค่าเป็น 0)
+func (r *eventRepository) CountEventInsideByIDs(eventIDs []uint) (map[uint]uint, error) {
+	counts := make(map[uint]uint, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		EventID uint
+		Total   uint
+	}
+	if err := r.db.Model(&entity.EventInside{}).
+		Select("event_id, COUNT(*) AS total").
+		Where("event_id IN ?", eventIDs).
+		Group("event_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.EventID] = row.Total
+	}
+	return counts, nil
+}
+
 func (r *eventRepository) GetEventByID(id uint) (*entity.Event, error) {
 	var event entity.Event
-	if err := r.db.Preload("Teacher").First(&event, "event_id = ?", id).Error; err != nil {
+	if err := r.db.Preload("Teacher").Preload("Branches").Preload("Years").First(&event, "event_id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("event with ID %d not found", id)
 		}
@@ -239,28 +400,44 @@ func (r *eventRepository) GroupByEvent(eventID uint) ([]uint, error) {
 
 func (r *eventRepository) MyEvent(userID uint) ([]entity.Event, error) {
 	var events []entity.Event
-	if err := r.db.Preload("Teacher").Where("creator = ?", userID).Find(&events).Error; err != nil {
+	if err := r.db.Preload("Teacher").Preload("Branches").Preload("Years").Where("creator = ?", userID).Find(&events).Error; err != nil {
 		return nil, err
 	}
 	return events, nil
 }
 
-func (r *eventRepository) AllAllowedEvent() ([]entity.Event, error) {
-	var events []entity.Event
-	if err := r.db.Preload("Teacher").Where("status = true").Find(&events).Error; err != nil {
-		return nil, err
-	}
-	return events, nil
+func (r *eventRepository) AllAllowedEvent(filter request.EventFilter) ([]entity.Event, int64, error) {
+	return r.findEventPage(r.db.Model(&entity.Event{}).Where("status = true"), filter)
 }
 
-func (r *eventRepository) AllCurrentEvent() ([]entity.Event, error) {
-	var events []entity.Event
+func (r *eventRepository) AllCurrentEvent(filter request.EventFilter) ([]entity.Event, int64, error) {
 	today := time.Now()
 	futureDate := today.AddDate(0, 1, 0)
-	if err := r.db.Preload("Teacher").Where("start_date BETWEEN ? AND ?", today, futureDate).Find(&events).Error; err != nil {
+	return r.findEventPage(r.db.Model(&entity.Event{}).Where("start_date BETWEEN ? AND ?", today, futureDate), filter)
+}
+
+// กิจกรรมที่เปิดรับและยังไม่เริ่ม สิทธิ์สาขา/ชั้นปีกรองผ่าน filter.Branch และ filter.Year
+func (r *eventRepository) OpenUpcomingEvent(filter request.EventFilter) ([]entity.Event, int64, error) {
+	return r.findEventPage(r.db.Model(&entity.Event{}).Where("status = ? AND start_date > ?", true, time.Now()), filter)
+}
+
+// กิจกรรมใดบ้างใน eventIDs ที่ผู้ใช้เข้าร่วมแล้ว
+func (r *eventRepository) JoinedEventIDs(userID uint, eventIDs []uint) (map[uint]bool, error) {
+	joined := make(map[uint]bool, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return joined, nil
+	}
+
+	var ids []uint
+	if err := r.db.Model(&entity.EventInside{}).
+		Where("event_insides.user = ? AND event_id IN ?", userID, eventIDs).
+		Pluck("event_id", &ids).Error; err != nil {
 		return nil, err
 	}
-	return events, nil
+	for _, id := range ids {
+		joined[id] = true
+	}
+	return joined, nil
 }
 
 // inside event
@@ -273,7 +450,7 @@ func (r *eventRepository) JoinEvent(eventInside *entity.EventInside) error {
 	}()
 
 	// ตั้งค่า lock timeout เพื่อป้องกันการล็อกที่ยาวนาน
-	if err := tx.Exec("SET innodb_lock_wait_timeout = 5").Error; err != nil {
+	if err := setLockTimeout(tx, 5); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to set lock timeout: %w", err)
 	}
@@ -323,7 +500,7 @@ func (r *eventRepository) UnJoinEvent(eventID uint, userID uint) error {
 	}()
 
 	// ตั้งค่า lock timeout เพื่อป้องกันการรอค้างนานเกินไป
-	if err := tx.Exec("SET innodb_lock_wait_timeout = 5").Error; err != nil {
+	if err := setLockTimeout(tx, 5); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to set lock timeout: %w", err)
 	}
@@ -339,29 +516,29 @@ func (r *eventRepository) UnJoinEvent(eventID uint, userID uint) error {
 
 	// ตรวจสอบว่าผู้ใช้เคยเข้าร่วมจริงหรือไม่
 	var eventInside entity.EventInside
-	if err := tx.Where("event_id = ? AND user = ?", eventID, userID).
+	if err := tx.Where("event_id = ? AND event_insides.user = ?", eventID, userID).
 		First(&eventInside).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("user has not joined this event")
 	}
-	if eventInside.File != "" {
-		if err := os.Remove(eventInside.File); err != nil && !os.IsNotExist(err) {
-			tx.Rollback()
-			return fmt.Errorf("failed to delete file: %w", err)
-		}
-	}
-
 	// ลบข้อมูลการเข้าร่วมของผู้ใช้
 	if err := tx.Delete(&eventInside).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to remove user from event: %w", err)
 	}
 
-	// เพิ่มจำนวน FreeSpace
-	event.FreeSpace += 1
-	if err := tx.Save(&event).Error; err != nil {
+	// ถ้ามีรายชื่อสำรอง ให้เลื่อนคนแรกเข้ามาแทน ไม่เช่นนั้นเพิ่มจำนวน FreeSpace
+	promoted, err := promoteFromWaitlist(tx, &event)
+	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to update event free space: %w", err)
+		return err
+	}
+	if !promoted {
+		event.FreeSpace += 1
+		if err := tx.Save(&event).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update event free space: %w", err)
+		}
 	}
 
 	// Commit transaction
@@ -372,33 +549,188 @@ func (r *eventRepository) UnJoinEvent(eventID uint, userID uint) error {
 	return nil
 }
 
-func (r *eventRepository) GetFilePath(eventID uint, userID uint) (string, error) {
-	var filePath string
-
-	err := r.db.Model(&entity.EventInside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
-		Pluck("file", &filePath).Error
+// เลื่อนนักศึกษาลำดับแรกในรายชื่อสำรองเข้าร่วมกิจกรรม ต้องเรียกภายใน transaction ที่ล็อกแถว event แล้ว
+func promoteFromWaitlist(tx *gorm.DB, event *entity.Event) (bool, error) {
+	var next entity.EventWaitlist
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ?", event.EventID).
+		Order("position").
+		First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve file path: %w", err)
+		return false, fmt.Errorf("failed to fetch waitlist: %w", err)
 	}
 
-	if filePath == "" {
-		return "", nil
+	if err := tx.Where("event_id = ? AND event_waitlists.user = ?", next.EventId, next.User).Delete(&entity.EventWaitlist{}).Error; err != nil {
+		return false, fmt.Errorf("failed to remove user from waitlist: %w", err)
 	}
 
-	return filePath, nil
+	eventInside := entity.EventInside{
+		EventId:   event.EventID,
+		User:      next.User,
+		State:     entity.StateJoined,
+		Certifier: event.Creator,
+	}
+	if err := tx.Create(&eventInside).Error; err != nil {
+		return false, fmt.Errorf("failed to create event inside record: %w", err)
+	}
+
+	news := entity.News{
+		Title:   "ได้รับสิทธิ์เข้าร่วมกิจกรรม",
+		UserID:  next.User,
+		Message: fmt.Sprintf("คุณได้รับการเลื่อนจากรายชื่อสำรองเข้าร่วมกิจกรรม '%s' แล้ว.", event.EventName),
+	}
+	if err := tx.Create(&news).Error; err != nil {
+		return false, fmt.Errorf("failed to send news to user %d: %w", next.User, err)
+	}
+	return true, nil
 }
 
-func (r *eventRepository) UploadFile(eventID uint, userID uint, filePath string) error {
-	if err := r.db.Model(&entity.EventInside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
-		Update("file", filePath).Error; err != nil {
-		return err
+// เลื่อนรายชื่อสำรองตามลำดับจนกว่า FreeSpace จะหมดหรือไม่มีคิวเหลือ ต้องเรียกภายใน transaction ที่ล็อกแถว event แล้ว
+func fillFromWaitlist(tx *gorm.DB, event *entity.Event) error {
+	remaining := event.FreeSpace
+	for remaining > 0 {
+		promoted, err := promoteFromWaitlist(tx, event)
+		if err != nil {
+			return err
+		}
+		if !promoted {
+			break
+		}
+		remaining--
+	}
+	if remaining == event.FreeSpace {
+		return nil
+	}
+	event.FreeSpace = remaining
+	if err := tx.Model(event).Update("free_space", remaining).Error; err != nil {
+		return fmt.Errorf("failed to update event free space: %w", err)
+	}
+	return nil
+}
+
+func (r *eventRepository) JoinWaitlist(eventID uint, userID uint) (uint, error) {
+	tx := r.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := setLockTimeout(tx, 5); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+
+	// ล็อกแถว Event เพื่อให้ลำดับในรายชื่อสำรองไม่ซ้ำกัน
+	var event entity.Event
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ?", eventID).
+		First(&event).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to fetch event: %w", err)
+	}
+
+	if event.FreeSpace > 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("event still has free space")
+	}
+
+	var joined int64
+	if err := tx.Model(&entity.EventInside{}).Where("event_id = ? AND event_insides.user = ?", eventID, userID).Count(&joined).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to check participation: %w", err)
+	}
+	if joined > 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("user has already joined this event")
+	}
+
+	var queued int64
+	if err := tx.Model(&entity.EventWaitlist{}).Where("event_id = ? AND event_waitlists.user = ?", eventID, userID).Count(&queued).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to check waitlist: %w", err)
+	}
+	if queued > 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("user is already on the waitlist")
+	}
+
+	var lastPosition uint
+	if err := tx.Model(&entity.EventWaitlist{}).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&lastPosition).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+
+	entry := entity.EventWaitlist{
+		EventId:  eventID,
+		User:     userID,
+		Position: lastPosition + 1,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	var rank int64
+	if err := tx.Model(&entity.EventWaitlist{}).
+		Where("event_id = ? AND position <= ?", eventID, entry.Position).
+		Count(&rank).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return uint(rank), nil
+}
+
+func (r *eventRepository) LeaveWaitlist(eventID uint, userID uint) error {
+	result := r.db.Where("event_id = ? AND event_waitlists.user = ?", eventID, userID).Delete(&entity.EventWaitlist{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user is not on the waitlist")
 	}
 	return nil
 }
 
-func (r *eventRepository) MyChecklist(userID uint, eventID uint) ([]entity.EventInside, error) {
+func (r *eventRepository) MyWaitlist(userID uint) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+	err := r.db.Table("event_waitlists AS w").
+		Select(`w.event_id, events.event_name, events.start_date,
+			(SELECT COUNT(*) FROM event_waitlists w2 WHERE w2.event_id = w.event_id AND w2.position <= w.position) AS position,
+			(SELECT COUNT(*) FROM event_waitlists w3 WHERE w3.event_id = w.event_id) AS total`).
+		Joins("JOIN events ON events.event_id = w.event_id").
+		Where("w.user = ?", userID).
+		Order("events.start_date").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+// บันทึกไฟล์หลักฐานพร้อมเปลี่ยนสถานะเป็น evidence_submitted
+func (r *eventRepository) UploadFile(transition *entity.ReviewTransition, filePath string, thumbnail string, audit *entity.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, &entity.EventInside{}, eventUserKeys(transition), transition,
+			map[string]interface{}{"file": filePath, "thumbnail": thumbnail}, audit)
+	})
+}
+
+func eventUserKeys(transition *entity.ReviewTransition) map[string]interface{} {
+	return map[string]interface{}{"event_id": transition.RefID, "user": transition.UserID}
+}
+
+func (r *eventRepository) MyChecklist(eventID uint) ([]entity.EventInside, error) {
 	var checklist []entity.EventInside
 	if err := r.db.Preload("Event").Preload("Student.Branch.Faculty").Where("event_id = ? ", eventID).Find(&checklist).Error; err != nil {
 		return nil, err
@@ -406,15 +738,105 @@ func (r *eventRepository) MyChecklist(userID uint, eventID uint) ([]entity.Event
 	return checklist, nil
 }
 
-func (r *eventRepository) UpdateEventStatusAndComment(eventID uint, userID uint, status bool, comment string) error {
+func (r *eventRepository) GetEventInsides(eventID uint, userIDs []uint) ([]entity.EventInside, error) {
+	var insides []entity.EventInside
+	if err := r.db.Where("event_id = ? AND event_insides.user IN ?", eventID, userIDs).Find(&insides).Error; err != nil {
+		return nil, fmt.Errorf("failed to get participants: %w", err)
+	}
+	return insides, nil
+}
+
+// บันทึกผลการตรวจหลายคนใน transaction เดียว และแจ้งผลให้นักศึกษาแต่ละคนผ่าน News
+// แถวที่สถานะถูกเปลี่ยนไปก่อนแล้วจะไม่ถูกบันทึกและคืน error รายคน
+// audits[i] คือ audit log ของ transitions[i] บันทึกเฉพาะแถวที่บันทึกผลได้
+func (r *eventRepository) ReviewEventInsides(eventID uint, transitions []entity.ReviewTransition, audits []*entity.AuditLog) ([]response.ReviewOutcome, error) {
+	outcomes := make([]response.ReviewOutcome, len(transitions))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var event entity.Event
+		if err := tx.Select("event_id", "event_name").First(&event, "event_id = ?", eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("event with ID %d not found", eventID)
+			}
+			return err
+		}
+
+		for i := range transitions {
+			transition := &transitions[i]
+			outcomes[i].UserID = transition.UserID
+			var audit *entity.AuditLog
+			if i < len(audits) {
+				audit = audits[i]
+			}
+			err := applyTransition(tx, &entity.EventInside{}, eventUserKeys(transition), transition,
+				map[string]interface{}{"comment": transition.Comment}, audit)
+			if errors.Is(err, ErrStateChanged) {
+				outcomes[i].Error = err.Error()
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update user %d: %w", transition.UserID, err)
+			}
+
+			news := entity.News{
+				Title:   "ผลการตรวจกิจกรรม",
+				UserID:  transition.UserID,
+				Message: reviewNewsMessage(event.EventName, transition),
+			}
+			if err := tx.Create(&news).Error; err != nil {
+				return fmt.Errorf("failed to send news to user %d: %w", transition.UserID, err)
+			}
+			outcomes[i].Updated = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+func reviewNewsMessage(eventName string, transition *entity.ReviewTransition) string {
+	switch transition.ToState {
+	case entity.StateApproved:
+		return fmt.Sprintf("กิจกรรม '%s' ได้รับการอนุมัติแล้ว.", eventName)
+	case entity.StateResubmissionRequested:
+		return fmt.Sprintf("กิจกรรม '%s' ต้องส่งหลักฐานใหม่: %s", eventName, transition.Comment)
+	default:
+		return fmt.Sprintf("กิจกรรม '%s' ไม่ได้รับการอนุมัติ: %s", eventName, transition.Comment)
+	}
+}
+
+func (r *eventRepository) UpdateInsideState(transition *entity.ReviewTransition, audit *entity.AuditLog) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, &entity.EventInside{}, eventUserKeys(transition), transition,
+			map[string]interface{}{"comment": transition.Comment}, audit)
+	})
+	if err != nil && !errors.Is(err, ErrStateChanged) {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	return err
+}
+
+func (r *eventRepository) MarkAttended(eventID uint, userID uint, attendedAt time.Time) error {
+	var eventInside entity.EventInside
+	if err := r.db.Where("event_id = ? AND event_insides.user = ?", eventID, userID).First(&eventInside).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("user has not joined this event")
+		}
+		return err
+	}
+	if eventInside.Attended {
+		return fmt.Errorf("user has already checked in")
+	}
+
 	updates := map[string]interface{}{
-		"status":  status,
-		"comment": comment,
+		"attended":    true,
+		"attended_at": attendedAt,
 	}
 	if err := r.db.Model(&entity.EventInside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
+		Where("event_id = ? AND event_insides.user = ?", eventID, userID).
 		Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to update event: %w", err)
+		return fmt.Errorf("failed to check in: %w", err)
 	}
 	return nil
 }
@@ -422,7 +844,7 @@ func (r *eventRepository) UpdateEventStatusAndComment(eventID uint, userID uint,
 func (r *eventRepository) AllEventInsideThisYear(userID uint, year uint) ([]entity.EventInside, error) {
 	var eventInsides []entity.EventInside
 	// year := 2568
-	err := r.db.Preload("Event").Joins("JOIN events ON events.event_id = event_insides.event_id").
+	err := r.db.Preload("Event").Preload("Teacher").Joins("JOIN events ON events.event_id = event_insides.event_id").
 		Where("event_insides.user = ?", userID).
 		Where("events.school_year = ?", year).
 		Find(&eventInsides).Error
@@ -433,8 +855,8 @@ func (r *eventRepository) AllEventInsideThisYear(userID uint, year uint) ([]enti
 }
 
 // outside event
-func (r *eventRepository) CreateEventOutside(outside entity.EventOutside) error {
-	if err := r.db.Create(&outside).Error; err != nil {
+func (r *eventRepository) CreateEventOutside(outside *entity.EventOutside) error {
+	if err := r.db.Create(outside).Error; err != nil {
 		return err
 	}
 	return nil
@@ -451,13 +873,6 @@ func (r *eventRepository) DeleteEventOutsideByID(eventID uint) error {
 		return fmt.Errorf("event not found: %w", err)
 	}
 
-	if event.File != "" {
-		if err := os.Remove(event.File); err != nil && !os.IsNotExist(err) {
-			tx.Rollback()
-			return fmt.Errorf("failed to remove file: %w", err)
-		}
-	}
-
 	// ลบ Event จากฐานข้อมูล
 	if err := tx.Where("event_id = ?", eventID).Delete(&entity.EventOutside{}).Error; err != nil {
 		tx.Rollback() // ยกเลิก Transaction ถ้าลบข้อมูลไม่สำเร็จ
@@ -479,43 +894,77 @@ func (r *eventRepository) GetEventOutsideByID(id uint) (*entity.EventOutside, er
 	return &outside, nil
 }
 
+func (r *eventRepository) CreateGeneratedForm(form *entity.GeneratedForm) error {
+	return r.db.Create(form).Error
+}
+
+func (r *eventRepository) GetGeneratedForm(serial string) (*entity.GeneratedForm, error) {
+	var form entity.GeneratedForm
+	if err := r.db.First(&form, "serial = ?", serial).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("form %s not found", serial)
+		}
+		return nil, err
+	}
+	return &form, nil
+}
+
 func (r *eventRepository) AllEventOutsideThisYear(userID uint, year uint) ([]entity.EventOutside, error) {
 	var eventOutside []entity.EventOutside
-	if err := r.db.Where("user = ? AND school_year = ?", userID, year).Find(&eventOutside).Error; err != nil {
+	if err := r.db.Where("event_outsides.user = ? AND school_year = ?", userID, year).Find(&eventOutside).Error; err != nil {
 		return nil, err
 	}
 	return eventOutside, nil
 }
 
-func (r *eventRepository) GetFilePathOutside(eventID uint, userID uint) (string, error) {
-	var filePath string
+// บันทึกแบบฟอร์มที่ลงนามแล้วพร้อมส่งให้ผู้ดูแลคณะตรวจ
+func (r *eventRepository) UploadFileOutside(transition *entity.ReviewTransition, filePath string, thumbnail string, certifierID uint, audit *entity.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, &entity.EventOutside{}, eventUserKeys(transition), transition,
+			map[string]interface{}{"file": filePath, "thumbnail": thumbnail, "certifier": certifierID, "comment": ""}, audit)
+	})
+}
 
-	err := r.db.Model(&entity.EventOutside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
-		Pluck("file", &filePath).Error
+// กิจกรรมภายนอกที่รอผู้ตรวจคนนี้ตรวจ เรียงตามวันที่ส่ง
+func (r *eventRepository) PendingOutsides(certifierID uint) ([]entity.EventOutside, error) {
+	var outsides []entity.EventOutside
+	err := r.db.Preload("Student.Branch.Faculty").
+		Where("certifier = ? AND state = ?", certifierID, entity.StateEvidenceSubmitted).
+		Order("state_changed_at").
+		Find(&outsides).Error
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve file path: %w", err)
+		return nil, fmt.Errorf("failed to get pending outside events: %w", err)
 	}
-
-	if filePath == "" {
-		return "", nil
-	}
-
-	return filePath, nil
+	return outsides, nil
 }
 
-func (r *eventRepository) UploadFileOutside(eventID uint, userID uint, filePath string) error {
-	if err := r.db.Model(&entity.EventOutside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
-		Update("file", filePath).Error; err != nil {
-		return err
+// บันทึกผลการตรวจกิจกรรมภายนอกและแจ้งผลให้นักศึกษาผ่าน News
+func (r *eventRepository) UpdateOutsideState(transition *entity.ReviewTransition, audit *entity.AuditLog) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var outside entity.EventOutside
+		if err := tx.Select("event_id", "event_name").First(&outside, "event_id = ?", transition.RefID).Error; err != nil {
+			return err
+		}
+		if err := applyTransition(tx, &entity.EventOutside{}, eventUserKeys(transition), transition,
+			map[string]interface{}{"comment": transition.Comment}, audit); err != nil {
+			return err
+		}
+		news := entity.News{
+			Title:   "ผลการตรวจกิจกรรมภายนอก",
+			UserID:  transition.UserID,
+			Message: reviewNewsMessage(outside.EventName, transition),
+		}
+		return tx.Create(&news).Error
+	})
+	if err != nil && !errors.Is(err, ErrStateChanged) {
+		return fmt.Errorf("failed to update outside event: %w", err)
 	}
-	return nil
+	return err
 }
 func (r *eventRepository) EventOutsideExists(eventID uint, userID uint) (bool, error) {
 	var count int64
 	err := r.db.Model(&entity.EventOutside{}).
-		Where("event_id = ? AND user = ?", eventID, userID).
+		Where("event_id = ? AND event_outsides.user = ?", eventID, userID).
 		Count(&count).Error
 	if err != nil {
 		return false, fmt.Errorf("failed to check event outside existence: %w", err)
@@ -13,6 +13,8 @@ type FacultyBranchRepository interface {
 	GetAllFaculties() ([]entity.Faculty, error)
 	UpdateFacultyByID(faculty *entity.Faculty) error
 	DeleteFacultyByID(facultyID uint) error
+	GetFacultyByID(facultyID uint) (*entity.Faculty, error)
+	GetFacultiesBySuperUser(userID uint) ([]entity.Faculty, error)
 	// UpdateSuperUser(facultyID uint, superUserID *uint) error
 
 	// branch
@@ -21,6 +23,7 @@ type FacultyBranchRepository interface {
 	UpdateBranchByID(branch *entity.Branch) error
 	DeleteBranchByID(branchID uint) error
 	BranchExists(branchID uint) (bool, error)
+	GetBranchByID(branchID uint) (*entity.Branch, error)
 }
 
 type facultyBranchRepository struct {
@@ -48,6 +51,25 @@ func (r *facultyBranchRepository) GetAllFaculties() ([]entity.Faculty, error) {
 	return faculties, nil
 }
 
+func (r *facultyBranchRepository) GetFacultyByID(facultyID uint) (*entity.Faculty, error) {
+	var faculty entity.Faculty
+	if err := r.db.First(&faculty, "faculty_id = ?", facultyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("faculty with ID %d not found", facultyID)
+		}
+		return nil, err
+	}
+	return &faculty, nil
+}
+
+func (r *facultyBranchRepository) GetFacultiesBySuperUser(userID uint) ([]entity.Faculty, error) {
+	var faculties []entity.Faculty
+	if err := r.db.Where("super_user = ?", userID).Find(&faculties).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch faculties: %w", err)
+	}
+	return faculties, nil
+}
+
 func (r *facultyBranchRepository) UpdateFacultyByID(faculty *entity.Faculty) error {
 	var existing entity.Faculty
 	if err := r.db.First(&existing, "faculty_id = ?", faculty.FacultyID).Error; err != nil {
@@ -151,6 +173,15 @@ func (r *facultyBranchRepository) DeleteBranchByID(branchID uint) error {
 		return err
 	}
 
+	// สาขาที่ยังถูกกำหนดสิทธิ์ในกิจกรรมอยู่ต้องแก้ไขกิจกรรมก่อน
+	var eventCount int64
+	if err := r.db.Model(&entity.EventBranch{}).Where("branch_id = ?", branchID).Count(&eventCount).Error; err != nil {
+		return err
+	}
+	if eventCount > 0 {
+		return fmt.Errorf("branch with ID %d is still assigned to %d events", branchID, eventCount)
+	}
+
 	if err := r.db.Delete(existingBranch).Error; err != nil {
 		return fmt.Errorf("failed to delete branch with ID %d: %w", existingBranch.BranchID, err)
 	}
@@ -158,6 +189,17 @@ func (r *facultyBranchRepository) DeleteBranchByID(branchID uint) error {
 	return nil
 }
 
+func (r *facultyBranchRepository) GetBranchByID(branchID uint) (*entity.Branch, error) {
+	var branch entity.Branch
+	if err := r.db.First(&branch, "branch_id = ?", branchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("branch with ID %d not found", branchID)
+		}
+		return nil, err
+	}
+	return &branch, nil
+}
+
 func (r *facultyBranchRepository) BranchExists(branchID uint) (bool, error) {
 	var branch entity.Branch
 	if err := r.db.Where("branch_id = ?", branchID).First(&branch).Error; err != nil {
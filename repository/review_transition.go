@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"errors"
+	"go-clean-arch/structure/entity"
+
+	"gorm.io/gorm"
+)
+
+// สถานะถูกเปลี่ยนโดยคำขออื่นระหว่างที่กำลังตรวจ
+var ErrStateChanged = errors.New("review state has changed, please reload and try again")
+
+// เปลี่ยน state ของแถวที่ตรงกับ keys เฉพาะเมื่อ state ปัจจุบันยังเป็น t.FromState
+// พร้อมอัปเดต fields อื่น บันทึกประวัติและ audit log ต้องเรียกภายใน transaction
+func applyTransition(tx *gorm.DB, model interface{}, keys map[string]interface{}, t *entity.ReviewTransition, fields map[string]interface{}, audit *entity.AuditLog) error {
+	updates := map[string]interface{}{
+		"state":            t.ToState,
+		"state_changed_at": t.CreatedAt,
+	}
+	for column, value := range fields {
+		updates[column] = value
+	}
+	result := tx.Model(model).Where(keys).Where("state = ?", t.FromState).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStateChanged
+	}
+	if err := tx.Create(t).Error; err != nil {
+		return err
+	}
+	return createAuditLog(tx, audit)
+}
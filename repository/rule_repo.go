@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/structure/entity"
+
+	"gorm.io/gorm"
+)
+
+type RuleRepository interface {
+	CreateRule(rule *entity.ActivityRule) error
+	GetAllRules() ([]entity.ActivityRule, error)
+	GetRuleByID(ruleID uint) (*entity.ActivityRule, error)
+	UpdateRuleByID(rule *entity.ActivityRule) error
+	DeleteRuleByID(ruleID uint) error
+	FindCandidateRules(facultyID uint, branchID uint, schoolYear uint) ([]entity.ActivityRule, error)
+}
+
+type ruleRepository struct {
+	db *gorm.DB
+}
+
+func NewRuleRepository(db *gorm.DB) RuleRepository {
+	return &ruleRepository{db: db}
+}
+
+func (r *ruleRepository) CreateRule(rule *entity.ActivityRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *ruleRepository) GetAllRules() ([]entity.ActivityRule, error) {
+	var rules []entity.ActivityRule
+	if err := r.db.Order("rule_id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *ruleRepository) GetRuleByID(ruleID uint) (*entity.ActivityRule, error) {
+	var rule entity.ActivityRule
+	if err := r.db.First(&rule, "rule_id = ?", ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rule with ID %d not found", ruleID)
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *ruleRepository) UpdateRuleByID(rule *entity.ActivityRule) error {
+	var existing entity.ActivityRule
+	if err := r.db.First(&existing, "rule_id = ?", rule.RuleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("rule with ID %d not found", rule.RuleID)
+		}
+		return err
+	}
+
+	existing.FacultyID = rule.FacultyID
+	existing.BranchID = rule.BranchID
+	existing.SchoolYear = rule.SchoolYear
+	existing.MinInsideHour = rule.MinInsideHour
+	existing.MinTotalHour = rule.MinTotalHour
+	existing.MaxOutsideHour = rule.MaxOutsideHour
+	existing.RequiredCategories = rule.RequiredCategories
+
+	return r.db.Save(&existing).Error
+}
+
+func (r *ruleRepository) DeleteRuleByID(ruleID uint) error {
+	result := r.db.Where("rule_id = ?", ruleID).Delete(&entity.ActivityRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete rule with ID %d: %w", ruleID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("rule with ID %d not found", ruleID)
+	}
+	return nil
+}
+
+// ดึงเกณฑ์ทั้งหมดที่อาจใช้กับนักศึกษาคนนี้ได้ ส่วนการเลือกเกณฑ์ที่เฉพาะเจาะจงที่สุดทำใน usecase
+func (r *ruleRepository) FindCandidateRules(facultyID uint, branchID uint, schoolYear uint) ([]entity.ActivityRule, error) {
+	var rules []entity.ActivityRule
+	err := r.db.
+		Where("faculty_id IS NULL OR faculty_id = ?", facultyID).
+		Where("branch_id IS NULL OR branch_id = ?", branchID).
+		Where("school_year IS NULL OR school_year = ?", schoolYear).
+		Find(&rules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/structure/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	CreateSession(session *entity.Session) error
+	GetSessionByTokenHash(tokenHash string) (*entity.Session, error)
+	RotateSession(sessionID string, oldHash string, newHash string, expiresAt time.Time) error
+	RevokeSession(sessionID string) error
+	RevokeAllSessions(userID uint) error
+	RevokeOtherSessions(userID uint, keepSessionID string) error
+	IsRevoked(sessionID string) (bool, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(session *entity.Session) error {
+	return r.db.Create(session).Error
+}
+
+// ค้นหาจาก refresh token ปัจจุบันหรือตัวก่อนหน้า (ใช้ตรวจจับการนำ token เก่ามาใช้ซ้ำ)
+func (r *sessionRepository) GetSessionByTokenHash(tokenHash string) (*entity.Session, error) {
+	var session entity.Session
+	err := r.db.Where("refresh_token_hash = ? OR previous_token_hash = ?", tokenHash, tokenHash).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) RotateSession(sessionID string, oldHash string, newHash string, expiresAt time.Time) error {
+	updates := map[string]interface{}{
+		"refresh_token_hash":  newHash,
+		"previous_token_hash": oldHash,
+		"expires_at":          expiresAt,
+	}
+	// อัปเดตเฉพาะเมื่อ token เดิมยังเป็นตัวปัจจุบัน ป้องกันการ refresh ซ้อนกัน
+	result := r.db.Model(&entity.Session{}).
+		Where("session_id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", sessionID, oldHash).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to rotate session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("session is no longer valid")
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeSession(sessionID string) error {
+	if err := r.db.Model(&entity.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeAllSessions(userID uint) error {
+	if err := r.db.Model(&entity.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke sessions for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeOtherSessions(userID uint, keepSessionID string) error {
+	if err := r.db.Model(&entity.Session{}).
+		Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke sessions for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// session ที่ไม่มีอยู่ถือว่าถูกเพิกถอน
+func (r *sessionRepository) IsRevoked(sessionID string) (bool, error) {
+	var session entity.Session
+	err := r.db.Select("revoked_at").Where("session_id = ?", sessionID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt != nil, nil
+}
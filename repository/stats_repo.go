@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"fmt"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type StatsRepository interface {
+	GroupStats(filter request.StatsFilter) ([]response.StatsGroup, error)
+	DoneStats(filter request.StatsFilter) ([]response.DoneStats, error)
+	StudentHours(filter request.StatsFilter) ([]response.StudentHour, error)
+	CompletedCategories(filter request.StatsFilter) ([]response.StudentCategory, error)
+}
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+// คอลัมน์ที่ใช้จัดกลุ่มตาม group_by (usecase ตรวจค่าแล้ว)
+func statsGroupColumns(groupBy string) []string {
+	columns := []string{"faculties.faculty_id", "faculties.faculty_name"}
+	if groupBy == "branch" || groupBy == "year" {
+		columns = append(columns, "branches.branch_id", "branches.branch_name")
+	}
+	if groupBy == "year" {
+		columns = append(columns, "students.year")
+	}
+	return columns
+}
+
+// นักศึกษาในขอบเขตของตัวกรอง (join สาขาและคณะ)
+func (r *statsRepository) students(filter request.StatsFilter) *gorm.DB {
+	query := r.db.Table("students").
+		Joins("JOIN branches ON branches.branch_id = students.branch_id").
+		Joins("JOIN faculties ON faculties.faculty_id = branches.faculty_id")
+	if len(filter.FacultyIDs) > 0 {
+		query = query.Where("faculties.faculty_id IN ?", filter.FacultyIDs)
+	}
+	if filter.FacultyID != 0 {
+		query = query.Where("faculties.faculty_id = ?", filter.FacultyID)
+	}
+	if filter.BranchID != 0 {
+		query = query.Where("branches.branch_id = ?", filter.BranchID)
+	}
+	if filter.Year != 0 {
+		query = query.Where("students.year = ?", filter.Year)
+	}
+	return query
+}
+
+// ชั่วโมงรวมรายคนของปีการศึกษา: ins (ภายในที่อนุมัติแล้ว + จำนวนกิจกรรมที่เข้าร่วม) และ outs (ภายนอกที่อนุมัติแล้ว)
+func (r *statsRepository) studentsWithHours(filter request.StatsFilter) *gorm.DB {
+	inside := r.db.Table("event_insides").
+		Select("event_insides.user AS user_id, SUM(CASE WHEN event_insides.state = ? THEN events.working_hour ELSE 0 END) AS hours, COUNT(*) AS joined", entity.StateApproved).
+		Joins("JOIN events ON events.event_id = event_insides.event_id").
+		Where("events.school_year = ?", filter.SchoolYear).
+		Group("event_insides.user")
+	outside := r.db.Table("event_outsides").
+		Select("event_outsides.user AS user_id, SUM(working_hour) AS hours").
+		Where("school_year = ? AND state = ?", filter.SchoolYear, entity.StateApproved).
+		Group("event_outsides.user")
+
+	return r.students(filter).
+		Joins("LEFT JOIN (?) AS ins ON ins.user_id = students.user_id", inside).
+		Joins("LEFT JOIN (?) AS outs ON outs.user_id = students.user_id", outside)
+}
+
+func (r *statsRepository) GroupStats(filter request.StatsFilter) ([]response.StatsGroup, error) {
+	columns := strings.Join(statsGroupColumns(filter.GroupBy), ", ")
+	var groups []response.StatsGroup
+	err := r.studentsWithHours(filter).
+		Select(columns + `, COUNT(*) AS students,
+			AVG(COALESCE(ins.hours, 0)) AS avg_inside_hour,
+			AVG(COALESCE(outs.hours, 0)) AS avg_outside_hour,
+			SUM(CASE WHEN ins.joined > 0 THEN 1 ELSE 0 END) AS participating_students`).
+		Group(columns).
+		Order(columns).
+		Scan(&groups).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group stats: %w", err)
+	}
+	return groups, nil
+}
+
+func (r *statsRepository) DoneStats(filter request.StatsFilter) ([]response.DoneStats, error) {
+	columns := strings.Join(statsGroupColumns(filter.GroupBy), ", ")
+	var stats []response.DoneStats
+	err := r.students(filter).
+		Joins("JOIN dones ON dones.user = students.user_id").
+		Where("dones.year = ?", filter.SchoolYear).
+		Select(columns+`,
+			SUM(CASE WHEN dones.state = ? THEN 1 ELSE 0 END) AS done_pending,
+			SUM(CASE WHEN dones.state = ? THEN 1 ELSE 0 END) AS done_approved,
+			SUM(CASE WHEN dones.state = ? THEN 1 ELSE 0 END) AS done_rejected,
+			SUM(CASE WHEN dones.state = ? THEN 1 ELSE 0 END) AS done_resubmission`,
+			entity.StateEvidenceSubmitted, entity.StateApproved, entity.StateRejected, entity.StateResubmissionRequested).
+		Group(columns).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get done stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *statsRepository) StudentHours(filter request.StatsFilter) ([]response.StudentHour, error) {
+	var hours []response.StudentHour
+	err := r.studentsWithHours(filter).
+		Select(`students.user_id, faculties.faculty_id, branches.branch_id, students.year,
+			COALESCE(ins.hours, 0) AS inside_hour, COALESCE(outs.hours, 0) AS outside_hour`).
+		Scan(&hours).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student hours: %w", err)
+	}
+	return hours, nil
+}
+
+// ประเภทกิจกรรมภายในที่ผ่านการตรวจแล้วของนักศึกษาในขอบเขต
+func (r *statsRepository) CompletedCategories(filter request.StatsFilter) ([]response.StudentCategory, error) {
+	var categories []response.StudentCategory
+	err := r.students(filter).
+		Joins("JOIN event_insides ON event_insides.user = students.user_id").
+		Joins("JOIN events ON events.event_id = event_insides.event_id").
+		Where("events.school_year = ?", filter.SchoolYear).
+		Where("event_insides.state = ?", entity.StateApproved).
+		Where("events.category <> ?", "").
+		Distinct("students.user_id", "events.category").
+		Scan(&categories).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed categories: %w", err)
+	}
+	return categories, nil
+}
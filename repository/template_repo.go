@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"go-clean-arch/structure/entity"
+
+	"gorm.io/gorm"
+)
+
+type TemplateRepository interface {
+	CreateTemplate(template *entity.DocumentTemplate) error
+	GetAllTemplates() ([]entity.DocumentTemplate, error)
+	GetTemplateByID(templateID uint) (*entity.DocumentTemplate, error)
+	DeleteTemplateByID(templateID uint) error
+	FindTemplate(name string, schoolYear uint) (*entity.DocumentTemplate, error)
+}
+
+type templateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+func (r *templateRepository) CreateTemplate(template *entity.DocumentTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *templateRepository) GetAllTemplates() ([]entity.DocumentTemplate, error) {
+	var templates []entity.DocumentTemplate
+	if err := r.db.Omit("content").Order("name, template_id DESC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *templateRepository) GetTemplateByID(templateID uint) (*entity.DocumentTemplate, error) {
+	var template entity.DocumentTemplate
+	if err := r.db.First(&template, "template_id = ?", templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("template with ID %d not found", templateID)
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *templateRepository) DeleteTemplateByID(templateID uint) error {
+	result := r.db.Delete(&entity.DocumentTemplate{}, "template_id = ?", templateID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("template with ID %d not found", templateID)
+	}
+	return nil
+}
+
+// หาแม่แบบล่าสุดของปีการศึกษานั้น ถ้าไม่มีใช้แม่แบบที่ไม่ระบุปี (คืน nil ถ้าไม่มีเลย)
+func (r *templateRepository) FindTemplate(name string, schoolYear uint) (*entity.DocumentTemplate, error) {
+	var template entity.DocumentTemplate
+	err := r.db.Where("name = ? AND (school_year = ? OR school_year IS NULL)", name, schoolYear).
+		Order("CASE WHEN school_year IS NULL THEN 1 ELSE 0 END, template_id DESC").
+		First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
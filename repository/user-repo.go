@@ -6,6 +6,7 @@ import (
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/structure/response"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,9 +14,15 @@ import (
 type UserRepository interface {
 	CreateTeacher(user *entity.User, teacher *entity.Teacher) error
 	CreateStudent(user *entity.User, student *entity.Student) error
+	CreateStudents(users []entity.User, students []entity.Student) error
+	ExistingEmails(emails []string) ([]string, error)
+	ExistingStudents(codes []string, phones []string) ([]entity.Student, error)
 	GetUserByEmail(email string) (*entity.User, error)
+	GetUserByID(userID uint) (*entity.User, error)
 	CreateDones(userID uint,year uint,superUserID uint)error
+	ResubmitDone(transition *entity.ReviewTransition, superUserID uint, audit *entity.AuditLog) error
 	GetTotalWorkingHours(userID uint, year uint) (uint, uint, error) 
+	GetCompletedCategories(userID uint, year uint) ([]string, error)
 
 	// GetTeacherByID(userID uint) (*entity.Teacher, error)
 	GetTeacherByID(userID uint) (*entity.Teacher, bool, error)
@@ -26,12 +33,17 @@ type UserRepository interface {
 	GetSuperUserForStudent(userID uint) (*uint, error) 
 	GetStudentsAndYearsByCertifier(certifierID uint) ([]response.StudentYear, error)
 	GetDone(userID uint,year uint) (*entity.Done,error) 
+	GetDoneByCertifier(certifierID uint, userID uint) (*entity.Done, error)
+	DonesByFaculty(facultyID uint, year uint) ([]entity.Done, error)
 
 	UpdateTeacherByID(teacher *entity.Teacher) error
 	UpdateStudentByID(student *entity.Student) error
-	UpdateStatusDones(certifierID uint, userID uint, status bool, comment string) error 
+	UpdateDoneState(transition *entity.ReviewTransition, audit *entity.AuditLog) error
 
 	UpdateRoleByID(userID uint, role string) error
+	UpdatePassword(userID uint, hashedPassword string) error
+	CreatePasswordReset(reset *entity.PasswordReset) error
+	ResetPassword(tokenHash string, hashedPassword string) (uint, error)
 	
 }
 
@@ -93,6 +105,42 @@ func (r *userRepository) CreateStudent(user *entity.User, student *entity.Studen
 	return nil
 }
 
+// สร้างผู้ใช้และนักศึกษาหลายคนใน transaction เดียว (students[i] เป็นของ users[i])
+func (r *userRepository) CreateStudents(users []entity.User, students []entity.Student) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Student", "Teacher").Create(&users).Error; err != nil {
+			return err
+		}
+		for i := range students {
+			students[i].UserID = users[i].UserID
+		}
+		return tx.Omit("Branch").Create(&students).Error
+	})
+}
+
+func (r *userRepository) ExistingEmails(emails []string) ([]string, error) {
+	var existing []string
+	if len(emails) == 0 {
+		return existing, nil
+	}
+	if err := r.db.Model(&entity.User{}).Where("email IN ?", emails).Pluck("email", &existing).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// นักศึกษาที่มีรหัสหรือเบอร์โทรศัพท์ซ้ำกับที่ระบุ
+func (r *userRepository) ExistingStudents(codes []string, phones []string) ([]entity.Student, error) {
+	var students []entity.Student
+	if len(codes) == 0 && len(phones) == 0 {
+		return students, nil
+	}
+	if err := r.db.Where("code IN ? OR phone IN ?", codes, phones).Find(&students).Error; err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
 func (r *userRepository) GetUserByEmail(email string) (*entity.User, error) {
 	var user entity.User
 	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
@@ -101,6 +149,14 @@ func (r *userRepository) GetUserByEmail(email string) (*entity.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) GetUserByID(userID uint) (*entity.User, error) {
+	var user entity.User
+	if err := r.db.Where("user_id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // information
 func (r *userRepository) GetTeacherByID(userID uint) (*entity.Teacher, bool, error) {
 	var teacher entity.Teacher
@@ -229,6 +285,81 @@ func (r *userRepository) UpdateRoleByID(userID uint, role string) error {
 	return nil
 }
 
+func (r *userRepository) UpdatePassword(userID uint, hashedPassword string) error {
+	result := r.db.Model(&entity.User{}).Where("user_id = ?", userID).Update("password", hashedPassword)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update password: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", userID)
+	}
+	return nil
+}
+
+func (r *userRepository) CreatePasswordReset(reset *entity.PasswordReset) error {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// token เก่าที่ยังไม่ได้ใช้ของผู้ใช้คนนี้ให้ใช้ไม่ได้อีก
+	if err := tx.Where("user_id = ? AND used_at IS NULL", reset.UserID).Delete(&entity.PasswordReset{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Create(reset).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ใช้ token รีเซ็ตรหัสผ่านและเปลี่ยนรหัสผ่านใน transaction เดียวกัน
+func (r *userRepository) ResetPassword(tokenHash string, hashedPassword string) (uint, error) {
+	tx := r.db.Begin()
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	var reset entity.PasswordReset
+	if err := tx.Where("token_hash = ?", tokenHash).First(&reset).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("invalid or expired reset token")
+		}
+		return 0, err
+	}
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		tx.Rollback()
+		return 0, fmt.Errorf("invalid or expired reset token")
+	}
+
+	// ทำเครื่องหมายว่าใช้แล้ว (ป้องกันการใช้ซ้ำพร้อมกัน)
+	result := tx.Model(&entity.PasswordReset{}).
+		Where("token_hash = ? AND used_at IS NULL", tokenHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		tx.Rollback()
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return 0, fmt.Errorf("invalid or expired reset token")
+	}
+
+	if err := tx.Model(&entity.User{}).Where("user_id = ?", reset.UserID).Update("password", hashedPassword).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	return reset.UserID, nil
+}
+
 func (r *userRepository) GetSuperUserForStudent(userID uint) (*uint, error) {
 	var superUserID *uint
 	err := r.db.Model(&entity.Student{}).
@@ -244,41 +375,41 @@ func (r *userRepository) GetSuperUserForStudent(userID uint) (*uint, error) {
 	return superUserID, nil
 }
 
+// สร้างรายการส่งตรวจใหม่ในสถานะ evidence_submitted
 func (r *userRepository) CreateDones(userID uint, year uint, superUserID uint) error {
-	var done entity.Done
-
-	err := r.db.Where("user = ? AND year = ?", userID, year).First(&done).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			newDone := entity.Done{
-				User:    userID,
-				Certifier: superUserID,
-				Year:      year,
-				Status:    false,
-			}
-			return r.db.Create(&newDone).Error
-		}
-		return err
+	now := time.Now()
+	newDone := entity.Done{
+		User:           userID,
+		Certifier:      superUserID,
+		Year:           year,
+		State:          entity.StateEvidenceSubmitted,
+		StateChangedAt: &now,
 	}
+	return r.db.Create(&newDone).Error
+}
 
-	if !done.Status {
-		done.Comment = ""
-		done.Certifier = superUserID 
-		return r.db.Save(&done).Error
-	}
+// ส่งตรวจซ้ำ ล้างความเห็นเดิมและส่งให้ผู้ตรวจปัจจุบันของคณะ
+func (r *userRepository) ResubmitDone(transition *entity.ReviewTransition, superUserID uint, audit *entity.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, &entity.Done{}, doneKeys(transition), transition,
+			map[string]interface{}{"comment": "", "certifier": superUserID}, audit)
+	})
+}
 
-	return fmt.Errorf("document for year %d already approved", year)
+func doneKeys(transition *entity.ReviewTransition) map[string]interface{} {
+	return map[string]interface{}{"user": transition.UserID, "year": transition.RefID}
 }
 
 func (r *userRepository) GetTotalWorkingHours(userID uint, year uint) (uint, uint, error) {
 	var eventOutsideHours uint
 	var eventInsideHours uint
 
-	// รวมชั่วโมงจาก EventOutside
+	// รวมชั่วโมงจาก EventOutside ที่ผู้ดูแลคณะอนุมัติแล้ว
 	err := r.db.Model(&entity.EventOutside{}).
 		Select("COALESCE(SUM(working_hour), 0)").
-		Where("user = ?", userID).
+		Where("event_outsides.user = ?", userID).
 		Where("school_year = ?", year).
+		Where("state = ?", entity.StateApproved).
 		Scan(&eventOutsideHours).Error
 	if err != nil {
 		return 0, 0, err
@@ -290,7 +421,7 @@ func (r *userRepository) GetTotalWorkingHours(userID uint, year uint) (uint, uin
 		Select("COALESCE(SUM(events.working_hour), 0)").
 		Where("event_insides.user = ?", userID).
 		Where("events.school_year = ?", year).
-		Where("event_insides.status = ?", true).
+		Where("event_insides.state = ?", entity.StateApproved).
 		Scan(&eventInsideHours).Error
 	if err != nil {
 		return 0, 0, err
@@ -299,6 +430,24 @@ func (r *userRepository) GetTotalWorkingHours(userID uint, year uint) (uint, uin
 	return eventOutsideHours, eventInsideHours, nil
 }
 
+func (r *userRepository) GetCompletedCategories(userID uint, year uint) ([]string, error) {
+	var categories []string
+
+	// ประเภทกิจกรรมภายในที่ผ่านการตรวจแล้ว
+	err := r.db.Model(&entity.EventInside{}).
+		Joins("JOIN events ON event_insides.event_id = events.event_id").
+		Where("event_insides.user = ?", userID).
+		Where("events.school_year = ?", year).
+		Where("event_insides.state = ?", entity.StateApproved).
+		Where("events.category <> ?", "").
+		Distinct().
+		Pluck("events.category", &categories).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completed categories: %w", err)
+	}
+	return categories, nil
+}
+
 func (r *userRepository) GetStudentsAndYearsByCertifier(certifierID uint) ([]response.StudentYear, error) {
 	var result []response.StudentYear
 
@@ -307,8 +456,7 @@ func (r *userRepository) GetStudentsAndYearsByCertifier(certifierID uint) ([]res
 		Joins("JOIN branches ON students.branch_id = branches.branch_id").
 		Joins("JOIN faculties ON branches.faculty_id = faculties.faculty_id").
 		Where("dones.certifier = ?", certifierID).
-		Where("dones.status = ?", false).
-		Where("dones.comment = ?","").
+		Where("dones.state = ?", entity.StateEvidenceSubmitted).
 		Select("students.user_id, students.title_name, students.first_name, students.last_name, students.phone, students.code, branches.branch_id, branches.branch_name, faculties.faculty_id, faculties.faculty_name, dones.year").
 		Scan(&result).Error
 
@@ -329,7 +477,7 @@ func (r *userRepository) GetStudentsAndYearsByCertifier(certifierID uint) ([]res
 
 func (r *userRepository) GetDone(userID uint, year uint) (*entity.Done, error) {
 	var done entity.Done
-	err := r.db.Where("user = ? AND year = ?", userID, year).First(&done).Error
+	err := r.db.Preload("Teacher").Where("dones.user = ? AND year = ?", userID, year).First(&done).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil // ยังไม่ส่งข้อมูล
 	}
@@ -339,16 +487,40 @@ func (r *userRepository) GetDone(userID uint, year uint) (*entity.Done, error) {
 	return &done, nil
 }
 
+// ผลส่งตรวจทั้งหมดของนักศึกษาในคณะ ในปีการศึกษาที่ระบุ
+func (r *userRepository) DonesByFaculty(facultyID uint, year uint) ([]entity.Done, error) {
+	var dones []entity.Done
+	err := r.db.Preload("Student.Branch.Faculty").Preload("Teacher").
+		Joins("JOIN students ON dones.user = students.user_id").
+		Joins("JOIN branches ON students.branch_id = branches.branch_id").
+		Where("branches.faculty_id = ? AND dones.year = ?", facultyID, year).
+		Order("branches.branch_name, students.code").
+		Find(&dones).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dones: %w", err)
+	}
+	return dones, nil
+}
 
-func (r *userRepository) UpdateStatusDones(certifierID uint, userID uint, status bool, comment string) error {
-	updates := map[string]interface{}{
-		"status":  status,
-		"comment": comment,
+func (r *userRepository) GetDoneByCertifier(certifierID uint, userID uint) (*entity.Done, error) {
+	var done entity.Done
+	err := r.db.Where("certifier = ? AND dones.user = ?", certifierID, userID).First(&done).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	if err := r.db.Model(&entity.Done{}).
-		Where("certifier = ? AND user = ?", certifierID, userID).
-		Updates(updates).Error; err != nil {
+	return &done, nil
+}
+
+func (r *userRepository) UpdateDoneState(transition *entity.ReviewTransition, audit *entity.AuditLog) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return applyTransition(tx, &entity.Done{}, doneKeys(transition), transition,
+			map[string]interface{}{"comment": transition.Comment}, audit)
+	})
+	if err != nil && !errors.Is(err, ErrStateChanged) {
 		return fmt.Errorf("failed to update event: %w", err)
 	}
-	return nil
-}
\ No newline at end of file
+	return err
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// บันทึกการเปลี่ยนแปลงข้อมูลทุกครั้ง เพิ่มได้อย่างเดียว ห้ามแก้ไขหรือลบ
+// ActorID = 0 หมายถึงผู้ที่ยังไม่ได้เข้าสู่ระบบ (เช่น สมัครสมาชิก รีเซ็ตรหัสผ่าน)
+// Before/After เก็บเป็น JSON (ว่าง = ไม่มีค่า)
+type AuditLog struct {
+	AuditID    uint      `gorm:"primaryKey;autoIncrement" json:"audit_id"`
+	ActorID    uint      `gorm:"not null;index" json:"actor_id"`
+	ActorRole  string    `gorm:"size:20" json:"actor_role"`
+	Action     string    `gorm:"size:50;not null;index" json:"action"`
+	TargetType string    `gorm:"size:30;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"size:64;index:idx_audit_target" json:"target_id"`
+	Before     string    `gorm:"type:text" json:"before"`
+	After      string    `gorm:"type:text" json:"after"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
@@ -3,21 +3,38 @@ package entity
 import "time"
 
 type Event struct {
-	EventID        uint      `gorm:"primaryKey;autoIncrement" json:"event_id"`
-	EventName      string    `gorm:"not null" json:"event_name"`
-	Creator        uint      `gorm:"not null" json:"creator"`
-	StartDate      time.Time `gorm:"not null" json:"start_date"`
-	SchoolYear     uint      `gorm:"not null" json:"school_year" `
-	WorkingHour    uint      `gorm:"not null" json:"working_hour"`
-	FreeSpace      uint      `gorm:"not null" json:"free_space"`
-	Location       string    `gorm:"not null" json:"location"`
-	Detail         string    `json:"detail"`
-	BranchIDs      string    `gorm:"type:json" json:"branches"`
-	Years          string    `gorm:"type:json" json:"years"`
-	AllowAllBranch bool      `json:"allow_all_branch"`
-	AllowAllYear   bool      `json:"allow_all_year"`
-	Status         bool      `gorm:"default:true" json:"status"`
-	Teacher        Teacher   `gorm:"foreignKey:Creator;references:UserID" json:"teacher"`
+	EventID        uint          `gorm:"primaryKey;autoIncrement" json:"event_id"`
+	EventName      string        `gorm:"not null" json:"event_name"`
+	Creator        uint          `gorm:"not null" json:"creator"`
+	StartDate      time.Time     `gorm:"not null" json:"start_date"`
+	SchoolYear     uint          `gorm:"not null" json:"school_year" `
+	WorkingHour    uint          `gorm:"not null" json:"working_hour"`
+	FreeSpace      uint          `gorm:"not null" json:"free_space"`
+	Location       string        `gorm:"not null" json:"location"`
+	Detail         string        `json:"detail"`
+	Category       string        `gorm:"size:100" json:"category"`
+	AllowAllBranch bool          `json:"allow_all_branch"`
+	AllowAllYear   bool          `json:"allow_all_year"`
+	Status         bool          `gorm:"default:true" json:"status"`
+	Teacher        Teacher       `gorm:"foreignKey:Creator;references:UserID" json:"teacher"`
+	Branches       []EventBranch `gorm:"foreignKey:EventID;references:EventID" json:"branches"`
+	Years          []EventYear   `gorm:"foreignKey:EventID;references:EventID" json:"years"`
+}
+
+// สาขาที่มีสิทธิ์เข้าร่วมกิจกรรม (ใช้เมื่อ AllowAllBranch = false)
+// ลบสาขาที่ยังผูกกับกิจกรรมอยู่ไม่ได้ (RESTRICT) ต้องจัดการกิจกรรมก่อน
+type EventBranch struct {
+	EventID  uint   `gorm:"primaryKey" json:"event_id"`
+	BranchID uint   `gorm:"primaryKey;index" json:"branch_id"`
+	Event    Event  `gorm:"foreignKey:EventID;references:EventID;constraint:OnDelete:CASCADE;" json:"-"`
+	Branch   Branch `gorm:"foreignKey:BranchID;references:BranchID;constraint:OnDelete:RESTRICT;" json:"-"`
+}
+
+// ชั้นปีที่มีสิทธิ์เข้าร่วมกิจกรรม (ใช้เมื่อ AllowAllYear = false)
+type EventYear struct {
+	EventID uint  `gorm:"primaryKey" json:"event_id"`
+	Year    uint  `gorm:"primaryKey;index" json:"year"`
+	Event   Event `gorm:"foreignKey:EventID;references:EventID;constraint:OnDelete:CASCADE;" json:"-"`
 }
 
 type EventInside struct {
@@ -28,9 +45,26 @@ type EventInside struct {
 	Student   Student `gorm:"foreignKey:User;references:UserID" json:"student"`
 	Certifier uint    `gorm:"default:null" json:"certifier"`
 	Teacher   Teacher `gorm:"foreignKey:Certifier;references:UserID" json:"teacher"`
-	Status    bool    `json:"status"`
-	Comment   string  `json:"comment"`
-	File      string  `gorm:"size:255" json:"file"`
+	// สถานะการตรวจ ดู ReviewState
+	State          ReviewState `gorm:"size:30;not null;default:joined;index" json:"state"`
+	StateChangedAt *time.Time  `gorm:"default:null" json:"state_changed_at"`
+	Comment        string      `json:"comment"`
+	File           string      `gorm:"size:255" json:"file"`
+	// ภาพย่อของรูปแรกเมื่อส่งหลักฐานเป็นรูปถ่าย (ว่างถ้าส่งเป็น PDF)
+	Thumbnail string `gorm:"size:255" json:"thumbnail"`
+	// เช็คชื่อผ่าน QR code
+	Attended   bool       `gorm:"default:false" json:"attended"`
+	AttendedAt *time.Time `gorm:"default:null" json:"attended_at"`
+}
+
+// รายชื่อสำรองเมื่อกิจกรรมเต็ม เรียงตาม Position (FIFO)
+type EventWaitlist struct {
+	EventId   uint      `gorm:"primaryKey" json:"event_id"`
+	User      uint      `gorm:"primaryKey" json:"user_id"`
+	Event     Event     `gorm:"foreignKey:EventId;references:EventID;constraint:OnDelete:CASCADE;" json:"event"`
+	Student   Student   `gorm:"foreignKey:User;references:UserID" json:"student"`
+	Position  uint      `gorm:"not null;index" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type EventOutside struct {
@@ -44,10 +78,22 @@ type EventOutside struct {
 	WorkingHour uint      `json:"working_hour"`
 	Location    string    `gorm:"not null" json:"location"`
 	File        string    `gorm:"size:255" json:"file"`
-	// Certifier   uint      `gorm:"default:null" json:"certifier"`
-	// Teacher     Teacher   `gorm:"foreignKey:Certifier;references:UserID" json:"teacher"`
-	// Status      bool      `json:"status"`
-	// Comment     string    `json:"comment"`
+	Thumbnail   string    `gorm:"size:255" json:"thumbnail"`
+	// ผู้ดูแลคณะที่ตรวจกิจกรรมนี้ กำหนดเมื่อส่งหลักฐาน
+	Certifier uint    `gorm:"default:null;index" json:"certifier"`
+	Teacher   Teacher `gorm:"foreignKey:Certifier;references:UserID" json:"teacher"`
+	// สถานะการตรวจ ดู ReviewState นับชั่วโมงเฉพาะที่ approved
+	State          ReviewState `gorm:"size:30;not null;default:joined;index" json:"state"`
+	StateChangedAt *time.Time  `gorm:"default:null" json:"state_changed_at"`
+	Comment        string      `json:"comment"`
+}
+
+// แบบฟอร์มกิจกรรมภายนอกที่ระบบออกให้ ใช้ยืนยันเอกสารกระดาษผ่าน QR code
+type GeneratedForm struct {
+	Serial      string    `gorm:"primaryKey;size:36" json:"serial"`
+	OutsideID   uint      `gorm:"not null;index" json:"outside_id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	GeneratedAt time.Time `gorm:"not null" json:"generated_at"`
 }
 
 // type Done struct {
@@ -64,8 +110,10 @@ type Done struct {
 	Certifier uint    `gorm:"default:null" json:"certifier"`
 	Teacher   Teacher `gorm:"foreignKey:Certifier;references:UserID" json:"teacher"`
 	Year      uint    `gorm:"not null" json:"year"` // เพิ่ม field นี้
-	Status    bool    `json:"status"`               // ใช้ *bool ให้ null ได้ (รอตรวจ)
-	Comment   string  `json:"comment"`
+	// สถานะการตรวจ ดู ReviewState
+	State          ReviewState `gorm:"size:30;not null;default:evidence_submitted;index" json:"state"`
+	StateChangedAt *time.Time  `gorm:"default:null" json:"state_changed_at"`
+	Comment        string      `json:"comment"`
 	// UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
@@ -80,8 +128,8 @@ type News struct {
 }
 
 type Permission struct {
-	BranchIDs      string `json:"branches"`
-	Years          string `json:"years"`
+	BranchIDs      []uint `json:"branches"`
+	Years          []uint `json:"years"`
 	AllowAllBranch bool   `json:"allow_all_branch"`
 	AllowAllYear   bool   `json:"allow_all_year"`
 }
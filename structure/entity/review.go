@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// สถานะการตรวจของการเข้าร่วมกิจกรรมภายใน (EventInside) และการส่งตรวจประจำปี (Done)
+type ReviewState string
+
+const (
+	StateJoined                ReviewState = "joined"
+	StateEvidenceSubmitted     ReviewState = "evidence_submitted"
+	StateApproved              ReviewState = "approved"
+	StateRejected              ReviewState = "rejected"
+	StateResubmissionRequested ReviewState = "resubmission_requested"
+)
+
+// ชนิดของรายการที่ถูกตรวจ ใช้แยกประวัติใน ReviewTransition
+const (
+	ReviewKindInside  = "inside"
+	ReviewKindOutside = "outside"
+	ReviewKindDone    = "done"
+)
+
+// ประวัติการเปลี่ยนสถานะการตรวจ หนึ่งแถวต่อหนึ่งครั้งที่เปลี่ยน
+// RefID คือ event_id เมื่อ Kind = inside หรือ outside และปีการศึกษาเมื่อ Kind = done
+type ReviewTransition struct {
+	TransitionID uint        `gorm:"primaryKey;autoIncrement" json:"transition_id"`
+	Kind         string      `gorm:"size:10;not null;index:idx_review_transition_ref" json:"kind"`
+	RefID        uint        `gorm:"not null;index:idx_review_transition_ref" json:"ref_id"`
+	UserID       uint        `gorm:"not null;index:idx_review_transition_ref" json:"user_id"`
+	FromState    ReviewState `gorm:"size:30" json:"from_state"`
+	ToState      ReviewState `gorm:"size:30;not null" json:"to_state"`
+	ActorID      uint        `gorm:"not null" json:"actor_id"`
+	Comment      string      `json:"comment"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+// ActivityRule เกณฑ์ชั่วโมงกิจกรรมสำหรับการส่งผล (Done)
+// ถ้า FacultyID / BranchID / SchoolYear เป็น null หมายถึงใช้กับทุกค่า
+type ActivityRule struct {
+	RuleID             uint    `gorm:"primaryKey;autoIncrement" json:"rule_id"`
+	FacultyID          *uint   `gorm:"default:null;index" json:"faculty_id"`
+	Faculty            Faculty `gorm:"foreignKey:FacultyID;references:FacultyID;constraint:OnDelete:CASCADE;" json:"-"`
+	BranchID           *uint   `gorm:"default:null;index" json:"branch_id"`
+	Branch             Branch  `gorm:"foreignKey:BranchID;references:BranchID;constraint:OnDelete:CASCADE;" json:"-"`
+	SchoolYear         *uint   `gorm:"default:null;index" json:"school_year"`
+	MinInsideHour      uint    `gorm:"not null" json:"min_inside_hour"`
+	MinTotalHour       uint    `gorm:"not null" json:"min_total_hour"`
+	MaxOutsideHour     *uint   `gorm:"default:null" json:"max_outside_hour"` // null = นับได้ไม่จำกัด
+	RequiredCategories string  `gorm:"type:json" json:"required_categories"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Session ของการเข้าสู่ระบบ เก็บ refresh token ปัจจุบัน (hash) เพื่อหมุนเวียนและเพิกถอนได้
+type Session struct {
+	SessionID         string     `gorm:"primaryKey;size:36" json:"session_id"`
+	UserID            uint       `gorm:"not null;index" json:"user_id"`
+	User              User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	RefreshTokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	PreviousTokenHash string     `gorm:"size:64;index" json:"-"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt         *time.Time `gorm:"default:null" json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// token รีเซ็ตรหัสผ่าน ใช้ได้ครั้งเดียวและมีวันหมดอายุ เก็บเฉพาะค่า hash
+type PasswordReset struct {
+	TokenHash string     `gorm:"primaryKey;size:64" json:"-"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"default:null" json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// แม่แบบเอกสาร PDF ที่ผู้ดูแลอัปโหลด (Content เป็น JSON ตามรูปแบบของ filesystem.PdfTemplate)
+// ถ้า SchoolYear เป็น null หมายถึงใช้กับทุกปีการศึกษา
+type DocumentTemplate struct {
+	TemplateID uint      `gorm:"primaryKey;autoIncrement" json:"template_id"`
+	Name       string    `gorm:"size:100;not null;index" json:"name"`
+	SchoolYear *uint     `gorm:"default:null;index" json:"school_year"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+	UploadedBy uint      `gorm:"not null" json:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
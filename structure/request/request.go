@@ -29,10 +29,63 @@ type EventRequest struct {
 	WorkingHour uint   `json:"working_hour"`
 	SchoolYear  uint   `json:"school_year"`
 	Location    string `json:"location"`
-	FreeSpace   uint   `json:"free_space"`
+	FreeSpace   uint   `json:"free_space"` // จำนวนที่รับตอนสร้าง ไม่ถูกใช้ตอนแก้ไข
 	Detail      string `json:"detail"`
+	Category    string `json:"category"`
 	Branches    []uint `json:"branches"`
 	Years       []uint `json:"years"`
+	// จำนวนที่รับทั้งหมดใหม่ ใช้เฉพาะตอนแก้ไข (ไม่ส่ง = ไม่เปลี่ยน) ต้องไม่น้อยกว่าผู้เข้าร่วมปัจจุบัน
+	Limit *uint `json:"limit,omitempty"`
+}
+
+// ตัวกรอง/การแบ่งหน้าของรายการกิจกรรม (รับจาก query string)
+type EventFilter struct {
+	Page       int    `query:"page"`
+	Limit      int    `query:"limit"`
+	SchoolYear uint   `query:"school_year"`
+	Branch     uint   `query:"branch"`
+	Year       uint   `query:"year"`
+	Creator    uint   `query:"creator"`
+	DateFrom   string `query:"date_from"` // รูปแบบ 2006-01-02
+	DateTo     string `query:"date_to"`   // รวมวันที่ date_to ด้วย
+	Status     *bool  `query:"status"`
+	Search     string `query:"search"` // ค้นหาจากชื่อกิจกรรมหรือสถานที่
+	Sort       string `query:"sort"`
+	Order      string `query:"order"`
+}
+
+// ตัวกรอง audit log (รับจาก query string)
+type AuditFilter struct {
+	Page       int    `query:"page"`
+	Limit      int    `query:"limit"`
+	ActorID    uint   `query:"actor_id"`
+	Action     string `query:"action"`
+	TargetType string `query:"target_type"`
+	TargetID   string `query:"target_id"`
+	DateFrom   string `query:"date_from"` // รูปแบบ 2006-01-02
+	DateTo     string `query:"date_to"`   // รวมวันที่ date_to ด้วย
+}
+
+// ตัวกรองสถิติ (รับจาก query string) group_by: faculty, branch (ค่าเริ่มต้น) หรือ year
+type StatsFilter struct {
+	SchoolYear uint   `query:"school_year"`
+	FacultyID  uint   `query:"faculty_id"`
+	BranchID   uint   `query:"branch_id"`
+	Year       uint   `query:"year"`
+	GroupBy    string `query:"group_by"`
+	// คณะที่ผู้ใช้มีสิทธิ์ดู (ว่าง = ทุกคณะ) กำหนดโดย usecase
+	FacultyIDs []uint `query:"-"`
+}
+
+// ผลการตรวจผู้เข้าร่วมกิจกรรมหนึ่งคน state: approved, rejected หรือ resubmission_requested
+type ReviewItem struct {
+	UserID  uint   `json:"user_id"`
+	State   string `json:"state"`
+	Comment string `json:"comment"`
+}
+
+type BatchReviewRequest struct {
+	Items []ReviewItem `json:"items"`
 }
 
 type OutsideRequest struct {
@@ -43,3 +96,23 @@ type OutsideRequest struct {
 	WorkingHour uint   `json:"working_hour"`
 	Intendant   string `json:"intendent"`
 }
+
+type RuleRequest struct {
+	FacultyID          *uint    `json:"faculty_id"`
+	BranchID           *uint    `json:"branch_id"`
+	SchoolYear         *uint    `json:"school_year"`
+	MinInsideHour      uint     `json:"min_inside_hour"`
+	MinTotalHour       uint     `json:"min_total_hour"`
+	MaxOutsideHour     *uint    `json:"max_outside_hour"`
+	RequiredCategories []string `json:"required_categories"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
@@ -1,9 +1,18 @@
 package response
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// token ที่ออกให้หลังเข้าสู่ระบบหรือ refresh ใช้ตั้งค่า cookie ใน controller
+type AuthTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
 type StudentResponse struct {
 	UserID      uint   `json:"user_id"`
 	TitleName   string `json:"title_name"`
@@ -50,6 +59,7 @@ type EventResponse struct {
 	FreeSpace      uint   `json:"free_space"`
 	Location       string `json:"location"`
 	Detail         string `json:"detail"`
+	Category       string `json:"category"`
 	Status         bool   `json:"status"`
 	BranchIDs      []uint `json:"branches"`
 	Years          []uint `json:"years"`
@@ -65,20 +75,92 @@ type EventResponse struct {
 	} `json:"creator"`
 }
 
+// รายการกิจกรรมแบบแบ่งหน้า
+type EventPage struct {
+	Data       []EventResponse `json:"data"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	Total      int64           `json:"total"`
+	TotalPages int             `json:"total_pages"`
+}
+
+type AuditLogResponse struct {
+	AuditID    uint            `json:"audit_id"`
+	ActorID    uint            `json:"actor_id"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditPage struct {
+	Data       []AuditLogResponse `json:"data"`
+	Page       int                `json:"page"`
+	Limit      int                `json:"limit"`
+	Total      int64              `json:"total"`
+	TotalPages int                `json:"total_pages"`
+}
+
+// กิจกรรมที่นักศึกษามีสิทธิ์เข้าร่วม พร้อมสถานะว่าเข้าร่วมแล้วหรือยัง
+type EligibleEventResponse struct {
+	EventResponse
+	Joined bool `json:"joined"`
+}
+
+type EligibleEventPage struct {
+	Data       []EligibleEventResponse `json:"data"`
+	Page       int                     `json:"page"`
+	Limit      int                     `json:"limit"`
+	Total      int64                   `json:"total"`
+	TotalPages int                     `json:"total_pages"`
+}
+
 type MyChecklist struct {
-	EventID   uint   `json:"event_id"`
-	UserID    uint   `json:"user_id"`
-	TitleName string `json:"title_name"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Code      string `json:"code"`
-	Certifier uint   `json:"certifier"`
-	Status    bool   `json:"status"`
-	Comment   string `json:"comment"`
-	File      string `json:"file"`
+	EventID     uint   `json:"event_id"`
+	UserID      uint   `json:"user_id"`
+	TitleName   string `json:"title_name"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Code        string `json:"code"`
+	BranchName  string `json:"branch_name"`
+	FacultyName string `json:"faculty_name"`
+	Certifier   uint   `json:"certifier"`
+	State       string `json:"state"`
+	Comment     string `json:"comment"`
+	File        string `json:"file"`
+	FileURL     string `json:"file_url"` // ลิงก์ดาวน์โหลดหลักฐานแบบมีอายุ
+	// ลิงก์ภาพย่อเมื่อส่งหลักฐานเป็นรูปถ่าย
+	ThumbnailURL string `json:"thumbnail_url"`
+	Attended     bool   `json:"attended"`
+	// เวลาที่เปลี่ยนสถานะล่าสุด
+	StateChangedAt *time.Time `json:"state_changed_at"`
+	// เวลาที่เช็คชื่อ ว่างถ้ายังไม่ได้เช็คชื่อ
+	AttendedAt string `json:"attended_at"`
 }
 
 type OutsideResponse struct {
+	EventID      uint            `json:"event_id"`
+	EventName    string          `json:"event_name"`
+	Location     string          `json:"location"`
+	StartDate    time.Time       `json:"start_date"`
+	SchoolYear   uint            `json:"school_year"`
+	WorkingHour  uint            `json:"working_hour"`
+	Intendant    string          `json:"intendent"`
+	Student      StudentResponse `json:"student"`
+	File         string          `json:"file"`
+	FileURL      string          `json:"file_url"`
+	ThumbnailURL string          `json:"thumbnail_url"`
+	State        string          `json:"state"`
+	Comment      string          `json:"comment"`
+}
+
+// ผลการตรวจสอบแบบฟอร์มกิจกรรมภายนอกจากเลขเอกสาร
+type FormVerification struct {
+	Serial      string          `json:"serial"`
+	GeneratedAt time.Time       `json:"generated_at"`
 	EventID     uint            `json:"event_id"`
 	EventName   string          `json:"event_name"`
 	Location    string          `json:"location"`
@@ -99,6 +181,11 @@ type MyOutside struct {
 	SchoolYear  uint   `json:"school_year"`
 	Intendant   string `json:"intendent"`
 	File        string `json:"file"`
+	FileURL     string `json:"file_url"`
+	State       string `json:"state"`
+	Comment     string `json:"comment"`
+	// เวลาที่เปลี่ยนสถานะล่าสุด
+	StateChangedAt *time.Time `json:"state_changed_at"`
 }
 
 type MyInside struct {
@@ -109,9 +196,21 @@ type MyInside struct {
 	StartTime   string `json:"start_time"`
 	WorkingHour uint   `json:"working_hour"`
 	SchoolYear  uint   `json:"school_year"`
-	Status      bool   `json:"status"`
+	State       string `json:"state"`
 	Comment     string `json:"comment"`
 	File        string `json:"file"`
+	FileURL     string `json:"file_url"`
+	// เวลาที่เปลี่ยนสถานะล่าสุด
+	StateChangedAt *time.Time `json:"state_changed_at"`
+}
+
+type WaitlistResponse struct {
+	EventID   uint   `json:"event_id"`
+	EventName string `json:"event_name"`
+	StartDate string `json:"start_date"`
+	StartTime string `json:"start_time"`
+	Position  uint   `json:"position"`
+	Total     uint   `json:"total"`
 }
 
 // response.StudentYear struct
@@ -129,10 +228,163 @@ type StudentYear struct {
 	Year        uint   `json:"year"`
 }
 
+// ข้อมูลใบรายงานผลการเข้าร่วมกิจกรรมของนักศึกษาหนึ่งปีการศึกษา
+type Transcript struct {
+	Student     StudentResponse     `json:"student"`
+	SchoolYear  uint                `json:"school_year"`
+	Inside      []TranscriptInside  `json:"inside"`
+	Outside     []TranscriptOutside `json:"outside"`
+	InsideHour  uint                `json:"inside_hour"`  // เฉพาะกิจกรรมที่อนุมัติแล้ว
+	OutsideHour uint                `json:"outside_hour"` // เฉพาะกิจกรรมที่อนุมัติแล้ว
+	TotalHour   uint                `json:"total_hour"`
+	Done        *TranscriptDone     `json:"done"` // nil = ยังไม่ส่งตรวจ
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+type TranscriptInside struct {
+	EventName   string    `json:"event_name"`
+	StartDate   time.Time `json:"start_date"`
+	Location    string    `json:"location"`
+	WorkingHour uint      `json:"working_hour"`
+	Certifier   string    `json:"certifier"`
+	State       string    `json:"state"`
+	Comment     string    `json:"comment"`
+}
+
+type TranscriptOutside struct {
+	EventName   string    `json:"event_name"`
+	StartDate   time.Time `json:"start_date"`
+	Location    string    `json:"location"`
+	WorkingHour uint      `json:"working_hour"`
+	Intendant   string    `json:"intendent"`
+	State       string    `json:"state"`
+}
+
+type TranscriptDone struct {
+	Certifier string `json:"certifier"`
+	State     string `json:"state"`
+	Comment   string `json:"comment"`
+}
+
 type DoneResponse struct {
 	User      uint   `json:"user_id"`
 	Certifier uint   `json:"certifier"`
 	Year      uint   `json:"year"`
-	Status    bool   `json:"status"`
+	State     string `json:"state"`
 	Comment   string `json:"comment"`
+	// เวลาที่เปลี่ยนสถานะล่าสุด
+	StateChangedAt *time.Time `json:"state_changed_at"`
+}
+
+type RuleResponse struct {
+	RuleID             uint     `json:"rule_id"`
+	FacultyID          *uint    `json:"faculty_id"`
+	BranchID           *uint    `json:"branch_id"`
+	SchoolYear         *uint    `json:"school_year"`
+	MinInsideHour      uint     `json:"min_inside_hour"`
+	MinTotalHour       uint     `json:"min_total_hour"`
+	MaxOutsideHour     *uint    `json:"max_outside_hour"`
+	RequiredCategories []string `json:"required_categories"`
+}
+
+type RuleCriterion struct {
+	Name     string `json:"name"`
+	Required uint   `json:"required"`
+	Actual   uint   `json:"actual"`
+	Met      bool   `json:"met"`
+}
+
+// ผลการตรวจเกณฑ์ชั่วโมงกิจกรรมของนักศึกษาในปีการศึกษานั้น
+type RuleEvaluation struct {
+	RuleID             uint            `json:"rule_id"`
+	SchoolYear         uint            `json:"school_year"`
+	InsideHour         uint            `json:"inside_hour"`
+	OutsideHour        uint            `json:"outside_hour"`
+	CountedOutsideHour uint            `json:"counted_outside_hour"`
+	TotalHour          uint            `json:"total_hour"`
+	Criteria           []RuleCriterion `json:"criteria"`
+	MissingCategories  []string        `json:"missing_categories"`
+	Passed             bool            `json:"passed"`
+}
+
+// ตารางสำหรับส่งออกเป็น CSV/XLSX
+type ExportTable struct {
+	Name   string // ชื่อไฟล์ (ไม่รวมนามสกุล) และชื่อ sheet
+	Header []string
+	Rows   [][]string
+}
+
+// สถิติของกลุ่ม (คณะ / สาขา / ชั้นปี) ในปีการศึกษา
+type StatsGroup struct {
+	FacultyID             uint    `json:"faculty_id"`
+	FacultyName           string  `json:"faculty_name"`
+	BranchID              uint    `json:"branch_id,omitempty"`
+	BranchName            string  `json:"branch_name,omitempty"`
+	Year                  uint    `json:"year,omitempty"`
+	Students              int64   `json:"students"`
+	AvgInsideHour         float64 `json:"avg_inside_hour"`
+	AvgOutsideHour        float64 `json:"avg_outside_hour"`
+	MetThreshold          int64   `json:"met_threshold"`
+	ParticipatingStudents int64   `json:"participating_students"`
+	ParticipationRate     float64 `json:"participation_rate"` // ร้อยละของนักศึกษาที่เข้าร่วมกิจกรรมภายในอย่างน้อย 1 กิจกรรม
+	DonePending           int64   `json:"done_pending"`
+	DoneApproved          int64   `json:"done_approved"`
+	DoneRejected          int64   `json:"done_rejected"`
+	DoneResubmission      int64   `json:"done_resubmission_requested"`
+}
+
+type Stats struct {
+	SchoolYear uint         `json:"school_year"`
+	GroupBy    string       `json:"group_by"`
+	Groups     []StatsGroup `json:"groups"`
+}
+
+// จำนวนผลส่งตรวจแยกตามสถานะของกลุ่ม
+type DoneStats struct {
+	FacultyID    uint
+	BranchID     uint
+	Year         uint
+	DonePending      int64
+	DoneApproved     int64
+	DoneRejected     int64
+	DoneResubmission int64
+}
+
+// ชั่วโมงกิจกรรมของนักศึกษาแต่ละคน ใช้ตรวจเกณฑ์
+type StudentHour struct {
+	UserID      uint
+	FacultyID   uint
+	BranchID    uint
+	Year        uint
+	InsideHour  uint
+	OutsideHour uint
+}
+
+type StudentCategory struct {
+	UserID   uint
+	Category string
+}
+
+// ผลการนำเข้านักศึกษาจาก CSV
+type ImportResult struct {
+	DryRun  bool        `json:"dry_run"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+type ImportRow struct {
+	Line     int      `json:"line"` // บรรทัดในไฟล์ (หัวตารางคือบรรทัด 1)
+	Email    string   `json:"email"`
+	Code     string   `json:"code"`
+	Password string   `json:"password,omitempty"` // รหัสผ่านเริ่มต้น (เฉพาะที่สร้างสำเร็จ)
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// ผลการตรวจรายคนของการตรวจแบบกลุ่ม
+type ReviewOutcome struct {
+	UserID  uint   `json:"user_id"`
+	Updated bool   `json:"updated"`
+	Error   string `json:"error,omitempty"`
 }
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
+	"log"
+	"time"
+)
+
+// บันทึก audit log ของการเปลี่ยนแปลงข้อมูล ใช้ร่วมกันทุก usecase
+// zero value (ไม่มี repo) จะไม่บันทึกอะไร
+type auditTrail struct {
+	repo repository.AuditRepository
+}
+
+// สร้าง audit log ให้ repository บันทึกใน transaction เดียวกับการเปลี่ยนแปลง (nil เมื่อไม่มี repo)
+// before/after เป็นค่าใดก็ได้ที่แปลงเป็น JSON ได้ (nil = ไม่มีค่า)
+func (a auditTrail) entry(claims map[string]interface{}, action string, targetType string, targetID interface{}, before interface{}, after interface{}) *entity.AuditLog {
+	if a.repo == nil {
+		return nil
+	}
+	entry := &entity.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+	}
+	if userID, ok := claims["user_id"].(float64); ok {
+		entry.ActorID = uint(userID)
+	}
+	if role, ok := claims["role"].(string); ok {
+		entry.ActorRole = role
+	}
+	return entry
+}
+
+// บันทึกหลังการเปลี่ยนแปลง commit แล้ว ใช้กับ repository ที่ไม่มี transaction
+// เป็น best effort: บันทึกไม่สำเร็จจะไม่ย้อนการเปลี่ยนแปลง แต่เขียน log ระดับ ERROR ไว้ให้ตามเก็บ
+func (a auditTrail) record(claims map[string]interface{}, action string, targetType string, targetID interface{}, before interface{}, after interface{}) {
+	entry := a.entry(claims, action, targetType, targetID, before, after)
+	if entry == nil {
+		return
+	}
+	if err := a.repo.CreateAuditLog(entry); err != nil {
+		log.Printf("ERROR audit: failed to record %s on %s %s: %v (before=%s after=%s)",
+			action, targetType, entry.TargetID, err, entry.Before, entry.After)
+	}
+}
+
+func auditJSON(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+type AuditUsecase interface {
+	GetAuditLogs(filter request.AuditFilter) (*response.AuditPage, error)
+	ExportAuditLogs(filter request.AuditFilter) (*response.ExportTable, error)
+}
+
+type auditUsecase struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditUsecase(auditRepo repository.AuditRepository) AuditUsecase {
+	return &auditUsecase{auditRepo: auditRepo}
+}
+
+const (
+	defaultAuditPageLimit = 50
+	maxAuditPageLimit     = 200
+	// จำนวนแถวสูงสุดที่ส่งออกได้ในครั้งเดียว
+	maxAuditExportRows = 10000
+)
+
+func normalizeAuditFilter(filter *request.AuditFilter) error {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultAuditPageLimit
+	}
+	if filter.Limit > maxAuditPageLimit {
+		filter.Limit = maxAuditPageLimit
+	}
+
+	var from, to time.Time
+	var err error
+	if filter.DateFrom != "" {
+		if from, err = time.Parse("2006-01-02", filter.DateFrom); err != nil {
+			return fmt.Errorf("invalid date_from format, expected YYYY-MM-DD")
+		}
+	}
+	if filter.DateTo != "" {
+		if to, err = time.Parse("2006-01-02", filter.DateTo); err != nil {
+			return fmt.Errorf("invalid date_to format, expected YYYY-MM-DD")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return fmt.Errorf("date_to must not be before date_from")
+	}
+	return nil
+}
+
+func (u *auditUsecase) GetAuditLogs(filter request.AuditFilter) (*response.AuditPage, error) {
+	if err := normalizeAuditFilter(&filter); err != nil {
+		return nil, err
+	}
+	logs, total, err := u.auditRepo.FindAuditLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &response.AuditPage{
+		Data:       make([]response.AuditLogResponse, 0, len(logs)),
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+	}
+	for _, entry := range logs {
+		page.Data = append(page.Data, response.AuditLogResponse{
+			AuditID:    entry.AuditID,
+			ActorID:    entry.ActorID,
+			ActorRole:  entry.ActorRole,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			Before:     rawAuditJSON(entry.Before),
+			After:      rawAuditJSON(entry.After),
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+func rawAuditJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
+
+// ส่งออก audit log ตามตัวกรองทั้งหมด (ไม่แบ่งหน้า)
+func (u *auditUsecase) ExportAuditLogs(filter request.AuditFilter) (*response.ExportTable, error) {
+	if err := normalizeAuditFilter(&filter); err != nil {
+		return nil, err
+	}
+	filter.Page = 1
+	filter.Limit = maxAuditExportRows
+	logs, total, err := u.auditRepo.FindAuditLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+	if total > maxAuditExportRows {
+		return nil, fmt.Errorf("too many audit logs (%d), narrow the filter to at most %d rows", total, maxAuditExportRows)
+	}
+
+	table := response.ExportTable{
+		Name:   "audit-log",
+		Header: []string{"เวลา", "ผู้ดำเนินการ", "บทบาท", "การกระทำ", "ประเภทข้อมูล", "รหัสข้อมูล", "ก่อน", "หลัง"},
+		Rows:   make([][]string, 0, len(logs)),
+	}
+	for _, entry := range logs {
+		table.Rows = append(table.Rows, []string{
+			entry.CreatedAt.Format("2006-01-02 15:04:05"),
+			fmt.Sprint(entry.ActorID),
+			entry.ActorRole,
+			entry.Action,
+			entry.TargetType,
+			entry.TargetID,
+			entry.Before,
+			entry.After,
+		})
+	}
+	return &table, nil
+}
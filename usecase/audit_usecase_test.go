@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+)
+
+// TestAuditTrail tests that mutating calls are recorded with before/after values
+func TestAuditTrail(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.AuditLog{}))
+	auditRepo := repository.NewAuditRepository(db)
+	u := NewFacultyUsecase(repository.NewFacultyRepositiry(db), auditRepo)
+	audits := NewAuditUsecase(auditRepo)
+	admin := map[string]interface{}{"user_id": float64(7), "role": "admin"}
+
+	t.Run("Update records actor and before/after", func(t *testing.T) {
+		err := u.UpdateBranchByID(&entity.Branch{BranchID: 2, BranchCode: "MA", BranchName: "Mathematics", FacultyId: 1}, admin)
+		assert.NoError(t, err)
+
+		page, err := audits.GetAuditLogs(request.AuditFilter{Action: "branch.update"})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Data, 1) {
+			entry := page.Data[0]
+			assert.Equal(t, uint(7), entry.ActorID)
+			assert.Equal(t, "admin", entry.ActorRole)
+			assert.Equal(t, "branch", entry.TargetType)
+			assert.Equal(t, "2", entry.TargetID)
+
+			var before, after map[string]interface{}
+			assert.NoError(t, json.Unmarshal(entry.Before, &before))
+			assert.NoError(t, json.Unmarshal(entry.After, &after))
+			assert.Equal(t, "Math", before["branch_name"])
+			assert.Equal(t, "Mathematics", after["branch_name"])
+		}
+	})
+
+	t.Run("Failed calls are not recorded", func(t *testing.T) {
+		assert.Error(t, u.DeleteBranchByID(99, admin))
+		page, err := audits.GetAuditLogs(request.AuditFilter{Action: "branch.delete"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), page.Total)
+	})
+
+	t.Run("Review is recorded in the same transaction", func(t *testing.T) {
+		assert.NoError(t, db.AutoMigrate(&entity.ReviewTransition{}))
+		events := &eventUsecase{eventRepo: repository.NewEventRepository(db), audit: auditTrail{repo: auditRepo}}
+
+		// audit log บันทึกไม่ได้ ผลการตรวจต้องถูกยกเลิกด้วย
+		assert.NoError(t, db.Migrator().RenameTable("audit_logs", "audit_logs_old"))
+		assert.Error(t, events.UpdateEventStatusAndComment(1, 102, admin, "approved", ""))
+		assert.NoError(t, db.Migrator().RenameTable("audit_logs_old", "audit_logs"))
+		var inside entity.EventInside
+		assert.NoError(t, db.First(&inside, "event_id = ? AND user = ?", 1, 102).Error)
+		assert.Equal(t, entity.StateEvidenceSubmitted, inside.State)
+
+		assert.NoError(t, events.UpdateEventStatusAndComment(1, 102, admin, "approved", ""))
+		page, err := audits.GetAuditLogs(request.AuditFilter{Action: "participant.review"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), page.Total)
+	})
+
+	t.Run("Invalid filter is rejected", func(t *testing.T) {
+		_, err := audits.GetAuditLogs(request.AuditFilter{DateFrom: "2025-02-01", DateTo: "2025-01-01"})
+		assert.Error(t, err)
+	})
+}
@@ -1,17 +1,18 @@
 package usecase
 
 import (
-	"database/sql"
-	"errors"
 	"fmt"
 	"go-clean-arch/pkg/utility"
 	"go-clean-arch/pkg/utility/filesystem"
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/structure/request"
 	"go-clean-arch/structure/response"
+	"io"
 	"mime/multipart"
-	"os"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Outside
@@ -27,7 +28,7 @@ func (u *eventUsecase) CreateEventOutside(req request.OutsideRequest,claims map[
 	}
 	userID := uint(userIDFloat)
 
-	outside := entity.EventOutside{
+	outside := &entity.EventOutside{
 		User: userID,
 		EventName: req.EventName,
 		SchoolYear: req.SchoolYear,
@@ -35,8 +36,33 @@ func (u *eventUsecase) CreateEventOutside(req request.OutsideRequest,claims map[
 		Intendant: req.Intendant,
 		WorkingHour: req.WorkingHour,
 		Location: req.Location,
+		State: entity.StateJoined,
+	}
+	if err := u.eventRepo.CreateEventOutside(outside); err != nil {
+		return err
+	}
+	u.audit.record(claims, "outside.create", "outside", outside.EventID, nil, outsideAudit(outside))
+	return nil
+}
+
+// ค่าของกิจกรรมภายนอกที่เก็บใน audit log
+func outsideAudit(outside *entity.EventOutside) interface{} {
+	if outside == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"user_id":      outside.User,
+		"event_name":   outside.EventName,
+		"school_year":  outside.SchoolYear,
+		"start_date":   outside.StartDate,
+		"intendant":    outside.Intendant,
+		"working_hour": outside.WorkingHour,
+		"location":     outside.Location,
+		"file":         outside.File,
+		"certifier":    outside.Certifier,
+		"state":        outside.State,
+		"comment":      outside.Comment,
 	}
-	return u.eventRepo.CreateEventOutside(outside)
 }
 
 func (u *eventUsecase) GetEventOutsideByID(eventID uint) (*response.OutsideResponse, error) {
@@ -44,7 +70,12 @@ func (u *eventUsecase) GetEventOutsideByID(eventID uint) (*response.OutsideRespo
 	if err != nil {
 		return nil, err
 	}
-	outsideRes := response.OutsideResponse{
+	outsideRes := mapEventOutside(*outside)
+	return &outsideRes, nil
+}
+
+func mapEventOutside(outside entity.EventOutside) response.OutsideResponse {
+	return response.OutsideResponse{
 		EventID:     outside.EventID,
 		EventName:   outside.EventName,
 		Location:    outside.Location,
@@ -64,24 +95,76 @@ func (u *eventUsecase) GetEventOutsideByID(eventID uint) (*response.OutsideRespo
 			FacultyID:   outside.Student.Branch.Faculty.FacultyID,
 			FacultyName: outside.Student.Branch.Faculty.FacultyName,
 		},
+		File:    outside.File,
+		State:   string(outside.State),
+		Comment: outside.Comment,
 	}
-	return &outsideRes, nil
 }
 
-func (u *eventUsecase) DeleteEventOutsideByID(eventID uint) error{
+func (u *eventUsecase) DeleteEventOutsideByID(eventID uint, claims map[string]interface{}) error{
+	before, _ := u.eventRepo.GetEventOutsideByID(eventID)
 	if err := u.eventRepo.DeleteEventOutsideByID(eventID); err != nil {
 		return fmt.Errorf("failed to deleted eventoutside: %w", err)
 	}
+	if before != nil {
+		u.removeEvidence(before.File)
+	}
+	u.audit.record(claims, "outside.delete", "outside", eventID, outsideAudit(before), nil)
+	return nil
+}
+
+
+// สร้างแบบฟอร์มได้เฉพาะเจ้าของกิจกรรม (/student/download) ผู้ดูแลคณะที่ได้รับมอบหมาย หรือแอดมิน (/teacher/download)
+// เพราะแบบฟอร์มมีเลขเอกสารที่ลงนามไว้สำหรับตรวจสอบ
+func (u *eventUsecase) authorizeOutsideForm(eventID uint, claims map[string]interface{}) error {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	callerID := uint(userIDFloat)
+	role, _ := claims["role"].(string)
+
+	outside, err := u.eventRepo.GetEventOutsideByID(eventID)
+	if err != nil {
+		return fmt.Errorf("data not found: %v", err)
+	}
+	if outside.User != callerID && (outside.Certifier == 0 || outside.Certifier != callerID) &&
+		role != "admin" && role != "superadmin" {
+		return fmt.Errorf("you do not have permission to create this form")
+	}
 	return nil
 }
 
+func (u *eventUsecase) CreateFile(eventID uint, claims map[string]interface{}) ([]byte, string, error) {
+	if err := u.authorizeOutsideForm(eventID, claims); err != nil {
+		return nil, "", err
+	}
 
-func (u *eventUsecase) CreateFile(eventID uint) ([]byte, string, error) {
 	data, err := u.GetEventOutsideByID(eventID)
 	if err != nil {
 		return nil, "", fmt.Errorf("data not found: %v", err)
 	}
-	pdfBytes, fileName, err := filesystem.CreatePDF(*data)
+	tpl, err := u.outsideFormTemplate(data.SchoolYear)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// บันทึกเลขเอกสารก่อนพิมพ์ เพื่อให้ตรวจสอบย้อนกลับไปยัง EventOutside ได้
+	form := entity.GeneratedForm{
+		Serial:      uuid.New().String(),
+		OutsideID:   data.EventID,
+		UserID:      data.Student.UserID,
+		GeneratedAt: time.Now(),
+	}
+	if err := u.eventRepo.CreateGeneratedForm(&form); err != nil {
+		return nil, "", fmt.Errorf("failed to save form serial: %w", err)
+	}
+	stamp := filesystem.FormStamp{
+		Serial:    form.Serial,
+		VerifyURL: fmt.Sprintf("%s/%s?sig=%s", strings.TrimRight(u.verifyURL, "/"), form.Serial, u.jwt.SignSerial(form.Serial)),
+	}
+
+	pdfBytes, fileName, err := filesystem.CreatePDF(*data, stamp, tpl)
 	if err != nil {
 		return nil, " ", fmt.Errorf("error creating PDF: %v", err)
 	}
@@ -89,8 +172,57 @@ func (u *eventUsecase) CreateFile(eventID uint) ([]byte, string, error) {
 
 }
 
+// ตรวจสอบเอกสารจากเลขเอกสารและลายเซ็นใน QR code
+func (u *eventUsecase) VerifyForm(serial string, signature string) (*response.FormVerification, error) {
+	if !u.jwt.VerifySerial(serial, signature) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	form, err := u.eventRepo.GetGeneratedForm(serial)
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.GetEventOutsideByID(form.OutsideID)
+	if err != nil || data.Student.UserID != form.UserID {
+		return nil, fmt.Errorf("activity record of form %s not found", serial)
+	}
+	// endpoint นี้เปิดสาธารณะ ไม่เปิดเผยเบอร์โทรศัพท์
+	data.Student.Phone = ""
+
+	return &response.FormVerification{
+		Serial:      form.Serial,
+		GeneratedAt: form.GeneratedAt,
+		EventID:     data.EventID,
+		EventName:   data.EventName,
+		Location:    data.Location,
+		StartDate:   data.StartDate,
+		SchoolYear:  data.SchoolYear,
+		WorkingHour: data.WorkingHour,
+		Intendant:   data.Intendant,
+		Student:     data.Student,
+	}, nil
+}
+
+// แม่แบบแบบฟอร์มกิจกรรมภายนอกของปีการศึกษา (nil = ใช้แม่แบบเริ่มต้น)
+func (u *eventUsecase) outsideFormTemplate(schoolYear uint) (*filesystem.PdfTemplate, error) {
+	if u.templateRepo == nil {
+		return nil, nil
+	}
+	stored, err := u.templateRepo.FindTemplate(filesystem.OutsideFormTemplate, schoolYear)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template: %w", err)
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	tpl, err := filesystem.ParseTemplate([]byte(stored.Content))
+	if err != nil {
+		return nil, fmt.Errorf("template %d is invalid: %v", stored.TemplateID, err)
+	}
+	return tpl, nil
+}
+
 
-func (u *eventUsecase) UploadFileOutside(eventID uint, claims map[string]interface{}, file *multipart.FileHeader) error {
+func (u *eventUsecase) UploadFileOutside(eventID uint, claims map[string]interface{}, files []*multipart.FileHeader) error {
 	// ตรวจสอบว่า eventID มีอยู่ใน EventOutside หรือไม่
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
@@ -98,60 +230,126 @@ func (u *eventUsecase) UploadFileOutside(eventID uint, claims map[string]interfa
 	}
 	userID := uint(userIDFloat)
 
-	exists, err := u.eventRepo.EventOutsideExists(eventID, userID)
-	if err != nil {
-		return fmt.Errorf("failed to check event existence: %w", err)
-	}
-	if !exists {
+	outside, err := u.eventRepo.GetEventOutsideByID(eventID)
+	if err != nil || outside.User != userID {
 		return fmt.Errorf("event outside does not exist for this user")
 	}
 
-	// ตรวจสอบประเภทไฟล์ให้เป็น PDF
-	if file.Header.Get("Content-Type") != "application/pdf" ||
-		!strings.HasSuffix(file.Filename, ".pdf") {
-		return fmt.Errorf("only PDF files are allowed")
+	// ตรวจสอบชนิดและขนาดไฟล์ (PDF หนึ่งไฟล์ หรือรูปถ่ายหลายรูป)
+	if err := checkEvidenceFiles(files); err != nil {
+		return err
 	}
 
-	// จำกัดขนาดไฟล์ที่ 10MB
-	const maxFileSize = 10 * 1024 * 1024
-	if file.Size > maxFileSize {
-		return fmt.Errorf("file size exceeds the 10MB limit")
+	// ส่งแบบฟอร์มได้ก่อนตรวจเสร็จ หรือเมื่อผู้ดูแลคณะขอให้ส่งใหม่
+	transition, err := newTransition(entity.ReviewKindOutside, eventID, userID, outside.State, entity.StateEvidenceSubmitted, userID, "")
+	if err != nil {
+		return err
 	}
-
-	// ค้นหาไฟล์ปัจจุบันในฐานข้อมูล
-	currentFilePath, err := u.eventRepo.GetFilePathOutside(eventID, userID)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("failed to fetch current file path: %w", err)
+	superUserID, err := u.userRepo.GetSuperUserForStudent(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get super user: %v", err)
 	}
-
-	// ถ้ามีไฟล์เดิมให้ลบ
-	if currentFilePath != "" {
-		if removeErr := os.Remove(currentFilePath); removeErr != nil {
-			return fmt.Errorf("failed to remove old file: %v", removeErr)
-		}
+	if superUserID == nil {
+		return fmt.Errorf("faculty has no super user assigned")
 	}
 
 	// บันทึกไฟล์ใหม่
-	path, err := filesystem.SaveFile(file, userID)
+	key, thumbnail, err := u.saveEvidence(files, userID)
 	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
+		return err
 	}
 
 	// อัปเดตฐานข้อมูล
-	err = u.eventRepo.UploadFileOutside(eventID, userID, path)
+	audit := u.audit.entry(claims, "outside.upload", "outside", eventID,
+		map[string]interface{}{"state": outside.State, "file": outside.File, "certifier": outside.Certifier},
+		map[string]interface{}{"state": transition.ToState, "file": key, "certifier": *superUserID})
+	err = u.eventRepo.UploadFileOutside(transition, key, thumbnail, *superUserID, audit)
 	if err != nil {
-		os.Remove(path) // ลบไฟล์ใหม่หากอัปเดต DB ไม่สำเร็จ
+		u.removeEvidence(key, thumbnail) // ลบไฟล์ใหม่หากอัปเดต DB ไม่สำเร็จ
 		return fmt.Errorf("failed to update database: %w", err)
 	}
+	// ลบไฟล์เดิมหลังบันทึกไฟล์ใหม่สำเร็จแล้ว
+	u.removeEvidence(outside.File, outside.Thumbnail)
 
 	return nil
 }
 
 
-func (u *eventUsecase) GetFileOutside(eventID uint ,userID uint)(string,error){
-	filePath, err := u.eventRepo.GetFilePathOutside(eventID, userID)
+// แบบฟอร์มกิจกรรมภายนอกที่ลงนามแล้ว (ตรวจสิทธิ์ด้วย authorizeEvidence)
+func (u *eventUsecase) outsideEvidence(eventID uint, userID uint, claims map[string]interface{}) (string, error) {
+	outside, err := u.eventRepo.GetEventOutsideByID(eventID)
+	if err != nil || outside.User != userID {
+		return "", fmt.Errorf("event outside does not exist for this user")
+	}
+	if err := u.authorizeEvidence(claims, userID, outside.Certifier); err != nil {
+		return "", err
+	}
+	if outside.File == "" {
+		return "", fmt.Errorf("file not found")
+	}
+	return outside.File, nil
+}
+
+func (u *eventUsecase) GetFileOutside(eventID uint ,userID uint, claims map[string]interface{})(io.ReadCloser,error){
+	key, err := u.outsideEvidence(eventID, userID, claims)
+	if err != nil {
+		return nil, err
+	}
+	return u.openEvidence(key)
+}
+
+func (u *eventUsecase) FileOutsideURL(eventID uint, userID uint, claims map[string]interface{}) (string, error) {
+	key, err := u.outsideEvidence(eventID, userID, claims)
 	if err != nil {
 		return "", err
 	}
-	return filePath, nil
-}
\ No newline at end of file
+	return u.storage.SignedURL(key, EvidenceURLExpiry)
+}
+
+// กิจกรรมภายนอกที่รอผู้ดูแลคณะ (ผู้เรียก) ตรวจ
+func (u *eventUsecase) OutsideReviewQueue(claims map[string]interface{}) ([]response.OutsideResponse, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	outsides, err := u.eventRepo.PendingOutsides(uint(userIDFloat))
+	if err != nil {
+		return nil, err
+	}
+	queue := make([]response.OutsideResponse, 0, len(outsides))
+	for _, outside := range outsides {
+		mapped := mapEventOutside(outside)
+		mapped.FileURL = u.evidenceURL(outside.File)
+		mapped.ThumbnailURL = u.evidenceURL(outside.Thumbnail)
+		queue = append(queue, mapped)
+	}
+	return queue, nil
+}
+
+// ตรวจกิจกรรมภายนอก เฉพาะผู้ดูแลคณะที่ได้รับมอบหมายเมื่อนักศึกษาส่งแบบฟอร์ม
+func (u *eventUsecase) ReviewOutside(eventID uint, claims map[string]interface{}, state string, comment string) error {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	certifierID := uint(userIDFloat)
+
+	decision, err := parseReviewDecision(state, comment)
+	if err != nil {
+		return err
+	}
+	outside, err := u.eventRepo.GetEventOutsideByID(eventID)
+	if err != nil {
+		return err
+	}
+	if outside.Certifier != certifierID {
+		return fmt.Errorf("you are not the certifier of this activity")
+	}
+	transition, err := newReview(entity.ReviewKindOutside, eventID, outside.User, outside.State, decision, certifierID, comment)
+	if err != nil {
+		return err
+	}
+	audit := u.audit.entry(claims, "outside.review", "outside", eventID,
+		reviewAudit(outside.State, outside.Comment), reviewAudit(decision, comment))
+	return u.eventRepo.UpdateOutsideState(transition, audit)
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var eventListDBSeq int64
+
+// สร้างฐานข้อมูล sqlite ในหน่วยความจำ พร้อมกิจกรรม n รายการ (กิจกรรมละ 3 ผู้เข้าร่วม)
+// และตัวนับจำนวน query ที่ส่งไปยังฐานข้อมูล
+func newEventListDB(tb testing.TB, n int) (*gorm.DB, *int64) {
+	tb.Helper()
+	dsn := fmt.Sprintf("file:eventlist%d?mode=memory&cache=shared", atomic.AddInt64(&eventListDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.Teacher{}, &entity.Event{}, &entity.EventBranch{}, &entity.EventYear{}, &entity.EventInside{}); err != nil {
+		tb.Fatalf("failed to migrate: %v", err)
+	}
+
+	teacher := entity.Teacher{UserID: 1, TitleName: "อ.", FirstName: "สมชาย", LastName: "ใจดี", Phone: "0800000000", Code: "T001"}
+	if err := db.Create(&teacher).Error; err != nil {
+		tb.Fatalf("failed to seed teacher: %v", err)
+	}
+	for i := 1; i <= n; i++ {
+		event := entity.Event{
+			EventName:      fmt.Sprintf("event %d", i),
+			Creator:        teacher.UserID,
+			StartDate:      time.Now().AddDate(0, 0, i),
+			SchoolYear:     2568,
+			WorkingHour:    3,
+			FreeSpace:      10,
+			Location:       "hall",
+			AllowAllBranch: true,
+			AllowAllYear:   true,
+		}
+		if err := db.Omit("Teacher").Create(&event).Error; err != nil {
+			tb.Fatalf("failed to seed event: %v", err)
+		}
+		for u := uint(100); u < 103; u++ {
+			inside := entity.EventInside{EventId: event.EventID, User: u}
+			if err := db.Omit("Event", "Student", "Teacher", "Certifier").Create(&inside).Error; err != nil {
+				tb.Fatalf("failed to seed participant: %v", err)
+			}
+		}
+	}
+
+	var queries int64
+	count := func(*gorm.DB) { atomic.AddInt64(&queries, 1) }
+	db.Callback().Query().After("gorm:query").Register("test:count_query", count)
+	db.Callback().Row().After("gorm:row").Register("test:count_row", count)
+	return db, &queries
+}
+
+func listAllEvents(tb testing.TB, db *gorm.DB) []response.EventResponse {
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db)}
+	page, err := u.GetAllEvent(request.EventFilter{Limit: maxEventPageLimit})
+	if err != nil {
+		tb.Fatalf("GetAllEvent failed: %v", err)
+	}
+	return page.Data
+}
+
+// TestGetAllEventQueryCount tests that listing events does not query once per event
+func TestGetAllEventQueryCount(t *testing.T) {
+	perSize := map[int]int64{}
+	for _, n := range []int{5, 50} {
+		db, queries := newEventListDB(t, n)
+		events := listAllEvents(t, db)
+		assert.Len(t, events, n)
+		for _, event := range events {
+			assert.Equal(t, uint(13), event.Limit)
+		}
+		perSize[n] = atomic.LoadInt64(queries)
+	}
+	assert.Equal(t, perSize[5], perSize[50])
+}
+
+func BenchmarkGetAllEvent(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			db, queries := newEventListDB(b, n)
+			atomic.StoreInt64(queries, 0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				listAllEvents(b, db)
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(queries))/float64(b.N), "queries/op")
+		})
+	}
+}
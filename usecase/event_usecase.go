@@ -1,73 +1,150 @@
 package usecase
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
+	"bytes"
 	"fmt"
+	"go-clean-arch/pkg/jwt"
+	"go-clean-arch/pkg/scanner"
+	"go-clean-arch/pkg/storage"
 	"go-clean-arch/pkg/utility"
 	"go-clean-arch/pkg/utility/filesystem"
 	"go-clean-arch/repository"
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/structure/request"
 	"go-clean-arch/structure/response"
+	"io"
+	"log"
 	"mime/multipart"
-	"os"
+	"path"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
 )
 
 type EventUsecase interface {
 	CreateEvent(req *request.EventRequest, claims map[string]interface{}) error
-	GetAllEvent() ([]response.EventResponse, error)
+	GetAllEvent(filter request.EventFilter) (*response.EventPage, error)
 	GetEventByID(id uint) (*response.EventResponse, error)
 	ToggleEventStatus(eventID uint, claims map[string]interface{}) (bool, error)
 	DeleteEventByID(eventID uint, claims map[string]interface{}) error
 	UpdateEventByID(eventID uint, claims map[string]interface{}, req request.EventRequest) error
 	MyEvent(claims map[string]interface{}) ([]response.EventResponse, error)
-	AllAllowedEvent() ([]response.EventResponse, error)
-	AllCurrentEvent() ([]response.EventResponse, error)
-	MyEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,*response.DoneResponse,error)
-	SendEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,error)
+	AllAllowedEvent(filter request.EventFilter) (*response.EventPage, error)
+	AllCurrentEvent(filter request.EventFilter) (*response.EventPage, error)
+	EligibleEvents(claims map[string]interface{}, filter request.EventFilter) (*response.EligibleEventPage, error)
+	MyEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,*response.DoneResponse,*response.RuleEvaluation,error)
+	SendEventThisYear(userID uint,year uint,claims map[string]interface{}) ([]response.MyInside,[]response.MyOutside,error)
 
 	JoinEvent(eventID uint, claims map[string]interface{}) error
 	UnJoinEvent(eventID uint, claims map[string]interface{}) error
-	UploadFile(eventID uint, claims map[string]interface{}, file *multipart.FileHeader) error
-	GetFile(eventID uint, userID uint) (string, error)
+	JoinWaitlist(eventID uint, claims map[string]interface{}) (uint, error)
+	LeaveWaitlist(eventID uint, claims map[string]interface{}) error
+	MyWaitlist(claims map[string]interface{}) ([]response.WaitlistResponse, error)
+	UploadFile(eventID uint, claims map[string]interface{}, files []*multipart.FileHeader) error
+	GetFile(eventID uint, userID uint, claims map[string]interface{}) (io.ReadCloser, error)
+	FileURL(eventID uint, userID uint, claims map[string]interface{}) (string, error)
 	MyChecklist(eventID uint, claims map[string]interface{}) ([]response.MyChecklist, error)
-	UpdateEventStatusAndComment(eventID uint, userID uint, status bool, comment string) error
+	ExportChecklist(eventID uint, claims map[string]interface{}) (*response.ExportTable, error)
+	ExportFacultyDones(facultyID uint, year uint, claims map[string]interface{}) (*response.ExportTable, error)
+	UpdateEventStatusAndComment(eventID uint, userID uint, claims map[string]interface{}, state string, comment string) error
+	ReviewParticipants(eventID uint, claims map[string]interface{}, req request.BatchReviewRequest) ([]response.ReviewOutcome, error)
+	CreateCheckinQR(eventID uint, claims map[string]interface{}, minutes uint) ([]byte, error)
+	CheckIn(token string, claims map[string]interface{}) error
 
 	CreateEventOutside(req request.OutsideRequest,claims map[string]interface{}) error
-	DeleteEventOutsideByID(eventID uint) error
+	DeleteEventOutsideByID(eventID uint, claims map[string]interface{}) error
 	GetEventOutsideByID(eventID uint) (*response.OutsideResponse, error)
-	CreateFile(eventID uint) ([]byte, string, error)
-	GetFileOutside(eventID uint ,userID uint)(string,error)
-	UploadFileOutside(eventID uint, claims map[string]interface{}, file *multipart.FileHeader) error 
+	CreateFile(eventID uint, claims map[string]interface{}) ([]byte, string, error)
+	VerifyForm(serial string, signature string) (*response.FormVerification, error)
+	CreateTranscript(userID uint, year uint, claims map[string]interface{}) ([]byte, string, error)
+	GetFileOutside(eventID uint ,userID uint, claims map[string]interface{})(io.ReadCloser,error)
+	FileOutsideURL(eventID uint, userID uint, claims map[string]interface{}) (string, error)
+	UploadFileOutside(eventID uint, claims map[string]interface{}, files []*multipart.FileHeader) error
+	OutsideReviewQueue(claims map[string]interface{}) ([]response.OutsideResponse, error)
+	ReviewOutside(eventID uint, claims map[string]interface{}, state string, comment string) error
 
 }
 
 type eventUsecase struct {
-	userRepo    repository.UserRepository
-	facultyRepo repository.FacultyBranchRepository
-	eventRepo   repository.EventRepository
+	userRepo     repository.UserRepository
+	facultyRepo  repository.FacultyBranchRepository
+	eventRepo    repository.EventRepository
+	ruleRepo     repository.RuleRepository
+	templateRepo repository.TemplateRepository
+	storage      storage.Storage
+	scanner      scanner.Scanner
+	pdfLimits    filesystem.PDFLimits
+	verifyURL    string
+	jwt          jwt.JWTService
+	audit        auditTrail
 }
 
-func NewEventUsecase(userRepo repository.UserRepository, facultyRepo repository.FacultyBranchRepository, eventRepo repository.EventRepository) EventUsecase {
+func NewEventUsecase(userRepo repository.UserRepository, facultyRepo repository.FacultyBranchRepository, eventRepo repository.EventRepository, ruleRepo repository.RuleRepository, templateRepo repository.TemplateRepository, auditRepo repository.AuditRepository, store storage.Storage, scan scanner.Scanner, pdfLimits filesystem.PDFLimits, verifyURL string, jwt jwt.JWTService) EventUsecase {
 	return &eventUsecase{
-		userRepo:    userRepo,
-		facultyRepo: facultyRepo,
-		eventRepo:   eventRepo,
+		userRepo:     userRepo,
+		facultyRepo:  facultyRepo,
+		eventRepo:    eventRepo,
+		ruleRepo:     ruleRepo,
+		templateRepo: templateRepo,
+		storage:      store,
+		scanner:      scan,
+		pdfLimits:    pdfLimits,
+		verifyURL:    verifyURL,
+		jwt:          jwt,
+		audit:        auditTrail{repo: auditRepo},
 	}
 }
 
-func mapEventResponse(event entity.Event, count uint) (*response.EventResponse, error) {
-	branches, err := utility.DecodeIDs(event.BranchIDs)
-	if err != nil {
-		return &response.EventResponse{}, err
+// ค่าของกิจกรรมที่เก็บใน audit log (ไม่รวมข้อมูลผู้สร้างที่ preload มา)
+func eventAudit(event *entity.Event) interface{} {
+	if event == nil {
+		return nil
+	}
+	branches := make([]uint, 0, len(event.Branches))
+	for _, branch := range event.Branches {
+		branches = append(branches, branch.BranchID)
+	}
+	years := make([]uint, 0, len(event.Years))
+	for _, year := range event.Years {
+		years = append(years, year.Year)
+	}
+	return map[string]interface{}{
+		"event_name":       event.EventName,
+		"creator":          event.Creator,
+		"start_date":       event.StartDate,
+		"school_year":      event.SchoolYear,
+		"working_hour":     event.WorkingHour,
+		"free_space":       event.FreeSpace,
+		"location":         event.Location,
+		"detail":           event.Detail,
+		"category":         event.Category,
+		"allow_all_branch": event.AllowAllBranch,
+		"allow_all_year":   event.AllowAllYear,
+		"status":           event.Status,
+		"branches":         branches,
+		"years":            years,
 	}
+}
 
-	years, err := utility.DecodeIDs(event.Years)
-	if err != nil {
-		return &response.EventResponse{}, err
+// ผู้เข้าร่วมกิจกรรมหนึ่งแถวระบุด้วยกิจกรรมและนักศึกษา
+func participantTarget(eventID uint, userID uint) string {
+	return fmt.Sprintf("%d/%d", eventID, userID)
+}
+
+func reviewAudit(state entity.ReviewState, comment string) interface{} {
+	return map[string]interface{}{"state": state, "comment": comment}
+}
+
+func mapEventResponse(event entity.Event, count uint) (*response.EventResponse, error) {
+	branches := make([]uint, 0, len(event.Branches))
+	for _, branch := range event.Branches {
+		branches = append(branches, branch.BranchID)
+	}
+	years := make([]uint, 0, len(event.Years))
+	for _, year := range event.Years {
+		years = append(years, year.Year)
 	}
 	limit := event.FreeSpace + count
 
@@ -81,6 +158,7 @@ func mapEventResponse(event entity.Event, count uint) (*response.EventResponse,
 		Limit:          limit,
 		FreeSpace:      event.FreeSpace,
 		Detail:         event.Detail,
+		Category:       event.Category,
 		Location:       event.Location,
 		BranchIDs:      branches,
 		Status:         event.Status,
@@ -150,33 +228,26 @@ func (u *eventUsecase) buildPermission(branches []uint, years []uint) (*entity.P
 		}
 	}
 
-	createPermission := func(branches []uint, years []uint, allowAllBranch, allowAllYear bool) (*entity.Permission, error) {
-		branchData, err := json.Marshal(branches)
-		if err != nil {
-			return nil, err
-		}
-		yearData, err := json.Marshal(years)
-		if err != nil {
-			return nil, err
-		}
-		return &entity.Permission{
-			BranchIDs:      string(branchData),
-			Years:          string(yearData),
-			AllowAllBranch: allowAllBranch,
-			AllowAllYear:   allowAllYear,
-		}, nil
-	}
+	// ไม่ระบุสาขา/ชั้นปี = เปิดให้ทุกสาขา/ทุกชั้นปี
+	return &entity.Permission{
+		BranchIDs:      branches,
+		Years:          years,
+		AllowAllBranch: len(branches) == 0,
+		AllowAllYear:   len(years) == 0,
+	}, nil
+}
 
-	switch {
-	case len(branches) > 0 && len(years) > 0:
-		return createPermission(branches, years, false, false)
-	case len(branches) > 0:
-		return createPermission(branches, nil, false, true)
-	case len(years) > 0:
-		return createPermission(nil, years, true, false)
-	default:
-		return createPermission(nil, nil, true, true)
+// แปลงสิทธิ์เป็นแถวของตาราง event_branches และ event_years
+func permissionRows(permission *entity.Permission) ([]entity.EventBranch, []entity.EventYear) {
+	branches := make([]entity.EventBranch, 0, len(permission.BranchIDs))
+	for _, branchID := range permission.BranchIDs {
+		branches = append(branches, entity.EventBranch{BranchID: branchID})
+	}
+	years := make([]entity.EventYear, 0, len(permission.Years))
+	for _, year := range permission.Years {
+		years = append(years, entity.EventYear{Year: year})
 	}
+	return branches, years
 }
 
 func (u *eventUsecase) CreateEvent(req *request.EventRequest, claims map[string]interface{}) error {
@@ -190,6 +261,7 @@ func (u *eventUsecase) CreateEvent(req *request.EventRequest, claims map[string]
 	if err != nil {
 		return err
 	}
+	branches, years := permissionRows(permission)
 
 	startDate, err := utility.ParseStartDate(req.StartDate)
 	if err != nil {
@@ -203,17 +275,19 @@ func (u *eventUsecase) CreateEvent(req *request.EventRequest, claims map[string]
 		FreeSpace:      req.FreeSpace,
 		WorkingHour:    req.WorkingHour,
 		Detail:         req.Detail,
+		Category:       req.Category,
 		Location:       req.Location,
 		Creator:        userID,
 		AllowAllBranch: permission.AllowAllBranch,
 		AllowAllYear:   permission.AllowAllYear,
-		BranchIDs:      permission.BranchIDs,
-		Years:          permission.Years,
+		Branches:       branches,
+		Years:          years,
 	}
 
 	if err := u.eventRepo.CreateEvent(event); err != nil {
 		return err
 	}
+	u.audit.record(claims, "event.create", "event", event.EventID, nil, eventAudit(event))
 
 	userIDs, err := u.userRepo.GetAllStudentID()
 	if err != nil {
@@ -233,18 +307,80 @@ func (u *eventUsecase) CreateEvent(req *request.EventRequest, claims map[string]
 	return nil
 }
 
-func (u *eventUsecase) GetAllEvent() ([]response.EventResponse, error) {
-	events, err := u.eventRepo.GetAllEvent()
+const (
+	defaultEventPageLimit = 20
+	maxEventPageLimit     = 100
+)
+
+// คอลัมน์ที่อนุญาตให้เรียงลำดับได้
+var eventSortColumns = map[string]bool{
+	"start_date":   true,
+	"event_name":   true,
+	"school_year":  true,
+	"working_hour": true,
+	"free_space":   true,
+	"event_id":     true,
+}
+
+// ตรวจสอบและเติมค่าเริ่มต้นให้ตัวกรองก่อนส่งไป repository
+func normalizeEventFilter(filter *request.EventFilter) error {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultEventPageLimit
+	}
+	if filter.Limit > maxEventPageLimit {
+		filter.Limit = maxEventPageLimit
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "start_date"
+	}
+	if !eventSortColumns[filter.Sort] {
+		return fmt.Errorf("invalid sort field: %s", filter.Sort)
+	}
+	filter.Order = strings.ToLower(filter.Order)
+	if filter.Order == "" {
+		filter.Order = "desc"
+	}
+	if filter.Order != "asc" && filter.Order != "desc" {
+		return fmt.Errorf("invalid order: %s", filter.Order)
+	}
+
+	filter.Search = strings.TrimSpace(filter.Search)
+	var from, to time.Time
+	var err error
+	if filter.DateFrom != "" {
+		if from, err = time.Parse("2006-01-02", filter.DateFrom); err != nil {
+			return fmt.Errorf("invalid date_from format, expected YYYY-MM-DD")
+		}
+	}
+	if filter.DateTo != "" {
+		if to, err = time.Parse("2006-01-02", filter.DateTo); err != nil {
+			return fmt.Errorf("invalid date_to format, expected YYYY-MM-DD")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return fmt.Errorf("date_to must not be before date_from")
+	}
+	return nil
+}
+
+// แปลงรายการกิจกรรม โดยนับผู้เข้าร่วมทั้งหมดใน query เดียว
+func (u *eventUsecase) mapEventResponses(events []entity.Event) ([]response.EventResponse, error) {
+	eventIDs := make([]uint, 0, len(events))
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	counts, err := u.eventRepo.CountEventInsideByIDs(eventIDs)
 	if err != nil {
 		return nil, err
 	}
-	var res []response.EventResponse
+
+	res := []response.EventResponse{}
 	for _, event := range events {
-		count, err := u.eventRepo.CountEventInside(event.EventID)
-		if err != nil {
-			return nil, err
-		}
-		mappedEvent, err := mapEventResponse(event, count)
+		mappedEvent, err := mapEventResponse(event, counts[event.EventID])
 		if err != nil {
 			return nil, err
 		}
@@ -253,6 +389,33 @@ func (u *eventUsecase) GetAllEvent() ([]response.EventResponse, error) {
 	return res, nil
 }
 
+func (u *eventUsecase) buildEventPage(events []entity.Event, total int64, filter request.EventFilter) (*response.EventPage, error) {
+	res, err := u.mapEventResponses(events)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(filter.Limit) - 1) / int64(filter.Limit))
+	return &response.EventPage{
+		Data:       res,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (u *eventUsecase) GetAllEvent(filter request.EventFilter) (*response.EventPage, error) {
+	if err := normalizeEventFilter(&filter); err != nil {
+		return nil, err
+	}
+	events, total, err := u.eventRepo.GetAllEvent(filter)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildEventPage(events, total, filter)
+}
+
 func (u *eventUsecase) GetEventByID(id uint) (*response.EventResponse, error) {
 	event, err := u.eventRepo.GetEventByID(id)
 	if err != nil {
@@ -284,6 +447,8 @@ func (u *eventUsecase) ToggleEventStatus(eventID uint, claims map[string]interfa
 	if err != nil {
 		return false, err
 	}
+	u.audit.record(claims, "event.toggle_status", "event", eventID,
+		map[string]interface{}{"status": event.Status}, map[string]interface{}{"status": newStatus})
 
 	return newStatus, nil
 }
@@ -295,7 +460,10 @@ func (u *eventUsecase) UpdateEventByID(eventID uint, claims map[string]interface
 	}
 	userID := uint(userIDFloat)
 
-	return u.eventRepo.UpdateEventWithTransaction(eventID, userID, req)
+	before, _ := u.eventRepo.GetEventByID(eventID)
+	return u.eventRepo.UpdateEventWithTransaction(eventID, userID, req, func(after *entity.Event) *entity.AuditLog {
+		return u.audit.entry(claims, "event.update", "event", eventID, eventAudit(before), eventAudit(after))
+	})
 }
 
 
@@ -306,7 +474,12 @@ func (u *eventUsecase) DeleteEventByID(eventID uint, claims map[string]interface
 	}
 	userID := uint(userIDFloat)
 
-	return u.eventRepo.DeleteEventWithTransaction(eventID, userID)
+	before, _ := u.eventRepo.GetEventByID(eventID)
+	if err := u.eventRepo.DeleteEventWithTransaction(eventID, userID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "event.delete", "event", eventID, eventAudit(before), nil)
+	return nil
 }
 
 func (u *eventUsecase) MyEvent(claims map[string]interface{}) ([]response.EventResponse, error) {
@@ -320,66 +493,94 @@ func (u *eventUsecase) MyEvent(claims map[string]interface{}) ([]response.EventR
 	if err != nil {
 		return nil, err
 	}
-	var res []response.EventResponse
-	for _, event := range events {
-		count, err := u.eventRepo.CountEventInside(event.EventID)
-		if err != nil {
-			return nil, err
-		}
-		mappedEvent, err := mapEventResponse(event, count)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, *mappedEvent)
-	}
-	return res, nil
+	return u.mapEventResponses(events)
 }
 
-func (u *eventUsecase) AllAllowedEvent() ([]response.EventResponse, error) {
-	events, err := u.eventRepo.AllAllowedEvent()
+func (u *eventUsecase) AllAllowedEvent(filter request.EventFilter) (*response.EventPage, error) {
+	if err := normalizeEventFilter(&filter); err != nil {
+		return nil, err
+	}
+	events, total, err := u.eventRepo.AllAllowedEvent(filter)
 	if err != nil {
 		return nil, err
 	}
-	var res []response.EventResponse
-	for _, event := range events {
-		count, err := u.eventRepo.CountEventInside(event.EventID)
-		if err != nil {
-			return nil, err
-		}
-		mappedEvent, err := mapEventResponse(event, count)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, *mappedEvent)
+	return u.buildEventPage(events, total, filter)
+}
+
+func (u *eventUsecase) AllCurrentEvent(filter request.EventFilter) (*response.EventPage, error) {
+	if err := normalizeEventFilter(&filter); err != nil {
+		return nil, err
 	}
-	return res, nil
+	events, total, err := u.eventRepo.AllCurrentEvent(filter)
+	if err != nil {
+		return nil, err
+	}
+	return u.buildEventPage(events, total, filter)
 }
 
-func (u *eventUsecase) AllCurrentEvent() ([]response.EventResponse, error) {
-	events, err := u.eventRepo.AllCurrentEvent()
+// ฟีดกิจกรรมสำหรับนักศึกษา: เฉพาะกิจกรรมที่เปิดอยู่ ยังไม่เริ่ม และตรงกับสาขา/ชั้นปีของนักศึกษา
+func (u *eventUsecase) EligibleEvents(claims map[string]interface{}, filter request.EventFilter) (*response.EligibleEventPage, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	student, err := u.userRepo.GetStudentByID(userID)
+	if err != nil || student == nil {
+		return nil, fmt.Errorf("student not found")
+	}
+
+	// กิจกรรมที่ใกล้จะเริ่มขึ้นก่อน
+	if filter.Sort == "" && filter.Order == "" {
+		filter.Order = "asc"
+	}
+	if err := normalizeEventFilter(&filter); err != nil {
+		return nil, err
+	}
+	filter.Branch = student.BranchId
+	filter.Year = student.Year
+	filter.Status = nil
+
+	events, total, err := u.eventRepo.OpenUpcomingEvent(filter)
 	if err != nil {
 		return nil, err
 	}
-	var res []response.EventResponse
-	for _, event := range events {
-		count, err := u.eventRepo.CountEventInside(event.EventID)
-		if err != nil {
-			return nil, err
-		}
-		mappedEvent, err := mapEventResponse(event, count)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, *mappedEvent)
+	page, err := u.buildEventPage(events, total, filter)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+
+	eventIDs := make([]uint, 0, len(page.Data))
+	for _, event := range page.Data {
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	joined, err := u.eventRepo.JoinedEventIDs(userID, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]response.EligibleEventResponse, 0, len(page.Data))
+	for _, event := range page.Data {
+		res = append(res, response.EligibleEventResponse{
+			EventResponse: event,
+			Joined:        joined[event.EventID],
+		})
+	}
+	return &response.EligibleEventPage{
+		Data:       res,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		Total:      page.Total,
+		TotalPages: page.TotalPages,
+	}, nil
 }
 
-func (u *eventUsecase) MyEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,*response.DoneResponse,error){
+func (u *eventUsecase) MyEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,*response.DoneResponse,*response.RuleEvaluation,error){
 	
 	inside,err:= u.eventRepo.AllEventInsideThisYear(userID,year)
 	if err != nil {
-		return nil,nil,nil,err
+		return nil,nil,nil,nil,err
 	}
 	var insideEvents []response.MyInside
 	for _, event := range inside {
@@ -391,15 +592,17 @@ func (u *eventUsecase) MyEventThisYear(userID uint,year uint) ([]response.MyInsi
 			StartTime: utility.FormatToThaiTime(event.Event.StartDate),
 			WorkingHour: event.Event.WorkingHour,
 			SchoolYear: event.Event.SchoolYear,
-			Status:event.Status,
+			State: string(event.State),
 			Comment: event.Comment,
 			File: event.File,
+			FileURL: u.evidenceURL(event.File),
+			StateChangedAt: event.StateChangedAt,
 		}
 		insideEvents = append(insideEvents, mappedEvent)
 	}
 	outside,err:=u.eventRepo.AllEventOutsideThisYear(userID,year)
 	if err != nil {
-		return nil,nil,nil, err
+		return nil,nil,nil,nil,err
 	}
 	var outsideEvents []response.MyOutside
 	for _, event := range outside {
@@ -413,29 +616,42 @@ func (u *eventUsecase) MyEventThisYear(userID uint,year uint) ([]response.MyInsi
 			SchoolYear: event.SchoolYear,
 			Intendant: event.Intendant,
 			File: event.File,
+			FileURL: u.evidenceURL(event.File),
+			State: string(event.State),
+			Comment: event.Comment,
+			StateChangedAt: event.StateChangedAt,
 		}
 		outsideEvents = append(outsideEvents, mappedEvent)
 	}
+	evaluation, err := evaluateActivityRule(u.ruleRepo, u.userRepo, userID, year)
+	if err != nil {
+		return nil,nil,nil,nil,err
+	}
 	result ,err := u.userRepo.GetDone(userID,year)
 	if err != nil {
-		return nil,nil,nil,err
+		return nil,nil,nil,nil,err
 	}
 	if result == nil{
-		return insideEvents,outsideEvents,nil,nil
+		return insideEvents,outsideEvents,nil,evaluation,nil
 	}else{
 		dones := response.DoneResponse{
 			User: result.User,
 			Certifier: result.Certifier,
 			Year: result.Year,
-			Status: result.Status,
+			State: string(result.State),
 			Comment: result.Comment,
+			StateChangedAt: result.StateChangedAt,
 		}
-		return insideEvents,outsideEvents,&dones,nil
+		return insideEvents,outsideEvents,&dones,evaluation,nil
 	}
 
 }
-func (u *eventUsecase) SendEventThisYear(userID uint,year uint) ([]response.MyInside,[]response.MyOutside,error){
-	
+func (u *eventUsecase) SendEventThisYear(userID uint,year uint,claims map[string]interface{}) ([]response.MyInside,[]response.MyOutside,error){
+	// ให้ลิงก์ไฟล์เฉพาะหลักฐานที่ผู้เรียกมีสิทธิ์ดู
+	access, err := u.evidenceAccessFor(claims, userID)
+	if err != nil {
+		return nil,nil,err
+	}
 	inside,err:= u.eventRepo.AllEventInsideThisYear(userID,year)
 	if err != nil {
 		return nil,nil,err
@@ -450,9 +666,13 @@ func (u *eventUsecase) SendEventThisYear(userID uint,year uint) ([]response.MyIn
 			StartTime: utility.FormatToThaiTime(event.Event.StartDate),
 			WorkingHour: event.Event.WorkingHour,
 			SchoolYear: event.Event.SchoolYear,
-			Status:event.Status,
+			State: string(event.State),
 			Comment: event.Comment,
 			File: event.File,
+			StateChangedAt: event.StateChangedAt,
+		}
+		if access.allows(event.Certifier) {
+			mappedEvent.FileURL = u.evidenceURL(event.File)
 		}
 		insideEvents = append(insideEvents, mappedEvent)
 	}
@@ -472,6 +692,12 @@ func (u *eventUsecase) SendEventThisYear(userID uint,year uint) ([]response.MyIn
 			SchoolYear: event.SchoolYear,
 			Intendant: event.Intendant,
 			File: event.File,
+			State: string(event.State),
+			Comment: event.Comment,
+			StateChangedAt: event.StateChangedAt,
+		}
+		if access.allows(event.Certifier) {
+			mappedEvent.FileURL = u.evidenceURL(event.File)
 		}
 		outsideEvents = append(outsideEvents, mappedEvent)
 	}
@@ -484,32 +710,12 @@ func (u *eventUsecase) SendEventThisYear(userID uint,year uint) ([]response.MyIn
 // }
 
 // Inside
-func checkPermission(permission *response.EventResponse, user *entity.Student) bool {
-	if permission == nil || user == nil {
-		return false
-	}
-	permissionBranch := permission.AllowAllBranch
-	permissionYear := permission.AllowAllYear
-
-	if !permissionBranch && permission.BranchIDs != nil {
-		for _, branch := range permission.BranchIDs {
-			if user.BranchId == branch {
-				permissionBranch = true
-				break
-			}
-		}
-	}
-
-	if !permissionYear && permission.Years != nil {
-		for _, year := range permission.Years {
-			if user.Year == year {
-				permissionYear = true
-				break
-			}
-		}
+// ตรวจสอบสิทธิ์สาขา/ชั้นปีของนักศึกษาจากตาราง event_branches และ event_years
+func (u *eventUsecase) checkPermission(eventID uint, user *entity.Student) (bool, error) {
+	if user == nil {
+		return false, nil
 	}
-
-	return permissionBranch && permissionYear
+	return u.eventRepo.HasEventPermission(eventID, user.BranchId, user.Year)
 }
 
 func (u *eventUsecase) JoinEvent(eventID uint, claims map[string]interface{}) error {
@@ -537,14 +743,18 @@ func (u *eventUsecase) JoinEvent(eventID uint, claims map[string]interface{}) er
 		return fmt.Errorf("the event is full")
 	}
 
-	if !checkPermission(event, student) {
+	allowed, err := u.checkPermission(eventID, student)
+	if err != nil {
+		return fmt.Errorf("failed to check permission: %w", err)
+	}
+	if !allowed {
 		return fmt.Errorf("user is not allowed to join this event")
 	}
 
 	eventInside := &entity.EventInside{
 		EventId:   eventID,
 		User:      userID,
-		Status:    false,
+		State:     entity.StateJoined,
 		Certifier: event.Creator.UserID,
 	}
 
@@ -552,6 +762,8 @@ func (u *eventUsecase) JoinEvent(eventID uint, claims map[string]interface{}) er
 	if err != nil {
 		return fmt.Errorf("failed to join event inside: %w", err)
 	}
+	u.audit.record(claims, "participant.join", "participant", participantTarget(eventID, userID), nil,
+		map[string]interface{}{"state": eventInside.State, "certifier": eventInside.Certifier})
 	return nil
 }
 
@@ -574,105 +786,669 @@ func (u *eventUsecase) UnJoinEvent(eventID uint, claims map[string]interface{})
 	if err != nil {
 		return fmt.Errorf("event not found")
 	}
+	inside, err := u.getEventInside(eventID, userID)
+	if err != nil {
+		return fmt.Errorf("user has not joined this event")
+	}
 
 	// ดำเนินการ Unjoin Event
 	if err := u.eventRepo.UnJoinEvent(eventID, userID); err != nil {
 		return fmt.Errorf("failed to unjoin event: %w", err)
 	}
+	u.removeEvidence(inside.File)
+	u.audit.record(claims, "participant.unjoin", "participant", participantTarget(eventID, userID),
+		map[string]interface{}{"state": inside.State, "file": inside.File}, nil)
 
 	return nil
 }
 
-func (u *eventUsecase) UploadFile(eventID uint, claims map[string]interface{}, file *multipart.FileHeader) error {
-	//  ตรวจสอบประเภทไฟล์ให้เป็น PDF
-	if file.Header.Get("Content-Type") != "application/pdf" ||
-		!strings.HasSuffix(file.Filename, ".pdf") {
-		return fmt.Errorf("only PDF files are allowed")
+func (u *eventUsecase) JoinWaitlist(eventID uint, claims map[string]interface{}) (uint, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid user_id in claims")
 	}
+	userID := uint(userIDFloat)
 
-	//  จำกัดขนาดไฟล์ที่ 10MB
-	const maxFileSize = 10 * 1024 * 1024
-	if file.Size > maxFileSize {
-		return fmt.Errorf("file size exceeds the 10MB limit")
+	student, err := u.userRepo.GetStudentByID(userID)
+	if err != nil || student == nil {
+		return 0, fmt.Errorf("student not found")
 	}
 
-	//  ดึง user_id จาก claims
+	event, err := u.GetEventByID(eventID)
+	if err != nil {
+		return 0, fmt.Errorf("event not found")
+	}
+
+	if !event.Status {
+		return 0, fmt.Errorf("event not allowed")
+	}
+
+	allowed, err := u.checkPermission(eventID, student)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check permission: %w", err)
+	}
+	if !allowed {
+		return 0, fmt.Errorf("user is not allowed to join this event")
+	}
+
+	// ต่อคิวได้เฉพาะกิจกรรมที่เต็มแล้ว
+	if event.FreeSpace > 0 {
+		return 0, fmt.Errorf("event still has free space, join the event directly")
+	}
+
+	position, err := u.eventRepo.JoinWaitlist(eventID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+	u.audit.record(claims, "waitlist.join", "waitlist", participantTarget(eventID, userID), nil,
+		map[string]interface{}{"position": position})
+	return position, nil
+}
+
+func (u *eventUsecase) LeaveWaitlist(eventID uint, claims map[string]interface{}) error {
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
 		return fmt.Errorf("invalid user_id in claims")
 	}
 	userID := uint(userIDFloat)
 
-	// ค้นหาไฟล์ปัจจุบันในฐานข้อมูล
-	currentFilePath, err := u.eventRepo.GetFilePath(eventID, userID)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("failed to fetch current file path: %w", err)
+	if err := u.eventRepo.LeaveWaitlist(eventID, userID); err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
 	}
+	u.audit.record(claims, "waitlist.leave", "waitlist", participantTarget(eventID, userID), nil, nil)
+	return nil
+}
 
-	// ถ้ามีไฟล์เดิมให้ลบ
-	if currentFilePath != "" {
-		if removeErr := os.Remove(currentFilePath); removeErr != nil {
-			return fmt.Errorf("failed to remove old file: %v", removeErr)
-		}
+func (u *eventUsecase) MyWaitlist(claims map[string]interface{}) ([]response.WaitlistResponse, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	entries, err := u.eventRepo.MyWaitlist(userID)
+	if err != nil {
+		return nil, err
+	}
+	var res []response.WaitlistResponse
+	for _, entry := range entries {
+		res = append(res, response.WaitlistResponse{
+			EventID:   entry.EventID,
+			EventName: entry.EventName,
+			StartDate: utility.FormatToThaiDate(entry.StartDate),
+			StartTime: utility.FormatToThaiTime(entry.StartDate),
+			Position:  entry.Position,
+			Total:     entry.Total,
+		})
+	}
+	return res, nil
+}
+
+func (u *eventUsecase) UploadFile(eventID uint, claims map[string]interface{}, files []*multipart.FileHeader) error {
+	//  ตรวจสอบชนิดและขนาดไฟล์ (PDF หนึ่งไฟล์ หรือรูปถ่ายหลายรูป)
+	if err := checkEvidenceFiles(files); err != nil {
+		return err
+	}
+
+	//  ดึง user_id จาก claims
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	// ส่งหลักฐานได้ก่อนตรวจเสร็จ หรือเมื่ออาจารย์ขอให้ส่งใหม่
+	inside, err := u.getEventInside(eventID, userID)
+	if err != nil {
+		return err
+	}
+	transition, err := newTransition(entity.ReviewKindInside, eventID, userID, inside.State, entity.StateEvidenceSubmitted, userID, "")
+	if err != nil {
+		return err
 	}
 
 	// บันทึกไฟล์ใหม่
-	path, err := filesystem.SaveFile(file, userID)
+	key, thumbnail, err := u.saveEvidence(files, userID)
 	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
+		return err
 	}
 
 	// อัปเดตฐานข้อมูล
-	err = u.eventRepo.UploadFile(eventID, userID, path)
+	audit := u.audit.entry(claims, "participant.upload", "participant", participantTarget(eventID, userID),
+		map[string]interface{}{"state": inside.State, "file": inside.File},
+		map[string]interface{}{"state": transition.ToState, "file": key})
+	err = u.eventRepo.UploadFile(transition, key, thumbnail, audit)
 	if err != nil {
-		os.Remove(path) // ลบไฟล์ใหม่หากอัปเดต DB ไม่สำเร็จ
+		u.removeEvidence(key, thumbnail) // ลบไฟล์ใหม่หากอัปเดต DB ไม่สำเร็จ
 		return fmt.Errorf("failed to update database: %w", err)
 	}
+	// ลบไฟล์เดิมหลังบันทึกไฟล์ใหม่สำเร็จแล้ว
+	u.removeEvidence(inside.File, inside.Thumbnail)
 
 	return nil
 }
 
-func (u *eventUsecase) GetFile(eventID uint, userID uint) (string, error) {
-	filePath, err := u.eventRepo.GetFilePath(eventID, userID)
+// ไฟล์หลักฐานกิจกรรมภายในของนักศึกษา userID (ตรวจสิทธิ์ด้วย authorizeEvidence)
+func (u *eventUsecase) insideEvidence(eventID uint, userID uint, claims map[string]interface{}) (string, error) {
+	inside, err := u.getEventInside(eventID, userID)
 	if err != nil {
 		return "", err
 	}
-	return filePath, nil
+	if err := u.authorizeEvidence(claims, userID, inside.Certifier); err != nil {
+		return "", err
+	}
+	if inside.File == "" {
+		return "", fmt.Errorf("file not found")
+	}
+	return inside.File, nil
 }
 
-func (u *eventUsecase) MyChecklist(eventID uint, claims map[string]interface{}) ([]response.MyChecklist, error) {
-	//  ดึง user_id จาก claims
+func (u *eventUsecase) GetFile(eventID uint, userID uint, claims map[string]interface{}) (io.ReadCloser, error) {
+	key, err := u.insideEvidence(eventID, userID, claims)
+	if err != nil {
+		return nil, err
+	}
+	return u.openEvidence(key)
+}
+
+// ลิงก์ดาวน์โหลดแบบมีอายุ สำหรับเปิดไฟล์โดยไม่ต้องแนบ token
+func (u *eventUsecase) FileURL(eventID uint, userID uint, claims map[string]interface{}) (string, error) {
+	key, err := u.insideEvidence(eventID, userID, claims)
+	if err != nil {
+		return "", err
+	}
+	return u.storage.SignedURL(key, EvidenceURLExpiry)
+}
+
+// สิทธิ์ดูหลักฐานของนักศึกษาหนึ่งคน
+type evidenceAccess struct {
+	callerID uint
+	// แอดมิน ผู้ดูแลคณะของนักศึกษา หรือตัวนักศึกษาเอง ดูได้ทุกไฟล์
+	privileged bool
+}
+
+// ผู้ตรวจของรายการนั้นดูได้เฉพาะไฟล์ที่ตนเป็นผู้ตรวจ
+func (a *evidenceAccess) allows(certifierID uint) bool {
+	return a.privileged || (certifierID != 0 && a.callerID == certifierID)
+}
+
+func (u *eventUsecase) evidenceAccessFor(claims map[string]interface{}, ownerID uint) (*evidenceAccess, error) {
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
 		return nil, fmt.Errorf("invalid user_id in claims")
 	}
-	userID := uint(userIDFloat)
+	access := &evidenceAccess{callerID: uint(userIDFloat)}
+	role, _ := claims["role"].(string)
+	if role == "admin" || role == "superadmin" || access.callerID == ownerID {
+		access.privileged = true
+		return access, nil
+	}
+	superUserID, err := u.userRepo.GetSuperUserForStudent(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	access.privileged = superUserID != nil && *superUserID == access.callerID
+	return access, nil
+}
+
+func (u *eventUsecase) authorizeEvidence(claims map[string]interface{}, ownerID uint, certifierID uint) error {
+	access, err := u.evidenceAccessFor(claims, ownerID)
+	if err != nil {
+		return err
+	}
+	if !access.allows(certifierID) {
+		return fmt.Errorf("you do not have permission to access this file")
+	}
+	return nil
+}
+
+// อายุของลิงก์ดาวน์โหลดหลักฐานใน response
+const EvidenceURLExpiry = 15 * time.Minute
+
+// จำกัดขนาดไฟล์ละ 10MB และรวมทุกไฟล์ไม่เกิน 30MB
+const (
+	maxEvidenceFileSize  = 10 * 1024 * 1024
+	maxEvidenceTotalSize = 30 * 1024 * 1024
+)
+
+// ตรวจนามสกุลและขนาดก่อนอ่านไฟล์ ส่วนชนิดจริงตรวจจากเนื้อไฟล์ใน readEvidence
+func checkEvidenceFiles(files []*multipart.FileHeader) error {
+	if len(files) == 0 {
+		return fmt.Errorf("file is required")
+	}
+	var total int64
+	for _, file := range files {
+		switch strings.ToLower(path.Ext(file.Filename)) {
+		case ".pdf":
+			if len(files) > 1 {
+				return fmt.Errorf("only one PDF file can be uploaded")
+			}
+		case ".jpg", ".jpeg", ".png":
+		default:
+			return fmt.Errorf("only PDF, JPEG and PNG files are allowed")
+		}
+		if file.Size > maxEvidenceFileSize {
+			return fmt.Errorf("file size exceeds the 10MB limit")
+		}
+		total += file.Size
+	}
+	if total > maxEvidenceTotalSize {
+		return fmt.Errorf("total file size exceeds the 30MB limit")
+	}
+	return nil
+}
+
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return content, nil
+}
+
+// อ่านไฟล์ที่อัปโหลดเป็น PDF หลักฐาน รูปถ่ายจะถูกรวมเป็น PDF พร้อมคืนภาพย่อของรูปแรก
+// ไฟล์ต้นฉบับทุกไฟล์ผ่านการสแกนก่อนแปลง
+func (u *eventUsecase) readEvidence(files []*multipart.FileHeader) ([]byte, []byte, error) {
+	contents := make([][]byte, 0, len(files))
+	for _, file := range files {
+		content, err := readUpload(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if u.scanner != nil {
+			if err := u.scanner.Scan(bytes.NewReader(content)); err != nil {
+				return nil, nil, err
+			}
+		}
+		contents = append(contents, content)
+	}
+
+	if len(contents) == 1 && !filesystem.IsEvidenceImage(contents[0]) {
+		return contents[0], nil, nil
+	}
+	for i, content := range contents {
+		if !filesystem.IsEvidenceImage(content) {
+			return nil, nil, fmt.Errorf("%w: file %d is not a JPEG or PNG image", filesystem.ErrInvalidImage, i+1)
+		}
+	}
+	// รูปละหนึ่งหน้า จึงจำกัดจำนวนรูปเท่าจำนวนหน้าสูงสุดของ PDF
+	if u.pdfLimits.MaxPages > 0 && len(contents) > u.pdfLimits.MaxPages {
+		return nil, nil, fmt.Errorf("%w: more than %d images", filesystem.ErrInvalidImage, u.pdfLimits.MaxPages)
+	}
+	return filesystem.ImagesToPDF(contents)
+}
+
+// บันทึกไฟล์หลักฐานลง storage คืน key ของ PDF และ key ของภาพย่อ (ว่างถ้าส่งเป็น PDF)
+// ตรวจเนื้อไฟล์จริงก่อนเก็บ เพราะ Content-Type และนามสกุลมาจากฝั่ง client
+func (u *eventUsecase) saveEvidence(files []*multipart.FileHeader, userID uint) (string, string, error) {
+	content, thumbnail, err := u.readEvidence(files)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := filesystem.InspectPDF(content, u.pdfLimits); err != nil {
+		return "", "", err
+	}
+
+	name := fmt.Sprintf("%d/%s", userID, uuid.New().String())
+	key := name + ".pdf"
+	if err := u.storage.Put(key, bytes.NewReader(content), "application/pdf"); err != nil {
+		return "", "", fmt.Errorf("failed to save file: %w", err)
+	}
+	if thumbnail == nil {
+		return key, "", nil
+	}
+	thumbnailKey := name + "-thumb.jpg"
+	if err := u.storage.Put(thumbnailKey, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+		u.removeEvidence(key)
+		return "", "", fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+	return key, thumbnailKey, nil
+}
+
+// ลบไม่สำเร็จจะเขียน log แทน เพราะข้อมูลในฐานข้อมูลเปลี่ยนไปแล้ว
+func (u *eventUsecase) removeEvidence(keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := u.storage.Delete(key); err != nil {
+			log.Printf("failed to remove file %s: %v", key, err)
+		}
+	}
+}
+
+func (u *eventUsecase) openEvidence(key string) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, fmt.Errorf("file not found")
+	}
+	file, err := u.storage.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
+
+// ลิงก์ดาวน์โหลดแบบมีอายุ (ว่างถ้ายังไม่มีไฟล์หรือสร้างลิงก์ไม่ได้)
+func (u *eventUsecase) evidenceURL(key string) string {
+	if key == "" || u.storage == nil {
+		return ""
+	}
+	link, err := u.storage.SignedURL(key, EvidenceURLExpiry)
+	if err != nil {
+		log.Printf("failed to sign url for %s: %v", key, err)
+		return ""
+	}
+	return link
+}
+
+// สิทธิ์ดูรายชื่อผู้เข้าร่วมของกิจกรรมหนึ่งกิจกรรม
+type checklistAccess struct {
+	callerID uint
+	admin    bool
+	creator  bool
+	// คณะที่ผู้เรียกเป็นผู้ดูแล
+	superFaculties map[uint]bool
+}
+
+func (u *eventUsecase) checklistAccessFor(claims map[string]interface{}, event *entity.Event) (*checklistAccess, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	role, _ := claims["role"].(string)
+	access := &checklistAccess{
+		callerID:       uint(userIDFloat),
+		admin:          role == "admin" || role == "superadmin",
+		superFaculties: map[uint]bool{},
+	}
+	access.creator = event.Creator == access.callerID
+	faculties, err := u.facultyRepo.GetFacultiesBySuperUser(access.callerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, faculty := range faculties {
+		access.superFaculties[faculty.FacultyID] = true
+	}
+	return access, nil
+}
+
+// แอดมิน ผู้สร้างกิจกรรม หรือผู้ตรวจของผู้เข้าร่วมคนใดคนหนึ่ง
+func (a *checklistAccess) manages(checklist []entity.EventInside) bool {
+	if a.admin || a.creator {
+		return true
+	}
+	for _, inside := range checklist {
+		if inside.Certifier != 0 && inside.Certifier == a.callerID {
+			return true
+		}
+	}
+	return false
+}
+
+// ผู้ดูแลคณะดูรายชื่อได้เมื่อมีนักศึกษาในคณะของตนเข้าร่วม
+func (a *checklistAccess) canView(checklist []entity.EventInside) bool {
+	if a.manages(checklist) {
+		return true
+	}
+	for _, inside := range checklist {
+		if a.superFaculties[inside.Student.Branch.FacultyId] {
+			return true
+		}
+	}
+	return false
+}
+
+// สิทธิ์ดูหลักฐานของผู้เข้าร่วมแต่ละคน ตามกฎเดียวกับ evidenceAccessFor
+func (a *checklistAccess) evidence(inside entity.EventInside) *evidenceAccess {
+	return &evidenceAccess{
+		callerID:   a.callerID,
+		privileged: a.admin || a.callerID == inside.User || a.superFaculties[inside.Student.Branch.FacultyId],
+	}
+}
+
+// รายชื่อผู้เข้าร่วมกิจกรรมพร้อมสิทธิ์ของผู้เรียก ผู้เรียกต้องตรวจสิทธิ์เองก่อนใช้
+func (u *eventUsecase) loadChecklist(eventID uint, claims map[string]interface{}) (*checklistAccess, []entity.EventInside, error) {
+	event, err := u.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("event not found")
+	}
+	access, err := u.checklistAccessFor(claims, event)
+	if err != nil {
+		return nil, nil, err
+	}
+	checklist, err := u.eventRepo.MyChecklist(eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return access, checklist, nil
+}
 
-	checklist, err := u.eventRepo.MyChecklist(userID, eventID)
+func (u *eventUsecase) MyChecklist(eventID uint, claims map[string]interface{}) ([]response.MyChecklist, error) {
+	access, checklist, err := u.loadChecklist(eventID, claims)
 	if err != nil {
 		return nil, err
 	}
+	if !access.canView(checklist) {
+		return nil, fmt.Errorf("you do not have permission to view this checklist")
+	}
+	return u.checklistResponse(access, checklist), nil
+}
+
+func (u *eventUsecase) checklistResponse(access *checklistAccess, checklist []entity.EventInside) []response.MyChecklist {
 	var res []response.MyChecklist
 	for _, inside := range checklist {
 		mappedEvent := response.MyChecklist{
-			EventID:   inside.EventId,
-			UserID:    inside.User,
-			TitleName: inside.Student.TitleName,
-			FirstName: inside.Student.FirstName,
-			LastName:  inside.Student.LastName,
-			Code:      inside.Student.Code,
-			Certifier: inside.Certifier,
-			Status:    inside.Status,
-			Comment:   inside.Comment,
-			File:      inside.File,
+			EventID:        inside.EventId,
+			UserID:         inside.User,
+			TitleName:      inside.Student.TitleName,
+			FirstName:      inside.Student.FirstName,
+			LastName:       inside.Student.LastName,
+			Code:           inside.Student.Code,
+			BranchName:     inside.Student.Branch.BranchName,
+			FacultyName:    inside.Student.Branch.Faculty.FacultyName,
+			Certifier:      inside.Certifier,
+			State:          string(inside.State),
+			Comment:        inside.Comment,
+			File:           inside.File,
+			Attended:       inside.Attended,
+			StateChangedAt: inside.StateChangedAt,
+		}
+		// ให้ลิงก์ไฟล์เฉพาะหลักฐานที่ผู้เรียกมีสิทธิ์ดู
+		if access.evidence(inside).allows(inside.Certifier) {
+			mappedEvent.FileURL = u.evidenceURL(inside.File)
+			mappedEvent.ThumbnailURL = u.evidenceURL(inside.Thumbnail)
+		}
+		if inside.AttendedAt != nil {
+			mappedEvent.AttendedAt = utility.FormatToThaiDate(*inside.AttendedAt) + " " + utility.FormatToThaiTime(*inside.AttendedAt)
 		}
 		res = append(res, mappedEvent)
 	}
-	return res, nil
+	return res
 }
 
-func (u *eventUsecase) UpdateEventStatusAndComment(eventID uint, userID uint, status bool, comment string) error {
-	return u.eventRepo.UpdateEventStatusAndComment(eventID, userID, status, comment)
+func (u *eventUsecase) getEventInside(eventID uint, userID uint) (*entity.EventInside, error) {
+	insides, err := u.eventRepo.GetEventInsides(eventID, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(insides) == 0 {
+		return nil, fmt.Errorf("participant not found")
+	}
+	return &insides[0], nil
+}
+
+func (u *eventUsecase) UpdateEventStatusAndComment(eventID uint, userID uint, claims map[string]interface{}, state string, comment string) error {
+	actorIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	decision, err := parseReviewDecision(state, comment)
+	if err != nil {
+		return err
+	}
+	inside, err := u.getEventInside(eventID, userID)
+	if err != nil {
+		return err
+	}
+	// แก้ผลที่ตรวจเสร็จแล้วได้เฉพาะผู้ตรวจของรายการนั้นหรือแอดมิน
+	role, _ := claims["role"].(string)
+	if isFinalState(inside.State) && inside.Certifier != uint(actorIDFloat) && role != "admin" && role != "superadmin" {
+		return fmt.Errorf("you do not have permission to correct this review")
+	}
+	transition, err := newReview(entity.ReviewKindInside, eventID, userID, inside.State, decision, uint(actorIDFloat), comment)
+	if err != nil {
+		return err
+	}
+	audit := u.audit.entry(claims, "participant.review", "participant", participantTarget(eventID, userID),
+		reviewAudit(inside.State, inside.Comment), reviewAudit(decision, comment))
+	return u.eventRepo.UpdateInsideState(transition, audit)
+}
+
+// จำนวนผู้เข้าร่วมสูงสุดที่ตรวจได้ในหนึ่งคำขอ
+const maxReviewItems = 500
+
+// ตรวจผู้เข้าร่วมหลายคนในครั้งเดียว คืนผลรายคนตามลำดับที่ส่งมา
+func (u *eventUsecase) ReviewParticipants(eventID uint, claims map[string]interface{}, req request.BatchReviewRequest) ([]response.ReviewOutcome, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("items are required")
+	}
+	if len(req.Items) > maxReviewItems {
+		return nil, fmt.Errorf("cannot review more than %d participants at once", maxReviewItems)
+	}
+
+	certifierID := uint(userIDFloat)
+
+	seen := make(map[uint]bool, len(req.Items))
+	decisions := make([]entity.ReviewState, len(req.Items))
+	userIDs := make([]uint, len(req.Items))
+	for i, item := range req.Items {
+		if seen[item.UserID] {
+			return nil, fmt.Errorf("user %d appears more than once", item.UserID)
+		}
+		seen[item.UserID] = true
+		decision, err := parseReviewDecision(item.State, item.Comment)
+		if err != nil {
+			return nil, fmt.Errorf("user %d: %v", item.UserID, err)
+		}
+		decisions[i] = decision
+		userIDs[i] = item.UserID
+	}
+
+	insides, err := u.eventRepo.GetEventInsides(eventID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[uint]entity.EventInside, len(insides))
+	for _, inside := range insides {
+		byUser[inside.User] = inside
+	}
+
+	// ตรวจสิทธิ์และสถานะรายคนก่อน แถวที่ไม่ผ่านจะไม่ถูกส่งไปบันทึก
+	outcomes := make([]response.ReviewOutcome, len(req.Items))
+	var transitions []entity.ReviewTransition
+	var audits []*entity.AuditLog
+	var indexes []int
+	for i, item := range req.Items {
+		outcomes[i].UserID = item.UserID
+		inside, ok := byUser[item.UserID]
+		if !ok {
+			outcomes[i].Error = "user has not joined this event"
+			continue
+		}
+		if inside.Certifier != certifierID {
+			outcomes[i].Error = "you are not the certifier of this participant"
+			continue
+		}
+		transition, err := newTransition(entity.ReviewKindInside, eventID, item.UserID, inside.State, decisions[i], certifierID, item.Comment)
+		if err != nil {
+			outcomes[i].Error = err.Error()
+			continue
+		}
+		transitions = append(transitions, *transition)
+		audits = append(audits, u.audit.entry(claims, "participant.review", "participant", participantTarget(eventID, item.UserID),
+			reviewAudit(inside.State, inside.Comment), reviewAudit(decisions[i], item.Comment)))
+		indexes = append(indexes, i)
+	}
+
+	applied, err := u.eventRepo.ReviewEventInsides(eventID, transitions, audits)
+	if err != nil {
+		return nil, err
+	}
+	for i, outcome := range applied {
+		outcomes[indexes[i]] = outcome
+	}
+	return outcomes, nil
+}
+
+// อายุของ QR code เช็คชื่อ (นาที)
+const (
+	defaultCheckinMinutes = 15
+	maxCheckinMinutes     = 24 * 60
+)
+
+func (u *eventUsecase) CreateCheckinQR(eventID uint, claims map[string]interface{}, minutes uint) ([]byte, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	event, err := u.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	// เฉพาะผู้สร้างกิจกรรมเท่านั้นที่สร้าง QR code ได้
+	if event.Creator != userID {
+		return nil, fmt.Errorf("you do not have permission to create check-in code for this event")
+	}
+
+	if minutes == 0 {
+		minutes = defaultCheckinMinutes
+	}
+	if minutes > maxCheckinMinutes {
+		return nil, fmt.Errorf("check-in code cannot be valid for more than %d minutes", maxCheckinMinutes)
+	}
+
+	token, err := u.jwt.GenerateCheckinToken(eventID, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate check-in token: %w", err)
+	}
+
+	png, err := qrcode.Encode(token, qrcode.Medium, 512)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QR code: %w", err)
+	}
+	return png, nil
+}
+
+func (u *eventUsecase) CheckIn(token string, claims map[string]interface{}) error {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	eventID, err := u.jwt.ValidateCheckinToken(token)
+	if err != nil {
+		return fmt.Errorf("invalid or expired check-in code")
+	}
+
+	if err := u.eventRepo.MarkAttended(eventID, userID, time.Now()); err != nil {
+		return err
+	}
+	u.audit.record(claims, "participant.check_in", "participant", participantTarget(eventID, userID), nil,
+		map[string]interface{}{"attended": true})
+	return nil
 }
 
 
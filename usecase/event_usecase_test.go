@@ -0,0 +1,414 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go-clean-arch/pkg/storage"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+)
+
+// TestNormalizeEventFilter tests defaults and validation of event list filters
+func TestNormalizeEventFilter(t *testing.T) {
+	t.Run("Defaults are applied", func(t *testing.T) {
+		filter := request.EventFilter{}
+		assert.NoError(t, normalizeEventFilter(&filter))
+		assert.Equal(t, 1, filter.Page)
+		assert.Equal(t, defaultEventPageLimit, filter.Limit)
+		assert.Equal(t, "start_date", filter.Sort)
+		assert.Equal(t, "desc", filter.Order)
+	})
+
+	t.Run("Limit is capped", func(t *testing.T) {
+		filter := request.EventFilter{Limit: 1000}
+		assert.NoError(t, normalizeEventFilter(&filter))
+		assert.Equal(t, maxEventPageLimit, filter.Limit)
+	})
+
+	t.Run("Unknown sort field is rejected", func(t *testing.T) {
+		filter := request.EventFilter{Sort: "creator; DROP TABLE events"}
+		assert.Error(t, normalizeEventFilter(&filter))
+	})
+
+	t.Run("Invalid date range is rejected", func(t *testing.T) {
+		filter := request.EventFilter{DateFrom: "2025-02-01", DateTo: "2025-01-01"}
+		assert.Error(t, normalizeEventFilter(&filter))
+
+		filter = request.EventFilter{DateFrom: "01/02/2025"}
+		assert.Error(t, normalizeEventFilter(&filter))
+	})
+}
+
+// TestGetAllEventSearch tests that LIKE wildcards typed by the user are matched literally
+func TestGetAllEventSearch(t *testing.T) {
+	db, _ := newEventListDB(t, 5)
+	for id, name := range map[uint]string{1: "100% Fun", 2: "1000 runners", 3: "a_b", 4: "axb", 5: `path\x!`} {
+		assert.NoError(t, db.Model(&entity.Event{}).Where("event_id = ?", id).Update("event_name", name).Error)
+	}
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db)}
+
+	for search, want := range map[string][]string{
+		"100%": {"100% Fun"},
+		"a_b":  {"a_b"},
+		`h\x!`: {`path\x!`},
+		"HALL": {"100% Fun", "1000 runners", "a_b", "axb", `path\x!`},
+	} {
+		page, err := u.GetAllEvent(request.EventFilter{Search: search, Sort: "event_name", Order: "asc"})
+		assert.NoError(t, err)
+		var names []string
+		for _, event := range page.Data {
+			names = append(names, event.EventName)
+		}
+		assert.Equal(t, want, names, search)
+	}
+}
+
+// TestReviewParticipants tests batch review of event participants
+func TestReviewParticipants(t *testing.T) {
+	db, _ := newEventListDB(t, 1)
+	assert.NoError(t, db.AutoMigrate(&entity.News{}, &entity.ReviewTransition{}))
+	// ผู้เข้าร่วม 100, 101 มีผู้ตรวจคือ user 1 ส่วน 102 เป็นของ user 2
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("user IN ?", []uint{100, 101}).Update("certifier", 1).Error)
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("user = ?", 102).Update("certifier", 2).Error)
+
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db)}
+	claims := map[string]interface{}{"user_id": float64(1)}
+
+	t.Run("Invalid batches are rejected", func(t *testing.T) {
+		_, err := u.ReviewParticipants(1, claims, request.BatchReviewRequest{})
+		assert.Error(t, err)
+		_, err = u.ReviewParticipants(1, claims, request.BatchReviewRequest{Items: []request.ReviewItem{{UserID: 100, State: "approved"}, {UserID: 100, State: "approved"}}})
+		assert.Error(t, err)
+		_, err = u.ReviewParticipants(1, claims, request.BatchReviewRequest{Items: []request.ReviewItem{{UserID: 100, State: "rejected"}}})
+		assert.Error(t, err)
+		_, err = u.ReviewParticipants(1, claims, request.BatchReviewRequest{Items: []request.ReviewItem{{UserID: 100, State: "joined"}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Only rows certified by the caller are updated", func(t *testing.T) {
+		outcomes, err := u.ReviewParticipants(1, claims, request.BatchReviewRequest{Items: []request.ReviewItem{
+			{UserID: 100, State: "approved"},
+			{UserID: 101, State: "resubmission_requested", Comment: "ไม่มีหลักฐาน"},
+			{UserID: 102, State: "approved"},
+			{UserID: 999, State: "approved"},
+		}})
+		assert.NoError(t, err)
+		assert.True(t, outcomes[0].Updated)
+		assert.True(t, outcomes[1].Updated)
+		assert.False(t, outcomes[2].Updated)
+		assert.Equal(t, "you are not the certifier of this participant", outcomes[2].Error)
+		assert.Equal(t, "user has not joined this event", outcomes[3].Error)
+
+		var rows []entity.EventInside
+		assert.NoError(t, db.Order("user").Find(&rows, "event_id = ?", 1).Error)
+		assert.Equal(t, entity.StateApproved, rows[0].State)
+		assert.NotNil(t, rows[0].StateChangedAt)
+		assert.Equal(t, entity.StateResubmissionRequested, rows[1].State)
+		assert.Equal(t, "ไม่มีหลักฐาน", rows[1].Comment)
+		assert.Equal(t, entity.StateJoined, rows[2].State)
+
+		var news []entity.News
+		assert.NoError(t, db.Order("user_id").Find(&news).Error)
+		assert.Len(t, news, 2)
+		assert.Equal(t, uint(101), news[1].UserID)
+
+		var transitions int64
+		assert.NoError(t, db.Model(&entity.ReviewTransition{}).Count(&transitions).Error)
+		assert.Equal(t, int64(2), transitions)
+	})
+
+	t.Run("Transitions follow the state machine", func(t *testing.T) {
+		outcomes, err := u.ReviewParticipants(1, claims, request.BatchReviewRequest{Items: []request.ReviewItem{
+			{UserID: 100, State: "rejected", Comment: "ผิดพลาด"},
+			{UserID: 101, State: "approved"},
+		}})
+		assert.NoError(t, err)
+		// approved เป็นสถานะสุดท้าย และต้องส่งหลักฐานใหม่ก่อนจึงจะตรวจได้อีกครั้ง
+		assert.Equal(t, "cannot change state from approved to rejected", outcomes[0].Error)
+		assert.Equal(t, "cannot change state from resubmission_requested to approved", outcomes[1].Error)
+	})
+
+	t.Run("Certifier or admin can correct a final review", func(t *testing.T) {
+		other := map[string]interface{}{"user_id": float64(2), "role": "teacher"}
+		admin := map[string]interface{}{"user_id": float64(9), "role": "admin"}
+		state := func() entity.ReviewState {
+			var row entity.EventInside
+			assert.NoError(t, db.First(&row, "event_id = ? AND user = ?", 1, 100).Error)
+			return row.State
+		}
+
+		assert.ErrorContains(t, u.UpdateEventStatusAndComment(1, 100, other, "rejected", "ผิดพลาด"), "permission")
+		assert.ErrorContains(t, u.UpdateEventStatusAndComment(1, 100, claims, "rejected", ""), "comment is required")
+		assert.NoError(t, u.UpdateEventStatusAndComment(1, 100, claims, "rejected", "อนุมัติผิดคน"))
+		assert.Equal(t, entity.StateRejected, state())
+		assert.NoError(t, u.UpdateEventStatusAndComment(1, 100, admin, "approved", "ตรวจสอบใหม่แล้ว"))
+		assert.Equal(t, entity.StateApproved, state())
+	})
+}
+
+// TestReviewOutside tests the outside activity review by the faculty super user
+func TestReviewOutside(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.News{}, &entity.ReviewTransition{}))
+	// นักศึกษา 301 อยู่คณะ 2 ซึ่งมี user 9 เป็นผู้ดูแล
+	outside := entity.EventOutside{User: 301, EventName: "volunteer", SchoolYear: 2567, StartDate: time.Now(),
+		Intendant: "x", WorkingHour: 12, Location: "x", Certifier: 9, State: entity.StateEvidenceSubmitted}
+	assert.NoError(t, db.Omit("Student", "Teacher").Create(&outside).Error)
+
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db), userRepo: repository.NewUserRepository(db)}
+	superUser := map[string]interface{}{"user_id": float64(9)}
+
+	t.Run("Pending outside events are queued for the certifier", func(t *testing.T) {
+		queue, err := u.OutsideReviewQueue(superUser)
+		assert.NoError(t, err)
+		if assert.Len(t, queue, 1) {
+			assert.Equal(t, outside.EventID, queue[0].EventID)
+			assert.Equal(t, "Civil", queue[0].Student.BranchName)
+		}
+		queue, err = u.OutsideReviewQueue(map[string]interface{}{"user_id": float64(8)})
+		assert.NoError(t, err)
+		assert.Empty(t, queue)
+	})
+
+	t.Run("Only the certifier can review", func(t *testing.T) {
+		err := u.ReviewOutside(outside.EventID, map[string]interface{}{"user_id": float64(8)}, "approved", "")
+		assert.EqualError(t, err, "you are not the certifier of this activity")
+		err = u.ReviewOutside(outside.EventID, superUser, "rejected", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Approved hours count toward the total", func(t *testing.T) {
+		userRepo := repository.NewUserRepository(db)
+		hours, _, err := userRepo.GetTotalWorkingHours(301, 2567)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), hours)
+
+		assert.NoError(t, u.ReviewOutside(outside.EventID, superUser, "approved", ""))
+		hours, _, err = userRepo.GetTotalWorkingHours(301, 2567)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(12), hours)
+
+		// ผู้ตรวจแก้ผลที่อนุมัติผิดได้ ชั่วโมงจึงไม่ถูกนับอีก
+		assert.NoError(t, u.ReviewOutside(outside.EventID, superUser, "rejected", "ผิดพลาด"))
+		hours, _, err = userRepo.GetTotalWorkingHours(301, 2567)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), hours)
+
+		var news []entity.News
+		assert.NoError(t, db.Find(&news, "user_id = ?", 301).Error)
+		assert.Len(t, news, 2)
+	})
+}
+
+// TestEvidenceAccess tests who may download a student's evidence file
+func TestEvidenceAccess(t *testing.T) {
+	db := newStatsDB(t)
+	// นักศึกษา 301 อยู่คณะ 2 ซึ่งมีผู้ดูแลคณะคือ user 9 ผู้ตรวจของรายการนี้คือ user 5
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("event_id = ? AND user = ?", 1, 301).
+		Updates(map[string]interface{}{"certifier": 5, "file": "301/evidence.pdf"}).Error)
+
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db), userRepo: repository.NewUserRepository(db)}
+	caller := func(userID uint, role string) map[string]interface{} {
+		return map[string]interface{}{"user_id": float64(userID), "role": role}
+	}
+
+	t.Run("Owner, certifier, super user and admin are allowed", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{
+			caller(301, "student"), caller(5, "teacher"), caller(9, "teacher"), caller(1, "admin"),
+		} {
+			key, err := u.insideEvidence(1, 301, claims)
+			assert.NoError(t, err)
+			assert.Equal(t, "301/evidence.pdf", key)
+		}
+	})
+
+	t.Run("Other users are rejected", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{caller(101, "student"), caller(6, "teacher")} {
+			_, err := u.insideEvidence(1, 301, claims)
+			assert.ErrorContains(t, err, "permission")
+		}
+	})
+
+	t.Run("Missing participation is not found", func(t *testing.T) {
+		_, err := u.insideEvidence(2, 301, caller(1, "admin"))
+		assert.Error(t, err)
+		assert.NotContains(t, err.Error(), "permission")
+	})
+}
+
+// TestChecklistAccess tests who may list an event's participants and see their evidence links
+func TestChecklistAccess(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.Teacher{}, &entity.EventBranch{}, &entity.EventYear{}))
+	// กิจกรรม 1 สร้างโดย user 1 ผู้ตรวจของ 101 และ 102 คือ user 1 ส่วนของ 301 คือ user 5
+	// นักศึกษา 301 อยู่คณะ 2 ซึ่งมีผู้ดูแลคณะคือ user 9
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("event_id = ?", 1).
+		Updates(map[string]interface{}{"certifier": 1, "file": "x/evidence.pdf"}).Error)
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("event_id = ? AND user = ?", 1, 301).Update("certifier", 5).Error)
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost", []byte("secret"))
+	assert.NoError(t, err)
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db), facultyRepo: repository.NewFacultyRepositiry(db), storage: store}
+	caller := func(userID uint, role string) map[string]interface{} {
+		return map[string]interface{}{"user_id": float64(userID), "role": role}
+	}
+	links := func(claims map[string]interface{}) map[uint]bool {
+		rows, err := u.MyChecklist(1, claims)
+		assert.NoError(t, err)
+		signed := map[uint]bool{}
+		for _, row := range rows {
+			signed[row.UserID] = row.FileURL != ""
+		}
+		return signed
+	}
+
+	t.Run("Evidence links follow evidence access", func(t *testing.T) {
+		assert.Equal(t, map[uint]bool{101: true, 102: true, 301: true}, links(caller(2, "admin")))
+		assert.Equal(t, map[uint]bool{101: true, 102: true, 301: false}, links(caller(1, "teacher")))
+		assert.Equal(t, map[uint]bool{101: false, 102: false, 301: true}, links(caller(5, "teacher")))
+		assert.Equal(t, map[uint]bool{101: false, 102: false, 301: true}, links(caller(9, "teacher")))
+	})
+
+	t.Run("Unrelated teachers are rejected", func(t *testing.T) {
+		_, err := u.MyChecklist(1, caller(6, "teacher"))
+		assert.ErrorContains(t, err, "permission")
+	})
+
+	t.Run("Missing event is not found", func(t *testing.T) {
+		_, err := u.MyChecklist(99, caller(2, "admin"))
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("Export is limited to creator, certifiers and admins", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{caller(1, "teacher"), caller(5, "teacher"), caller(2, "admin")} {
+			table, err := u.ExportChecklist(1, claims)
+			assert.NoError(t, err)
+			if assert.NotNil(t, table) {
+				assert.Len(t, table.Rows, 3)
+			}
+		}
+		// ผู้ดูแลคณะดูรายชื่อได้แต่ส่งออกไม่ได้
+		for _, claims := range []map[string]interface{}{caller(9, "teacher"), caller(6, "teacher")} {
+			_, err := u.ExportChecklist(1, claims)
+			assert.ErrorContains(t, err, "permission")
+		}
+	})
+}
+
+// TestCreateFileAccess tests that only the owner, certifier or an admin may generate a signed outside form
+func TestCreateFileAccess(t *testing.T) {
+	db := newStatsDB(t)
+	// กิจกรรมภายนอก 1 เป็นของนักศึกษา 101 ผู้ตรวจคือ user 5
+	assert.NoError(t, db.Model(&entity.EventOutside{}).Where("event_id = ?", 1).Update("certifier", 5).Error)
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db)}
+	caller := func(userID uint, role string) map[string]interface{} {
+		return map[string]interface{}{"user_id": float64(userID), "role": role}
+	}
+
+	t.Run("Owner, certifier and admin are allowed", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{
+			caller(101, "student"), caller(5, "teacher"), caller(1, "admin"), caller(2, "superadmin"),
+		} {
+			assert.NoError(t, u.authorizeOutsideForm(1, claims))
+		}
+	})
+
+	t.Run("Other users are rejected", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{caller(102, "student"), caller(6, "teacher")} {
+			_, _, err := u.CreateFile(1, claims)
+			assert.ErrorContains(t, err, "permission")
+		}
+	})
+}
+
+// TestTranscriptAccess tests who may download a student's transcript
+func TestTranscriptAccess(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.Teacher{}))
+	// นักศึกษา 301 อยู่คณะ 2 ซึ่งมีผู้ดูแลคณะคือ user 9 ผู้ตรวจของกิจกรรมคือ user 5
+	assert.NoError(t, db.Model(&entity.EventInside{}).Where("event_id = ? AND user = ?", 1, 301).Update("certifier", 5).Error)
+
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db), userRepo: repository.NewUserRepository(db)}
+	caller := func(userID uint, role string) map[string]interface{} {
+		return map[string]interface{}{"user_id": float64(userID), "role": role}
+	}
+
+	t.Run("Owner, certifier, super user and admin are allowed", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{
+			caller(301, "student"), caller(5, "teacher"), caller(9, "teacher"), caller(1, "admin"),
+		} {
+			transcript, err := u.buildTranscript(301, 2567, claims)
+			assert.NoError(t, err)
+			if assert.NotNil(t, transcript) {
+				assert.Len(t, transcript.Inside, 1)
+			}
+		}
+	})
+
+	t.Run("Other users are rejected", func(t *testing.T) {
+		for _, claims := range []map[string]interface{}{caller(101, "student"), caller(6, "teacher")} {
+			_, err := u.buildTranscript(301, 2567, claims)
+			assert.ErrorContains(t, err, "permission")
+		}
+	})
+}
+
+// TestUpdateEventPromotesWaitlist tests that raising an event's limit admits waitlisted students in order
+func TestUpdateEventPromotesWaitlist(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.Teacher{}, &entity.EventBranch{}, &entity.EventYear{}, &entity.EventWaitlist{}, &entity.News{}))
+	// กิจกรรม 1 มีผู้เข้าร่วม 3 คนและเต็มแล้ว 103 และ 201 รออยู่ในรายชื่อสำรองตามลำดับ
+	for i, userID := range []uint{103, 201} {
+		assert.NoError(t, db.Omit("Event", "Student").Create(&entity.EventWaitlist{EventId: 1, User: userID, Position: uint(i + 1)}).Error)
+	}
+
+	u := &eventUsecase{eventRepo: repository.NewEventRepository(db)}
+	creator := map[string]interface{}{"user_id": float64(1), "role": "teacher"}
+	edit := func(req request.EventRequest) error {
+		req.EventName, req.StartDate, req.WorkingHour, req.Location = "event", "2024-01-01 09:00:00", 10, "hall"
+		return u.UpdateEventByID(1, creator, req)
+	}
+	update := func(limit uint) error {
+		return edit(request.EventRequest{Limit: &limit})
+	}
+	joined := func() []uint {
+		var users []uint
+		assert.NoError(t, db.Model(&entity.EventInside{}).Where("event_id = ?", 1).Order("user").Pluck("user", &users).Error)
+		return users
+	}
+	freeSpace := func() uint {
+		var event entity.Event
+		assert.NoError(t, db.First(&event, 1).Error)
+		return event.FreeSpace
+	}
+
+	t.Run("Free space sent back from GET is ignored", func(t *testing.T) {
+		assert.NoError(t, edit(request.EventRequest{FreeSpace: 1}))
+		assert.Equal(t, []uint{101, 102, 301}, joined())
+		assert.Equal(t, uint(0), freeSpace())
+	})
+
+	t.Run("Raising the limit promotes the first in line", func(t *testing.T) {
+		assert.NoError(t, update(4))
+		assert.Equal(t, []uint{101, 102, 103, 301}, joined())
+		assert.Equal(t, uint(0), freeSpace())
+
+		var news int64
+		assert.NoError(t, db.Model(&entity.News{}).Where("user_id = ? AND title = ?", 103, "ได้รับสิทธิ์เข้าร่วมกิจกรรม").Count(&news).Error)
+		assert.Equal(t, int64(1), news)
+	})
+
+	t.Run("Extra seats remain free once the waitlist is empty", func(t *testing.T) {
+		assert.NoError(t, update(10))
+		assert.Equal(t, []uint{101, 102, 103, 201, 301}, joined())
+		assert.Equal(t, uint(5), freeSpace())
+	})
+
+	t.Run("Limit below joined participants is rejected", func(t *testing.T) {
+		assert.Error(t, update(3))
+		assert.Equal(t, uint(5), freeSpace())
+	})
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility/filesystem"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+)
+
+// รายชื่อผู้เข้าร่วมกิจกรรมสำหรับส่งออกเป็นไฟล์ เฉพาะผู้สร้างกิจกรรม ผู้ตรวจ หรือแอดมิน
+func (u *eventUsecase) ExportChecklist(eventID uint, claims map[string]interface{}) (*response.ExportTable, error) {
+	access, participants, err := u.loadChecklist(eventID, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !access.manages(participants) {
+		return nil, fmt.Errorf("you do not have permission to export this checklist")
+	}
+	checklist := u.checklistResponse(access, participants)
+
+	table := response.ExportTable{
+		Name:   fmt.Sprintf("checklist-%d", eventID),
+		Header: []string{"รหัสนักศึกษา", "ชื่อ-สกุล", "สาขา", "คณะ", "เช็คชื่อ", "สถานะ", "หมายเหตุ", "หลักฐาน"},
+		Rows:   make([][]string, 0, len(checklist)),
+	}
+	for _, row := range checklist {
+		attended := "ยังไม่เช็คชื่อ"
+		if row.Attended {
+			attended = row.AttendedAt
+		}
+		evidence := "ยังไม่อัปโหลด"
+		if row.File != "" {
+			evidence = "อัปโหลดแล้ว"
+		}
+		table.Rows = append(table.Rows, []string{
+			row.Code,
+			row.TitleName + row.FirstName + " " + row.LastName,
+			row.BranchName,
+			row.FacultyName,
+			attended,
+			filesystem.ReviewStatusText(entity.ReviewState(row.State)),
+			row.Comment,
+			evidence,
+		})
+	}
+	return &table, nil
+}
+
+// ผลส่งตรวจ (Done) ทั้งคณะในปีการศึกษา สำหรับผู้ดูแลคณะหรือแอดมิน
+func (u *eventUsecase) ExportFacultyDones(facultyID uint, year uint, claims map[string]interface{}) (*response.ExportTable, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+	role, _ := claims["role"].(string)
+
+	faculty, err := u.facultyRepo.GetFacultyByID(facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "admin" && role != "superadmin" && (faculty.SuperUser == nil || *faculty.SuperUser != userID) {
+		return nil, fmt.Errorf("you do not have permission to export this faculty")
+	}
+
+	dones, err := u.userRepo.DonesByFaculty(facultyID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	table := response.ExportTable{
+		Name:   fmt.Sprintf("dones-%s-%d", faculty.FacultyCode, year),
+		Header: []string{"รหัสนักศึกษา", "ชื่อ-สกุล", "ชั้นปี", "สาขา", "ปีการศึกษา", "ผู้ตรวจสอบ", "สถานะ", "หมายเหตุ"},
+		Rows:   make([][]string, 0, len(dones)),
+	}
+	for _, done := range dones {
+		student := done.Student
+		table.Rows = append(table.Rows, []string{
+			student.Code,
+			student.TitleName + student.FirstName + " " + student.LastName,
+			fmt.Sprint(student.Year),
+			student.Branch.BranchName,
+			fmt.Sprint(done.Year),
+			teacherFullName(done.Teacher),
+			filesystem.ReviewStatusText(done.State),
+			done.Comment,
+		})
+	}
+	return &table, nil
+}
@@ -6,44 +6,83 @@ import (
 )
 
 type FacultyBranchUsecase interface {
-	CreateFaculty(faculty *entity.Faculty) error
+	CreateFaculty(faculty *entity.Faculty, claims map[string]interface{}) error
 	GetAllFaculties() ([]entity.Faculty, error)
-	UpdateFacultyByID(faculty *entity.Faculty) error
-	DeleteFacultyByID(facultyID uint) error
+	UpdateFacultyByID(faculty *entity.Faculty, claims map[string]interface{}) error
+	DeleteFacultyByID(facultyID uint, claims map[string]interface{}) error
 	// UpdateSuperUser(facultyID uint, superUserID uint) error
 
 	// branch
-	CreateBranch(branch *entity.Branch) error
+	CreateBranch(branch *entity.Branch, claims map[string]interface{}) error
 	GetAllBranches() ([]entity.Branch, error)
-	UpdateBranchByID(branch *entity.Branch) error
-	DeleteBranchByID(branchID uint) error
+	UpdateBranchByID(branch *entity.Branch, claims map[string]interface{}) error
+	DeleteBranchByID(branchID uint, claims map[string]interface{}) error
 }
 
 
 type facultyBranchUsecase struct {
 	facultyRepo repository.FacultyBranchRepository
+	audit       auditTrail
 }
 
-func NewFacultyUsecase(facultyRepo repository.FacultyBranchRepository) FacultyBranchUsecase {
+func NewFacultyUsecase(facultyRepo repository.FacultyBranchRepository, auditRepo repository.AuditRepository) FacultyBranchUsecase {
 	return &facultyBranchUsecase{
 		facultyRepo: facultyRepo,
+		audit:       auditTrail{repo: auditRepo},
 	}
 }
 
-func (u *facultyBranchUsecase) CreateFaculty(faculty *entity.Faculty) error {
-	return u.facultyRepo.CreateFaculty(faculty)
+// ค่าของคณะ/สาขาที่เก็บใน audit log (ไม่รวมข้อมูลที่ preload มา)
+func facultyAudit(faculty *entity.Faculty) interface{} {
+	if faculty == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"faculty_code": faculty.FacultyCode,
+		"faculty_name": faculty.FacultyName,
+		"super_user":   faculty.SuperUser,
+	}
+}
+
+func branchAudit(branch *entity.Branch) interface{} {
+	if branch == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"branch_code": branch.BranchCode,
+		"branch_name": branch.BranchName,
+		"faculty_id":  branch.FacultyId,
+	}
+}
+
+func (u *facultyBranchUsecase) CreateFaculty(faculty *entity.Faculty, claims map[string]interface{}) error {
+	if err := u.facultyRepo.CreateFaculty(faculty); err != nil {
+		return err
+	}
+	u.audit.record(claims, "faculty.create", "faculty", faculty.FacultyID, nil, facultyAudit(faculty))
+	return nil
 }
 
 func (u *facultyBranchUsecase) GetAllFaculties() ([]entity.Faculty, error) {
 	return u.facultyRepo.GetAllFaculties()
 }
 
-func (u *facultyBranchUsecase) UpdateFacultyByID(faculty *entity.Faculty) error {
-	return u.facultyRepo.UpdateFacultyByID(faculty)
+func (u *facultyBranchUsecase) UpdateFacultyByID(faculty *entity.Faculty, claims map[string]interface{}) error {
+	before, _ := u.facultyRepo.GetFacultyByID(faculty.FacultyID)
+	if err := u.facultyRepo.UpdateFacultyByID(faculty); err != nil {
+		return err
+	}
+	u.audit.record(claims, "faculty.update", "faculty", faculty.FacultyID, facultyAudit(before), facultyAudit(faculty))
+	return nil
 }
 
-func (u *facultyBranchUsecase) DeleteFacultyByID(facultyID uint) error {
-	return u.facultyRepo.DeleteFacultyByID(facultyID)
+func (u *facultyBranchUsecase) DeleteFacultyByID(facultyID uint, claims map[string]interface{}) error {
+	before, _ := u.facultyRepo.GetFacultyByID(facultyID)
+	if err := u.facultyRepo.DeleteFacultyByID(facultyID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "faculty.delete", "faculty", facultyID, facultyAudit(before), nil)
+	return nil
 }
 
 // func (u *facultyBranchUsecase) UpdateSuperUser(facultyID uint, superUserID uint) error {
@@ -52,17 +91,31 @@ func (u *facultyBranchUsecase) DeleteFacultyByID(facultyID uint) error {
 
 
 // branch ------------------------------------------------------------------
-func (u *facultyBranchUsecase) CreateBranch(branch *entity.Branch) error{
-	return u.facultyRepo.CreateBranch(branch)
+func (u *facultyBranchUsecase) CreateBranch(branch *entity.Branch, claims map[string]interface{}) error{
+	if err := u.facultyRepo.CreateBranch(branch); err != nil {
+		return err
+	}
+	u.audit.record(claims, "branch.create", "branch", branch.BranchID, nil, branchAudit(branch))
+	return nil
 }
 
 func (u *facultyBranchUsecase) GetAllBranches() ([]entity.Branch,error){
 	return u.facultyRepo.GetAllBranches()
 }
 
-func (u *facultyBranchUsecase) UpdateBranchByID(branch *entity.Branch) error{
-	return u.facultyRepo.UpdateBranchByID(branch)
+func (u *facultyBranchUsecase) UpdateBranchByID(branch *entity.Branch, claims map[string]interface{}) error{
+	before, _ := u.facultyRepo.GetBranchByID(branch.BranchID)
+	if err := u.facultyRepo.UpdateBranchByID(branch); err != nil {
+		return err
+	}
+	u.audit.record(claims, "branch.update", "branch", branch.BranchID, branchAudit(before), branchAudit(branch))
+	return nil
 }
-func (u *facultyBranchUsecase) DeleteBranchByID(branchID uint) error{
-	return u.facultyRepo.DeleteBranchByID(branchID)
+func (u *facultyBranchUsecase) DeleteBranchByID(branchID uint, claims map[string]interface{}) error{
+	before, _ := u.facultyRepo.GetBranchByID(branchID)
+	if err := u.facultyRepo.DeleteBranchByID(branchID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "branch.delete", "branch", branchID, branchAudit(before), nil)
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/structure/entity"
+	"strings"
+	"time"
+)
+
+// สถานะถัดไปที่อนุญาตจากแต่ละสถานะ approved และ rejected เป็นสถานะสุดท้าย
+// เปลี่ยนได้อีกเฉพาะการแก้ผลตรวจโดยผู้ตรวจหรือแอดมิน (reviewCorrections)
+var reviewTransitions = map[entity.ReviewState][]entity.ReviewState{
+	entity.StateJoined: {
+		entity.StateEvidenceSubmitted,
+		entity.StateApproved,
+		entity.StateRejected,
+		entity.StateResubmissionRequested,
+	},
+	// ส่งหลักฐานซ้ำได้ระหว่างรอตรวจ
+	entity.StateEvidenceSubmitted: {
+		entity.StateEvidenceSubmitted,
+		entity.StateApproved,
+		entity.StateRejected,
+		entity.StateResubmissionRequested,
+	},
+	entity.StateResubmissionRequested: {
+		entity.StateEvidenceSubmitted,
+	},
+}
+
+// ผลตรวจที่แก้ไขได้เมื่อตรวจผิด
+var reviewCorrections = map[entity.ReviewState][]entity.ReviewState{
+	entity.StateApproved: {entity.StateRejected, entity.StateResubmissionRequested},
+	entity.StateRejected: {entity.StateApproved, entity.StateResubmissionRequested},
+}
+
+// สถานะที่ตรวจเสร็จแล้ว เปลี่ยนต่อได้ด้วยการแก้ผลตรวจเท่านั้น
+func isFinalState(state entity.ReviewState) bool {
+	_, ok := reviewCorrections[state]
+	return ok
+}
+
+func checkTransition(from entity.ReviewState, to entity.ReviewState) error {
+	return checkAllowed(reviewTransitions, from, to)
+}
+
+func checkAllowed(allowed map[entity.ReviewState][]entity.ReviewState, from entity.ReviewState, to entity.ReviewState) error {
+	for _, next := range allowed[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change state from %s to %s", from, to)
+}
+
+// แปลงผลการตรวจที่อาจารย์ส่งมา ต้องเป็น approved, rejected หรือ resubmission_requested
+// และต้องระบุเหตุผลเมื่อไม่อนุมัติหรือขอให้ส่งใหม่
+func parseReviewDecision(state string, comment string) (entity.ReviewState, error) {
+	decision := entity.ReviewState(state)
+	switch decision {
+	case entity.StateApproved:
+		return decision, nil
+	case entity.StateRejected, entity.StateResubmissionRequested:
+		if strings.TrimSpace(comment) == "" {
+			return "", fmt.Errorf("comment is required when state is %s", decision)
+		}
+		return decision, nil
+	default:
+		return "", fmt.Errorf("invalid state %q", state)
+	}
+}
+
+// สร้างรายการประวัติหลังตรวจสอบว่าเปลี่ยนสถานะได้
+func newTransition(kind string, refID uint, userID uint, from entity.ReviewState, to entity.ReviewState, actorID uint, comment string) (*entity.ReviewTransition, error) {
+	if err := checkTransition(from, to); err != nil {
+		return nil, err
+	}
+	return buildTransition(kind, refID, userID, from, to, actorID, comment), nil
+}
+
+// ผลการตรวจของผู้ตรวจ ถ้าแถวตรวจเสร็จแล้วจะถือเป็นการแก้ผลตรวจซึ่งต้องระบุเหตุผลเสมอ
+// ผู้เรียกต้องตรวจแล้วว่า actor เป็นผู้ตรวจของแถวนั้นหรือแอดมิน
+func newReview(kind string, refID uint, userID uint, from entity.ReviewState, to entity.ReviewState, actorID uint, comment string) (*entity.ReviewTransition, error) {
+	if !isFinalState(from) {
+		return newTransition(kind, refID, userID, from, to, actorID, comment)
+	}
+	if strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("comment is required when correcting a %s review", from)
+	}
+	if err := checkAllowed(reviewCorrections, from, to); err != nil {
+		return nil, err
+	}
+	return buildTransition(kind, refID, userID, from, to, actorID, comment), nil
+}
+
+func buildTransition(kind string, refID uint, userID uint, from entity.ReviewState, to entity.ReviewState, actorID uint, comment string) *entity.ReviewTransition {
+	return &entity.ReviewTransition{
+		Kind:      kind,
+		RefID:     refID,
+		UserID:    userID,
+		FromState: from,
+		ToState:   to,
+		ActorID:   actorID,
+		Comment:   comment,
+		CreatedAt: time.Now(),
+	}
+}
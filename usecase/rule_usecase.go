@@ -0,0 +1,272 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
+)
+
+// เกณฑ์เดิมที่ใช้เมื่อยังไม่มีการกำหนดเกณฑ์ใดๆ ในระบบ
+var defaultActivityRule = entity.ActivityRule{
+	MinInsideHour: 18,
+	MinTotalHour:  36,
+}
+
+type RuleUsecase interface {
+	CreateRule(req *request.RuleRequest, claims map[string]interface{}) error
+	GetAllRules() ([]response.RuleResponse, error)
+	UpdateRuleByID(ruleID uint, req *request.RuleRequest, claims map[string]interface{}) error
+	DeleteRuleByID(ruleID uint, claims map[string]interface{}) error
+	EvaluateRule(userID uint, year uint) (*response.RuleEvaluation, error)
+}
+
+type ruleUsecase struct {
+	ruleRepo    repository.RuleRepository
+	userRepo    repository.UserRepository
+	facultyRepo repository.FacultyBranchRepository
+	audit       auditTrail
+}
+
+func NewRuleUsecase(ruleRepo repository.RuleRepository, userRepo repository.UserRepository, facultyRepo repository.FacultyBranchRepository, auditRepo repository.AuditRepository) RuleUsecase {
+	return &ruleUsecase{
+		ruleRepo:    ruleRepo,
+		userRepo:    userRepo,
+		facultyRepo: facultyRepo,
+		audit:       auditTrail{repo: auditRepo},
+	}
+}
+
+func mapRuleResponse(rule entity.ActivityRule) (*response.RuleResponse, error) {
+	categories, err := decodeCategories(rule.RequiredCategories)
+	if err != nil {
+		return nil, err
+	}
+	return &response.RuleResponse{
+		RuleID:             rule.RuleID,
+		FacultyID:          rule.FacultyID,
+		BranchID:           rule.BranchID,
+		SchoolYear:         rule.SchoolYear,
+		MinInsideHour:      rule.MinInsideHour,
+		MinTotalHour:       rule.MinTotalHour,
+		MaxOutsideHour:     rule.MaxOutsideHour,
+		RequiredCategories: categories,
+	}, nil
+}
+
+func decodeCategories(data string) ([]string, error) {
+	categories := []string{}
+	if data == "" || data == "null" {
+		return categories, nil
+	}
+	if err := json.Unmarshal([]byte(data), &categories); err != nil {
+		return nil, fmt.Errorf("invalid required categories: %w", err)
+	}
+	return categories, nil
+}
+
+// ค่า 0 ถือว่าไม่ได้ระบุ (ใช้กับทุกค่า) เหมือนกับ SuperUser ของ Faculty
+func nilIfZero(v *uint) *uint {
+	if v != nil && *v == 0 {
+		return nil
+	}
+	return v
+}
+
+func (u *ruleUsecase) buildRule(req *request.RuleRequest) (*entity.ActivityRule, error) {
+	if req.MinTotalHour < req.MinInsideHour {
+		return nil, fmt.Errorf("min_total_hour must not be less than min_inside_hour")
+	}
+
+	branchID := nilIfZero(req.BranchID)
+	if branchID != nil {
+		exists, err := u.facultyRepo.BranchExists(*branchID)
+		if err != nil {
+			return nil, fmt.Errorf("error checking branch: %v", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("branch with ID %d does not exist", *branchID)
+		}
+	}
+
+	categories := req.RequiredCategories
+	if categories == nil {
+		categories = []string{}
+	}
+	categoryData, err := json.Marshal(categories)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ActivityRule{
+		FacultyID:          nilIfZero(req.FacultyID),
+		BranchID:           branchID,
+		SchoolYear:         nilIfZero(req.SchoolYear),
+		MinInsideHour:      req.MinInsideHour,
+		MinTotalHour:       req.MinTotalHour,
+		MaxOutsideHour:     req.MaxOutsideHour,
+		RequiredCategories: string(categoryData),
+	}, nil
+}
+
+func (u *ruleUsecase) CreateRule(req *request.RuleRequest, claims map[string]interface{}) error {
+	rule, err := u.buildRule(req)
+	if err != nil {
+		return err
+	}
+	if err := u.ruleRepo.CreateRule(rule); err != nil {
+		return err
+	}
+	u.audit.record(claims, "rule.create", "rule", rule.RuleID, nil, u.ruleSnapshot(rule))
+	return nil
+}
+
+// ค่าเกณฑ์ที่เก็บใน audit log (nil ถ้าไม่มีเกณฑ์)
+func (u *ruleUsecase) ruleSnapshot(rule *entity.ActivityRule) interface{} {
+	if rule == nil {
+		return nil
+	}
+	mapped, err := mapRuleResponse(*rule)
+	if err != nil {
+		return nil
+	}
+	return mapped
+}
+
+func (u *ruleUsecase) GetAllRules() ([]response.RuleResponse, error) {
+	rules, err := u.ruleRepo.GetAllRules()
+	if err != nil {
+		return nil, err
+	}
+	var res []response.RuleResponse
+	for _, rule := range rules {
+		mapped, err := mapRuleResponse(rule)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *mapped)
+	}
+	return res, nil
+}
+
+func (u *ruleUsecase) UpdateRuleByID(ruleID uint, req *request.RuleRequest, claims map[string]interface{}) error {
+	rule, err := u.buildRule(req)
+	if err != nil {
+		return err
+	}
+	rule.RuleID = ruleID
+	before, _ := u.ruleRepo.GetRuleByID(ruleID)
+	if err := u.ruleRepo.UpdateRuleByID(rule); err != nil {
+		return err
+	}
+	u.audit.record(claims, "rule.update", "rule", ruleID, u.ruleSnapshot(before), u.ruleSnapshot(rule))
+	return nil
+}
+
+func (u *ruleUsecase) DeleteRuleByID(ruleID uint, claims map[string]interface{}) error {
+	before, _ := u.ruleRepo.GetRuleByID(ruleID)
+	if err := u.ruleRepo.DeleteRuleByID(ruleID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "rule.delete", "rule", ruleID, u.ruleSnapshot(before), nil)
+	return nil
+}
+
+func (u *ruleUsecase) EvaluateRule(userID uint, year uint) (*response.RuleEvaluation, error) {
+	return evaluateActivityRule(u.ruleRepo, u.userRepo, userID, year)
+}
+
+// เลือกเกณฑ์ที่เฉพาะเจาะจงที่สุด: สาขา > คณะ > ปีการศึกษา ถ้าเท่ากันใช้เกณฑ์ที่สร้างล่าสุด
+func pickApplicableRule(rules []entity.ActivityRule) entity.ActivityRule {
+	best := defaultActivityRule
+	bestScore := -1
+	for _, rule := range rules {
+		score := 0
+		if rule.BranchID != nil {
+			score += 4
+		}
+		if rule.FacultyID != nil {
+			score += 2
+		}
+		if rule.SchoolYear != nil {
+			score += 1
+		}
+		if score > bestScore || (score == bestScore && rule.RuleID > best.RuleID) {
+			best = rule
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func evaluateActivityRule(ruleRepo repository.RuleRepository, userRepo repository.UserRepository, userID uint, year uint) (*response.RuleEvaluation, error) {
+	student, err := userRepo.GetStudentByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("student not found")
+	}
+
+	candidates, err := ruleRepo.FindCandidateRules(student.Branch.FacultyId, student.BranchId, year)
+	if err != nil {
+		return nil, err
+	}
+	rule := pickApplicableRule(candidates)
+
+	outsideHour, insideHour, err := userRepo.GetTotalWorkingHours(userID, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get total working hours: %v", err)
+	}
+	completed, err := userRepo.GetCompletedCategories(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	return applyActivityRule(rule, year, insideHour, outsideHour, completed)
+}
+
+// ตรวจชั่วโมงและประเภทกิจกรรมที่ทำแล้วกับเกณฑ์
+func applyActivityRule(rule entity.ActivityRule, year uint, insideHour uint, outsideHour uint, completed []string) (*response.RuleEvaluation, error) {
+	// ชั่วโมงกิจกรรมภายนอกนับได้ไม่เกินที่เกณฑ์กำหนด
+	countedOutside := outsideHour
+	if rule.MaxOutsideHour != nil && countedOutside > *rule.MaxOutsideHour {
+		countedOutside = *rule.MaxOutsideHour
+	}
+	total := insideHour + countedOutside
+
+	required, err := decodeCategories(rule.RequiredCategories)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(completed))
+	for _, c := range completed {
+		done[c] = true
+	}
+	missing := []string{}
+	for _, c := range required {
+		if !done[c] {
+			missing = append(missing, c)
+		}
+	}
+
+	criteria := []response.RuleCriterion{
+		{Name: "min_inside_hour", Required: rule.MinInsideHour, Actual: insideHour, Met: insideHour >= rule.MinInsideHour},
+		{Name: "min_total_hour", Required: rule.MinTotalHour, Actual: total, Met: total >= rule.MinTotalHour},
+		{Name: "required_categories", Required: uint(len(required)), Actual: uint(len(required) - len(missing)), Met: len(missing) == 0},
+	}
+	passed := true
+	for _, c := range criteria {
+		passed = passed && c.Met
+	}
+
+	return &response.RuleEvaluation{
+		RuleID:             rule.RuleID,
+		SchoolYear:         year,
+		InsideHour:         insideHour,
+		OutsideHour:        outsideHour,
+		CountedOutsideHour: countedOutside,
+		TotalHour:          total,
+		Criteria:           criteria,
+		MissingCategories:  missing,
+		Passed:             passed,
+	}, nil
+}
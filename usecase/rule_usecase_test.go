@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go-clean-arch/structure/entity"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+// TestPickApplicableRule tests how the most specific rule is chosen
+func TestPickApplicableRule(t *testing.T) {
+	t.Run("No rules falls back to default", func(t *testing.T) {
+		rule := pickApplicableRule(nil)
+		assert.Equal(t, uint(18), rule.MinInsideHour)
+		assert.Equal(t, uint(36), rule.MinTotalHour)
+	})
+
+	t.Run("Branch rule wins over faculty and year rules", func(t *testing.T) {
+		rules := []entity.ActivityRule{
+			{RuleID: 1, SchoolYear: uintPtr(2568), MinInsideHour: 10},
+			{RuleID: 2, FacultyID: uintPtr(1), SchoolYear: uintPtr(2568), MinInsideHour: 20},
+			{RuleID: 3, BranchID: uintPtr(5), MinInsideHour: 30},
+		}
+		assert.Equal(t, uint(3), pickApplicableRule(rules).RuleID)
+	})
+
+	t.Run("Newest rule wins on equal specificity", func(t *testing.T) {
+		rules := []entity.ActivityRule{
+			{RuleID: 4, FacultyID: uintPtr(1)},
+			{RuleID: 7, FacultyID: uintPtr(1)},
+		}
+		assert.Equal(t, uint(7), pickApplicableRule(rules).RuleID)
+	})
+}
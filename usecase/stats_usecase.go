@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"go-clean-arch/structure/response"
+	"math"
+)
+
+type StatsUsecase interface {
+	GetStats(filter request.StatsFilter, claims map[string]interface{}) (*response.Stats, error)
+}
+
+type statsUsecase struct {
+	statsRepo   repository.StatsRepository
+	ruleRepo    repository.RuleRepository
+	facultyRepo repository.FacultyBranchRepository
+}
+
+func NewStatsUsecase(statsRepo repository.StatsRepository, ruleRepo repository.RuleRepository, facultyRepo repository.FacultyBranchRepository) StatsUsecase {
+	return &statsUsecase{
+		statsRepo:   statsRepo,
+		ruleRepo:    ruleRepo,
+		facultyRepo: facultyRepo,
+	}
+}
+
+// กุญแจของกลุ่มตาม group_by
+type statsKey struct {
+	facultyID uint
+	branchID  uint
+	year      uint
+}
+
+func newStatsKey(groupBy string, facultyID uint, branchID uint, year uint) statsKey {
+	switch groupBy {
+	case "faculty":
+		return statsKey{facultyID: facultyID}
+	case "branch":
+		return statsKey{facultyID: facultyID, branchID: branchID}
+	default:
+		return statsKey{facultyID: facultyID, branchID: branchID, year: year}
+	}
+}
+
+// แอดมินดูได้ทุกคณะ ผู้ดูแลคณะ (super user) ดูได้เฉพาะคณะของตนเอง
+func (u *statsUsecase) restrictScope(filter *request.StatsFilter, claims map[string]interface{}) error {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	role, _ := claims["role"].(string)
+	if role == "admin" || role == "superadmin" {
+		return nil
+	}
+
+	faculties, err := u.facultyRepo.GetFacultiesBySuperUser(uint(userIDFloat))
+	if err != nil {
+		return err
+	}
+	if len(faculties) == 0 {
+		return fmt.Errorf("you do not have permission to view statistics")
+	}
+	filter.FacultyIDs = make([]uint, 0, len(faculties))
+	allowed := false
+	for _, faculty := range faculties {
+		filter.FacultyIDs = append(filter.FacultyIDs, faculty.FacultyID)
+		allowed = allowed || faculty.FacultyID == filter.FacultyID
+	}
+	if filter.FacultyID != 0 && !allowed {
+		return fmt.Errorf("you do not have permission to view statistics of this faculty")
+	}
+	return nil
+}
+
+func (u *statsUsecase) GetStats(filter request.StatsFilter, claims map[string]interface{}) (*response.Stats, error) {
+	if filter.SchoolYear == 0 {
+		return nil, fmt.Errorf("school_year is required")
+	}
+	if filter.GroupBy == "" {
+		filter.GroupBy = "branch"
+	}
+	if filter.GroupBy != "faculty" && filter.GroupBy != "branch" && filter.GroupBy != "year" {
+		return nil, fmt.Errorf("group_by must be faculty, branch or year")
+	}
+	if err := u.restrictScope(&filter, claims); err != nil {
+		return nil, err
+	}
+
+	groups, err := u.statsRepo.GroupStats(filter)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[statsKey]*response.StatsGroup, len(groups))
+	for i := range groups {
+		group := &groups[i]
+		group.AvgInsideHour = round2(group.AvgInsideHour)
+		group.AvgOutsideHour = round2(group.AvgOutsideHour)
+		if group.Students > 0 {
+			group.ParticipationRate = round2(float64(group.ParticipatingStudents) * 100 / float64(group.Students))
+		}
+		index[newStatsKey(filter.GroupBy, group.FacultyID, group.BranchID, group.Year)] = group
+	}
+
+	dones, err := u.statsRepo.DoneStats(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, done := range dones {
+		if group, ok := index[newStatsKey(filter.GroupBy, done.FacultyID, done.BranchID, done.Year)]; ok {
+			group.DonePending = done.DonePending
+			group.DoneApproved = done.DoneApproved
+			group.DoneRejected = done.DoneRejected
+			group.DoneResubmission = done.DoneResubmission
+		}
+	}
+
+	if err := u.countMetThreshold(filter, index); err != nil {
+		return nil, err
+	}
+
+	return &response.Stats{
+		SchoolYear: filter.SchoolYear,
+		GroupBy:    filter.GroupBy,
+		Groups:     groups,
+	}, nil
+}
+
+// นับนักศึกษาที่ผ่านเกณฑ์ส่งผล (เกณฑ์เดียวกับ SendEvent) ของแต่ละกลุ่ม
+func (u *statsUsecase) countMetThreshold(filter request.StatsFilter, index map[statsKey]*response.StatsGroup) error {
+	hours, err := u.statsRepo.StudentHours(filter)
+	if err != nil {
+		return err
+	}
+	categories, err := u.statsRepo.CompletedCategories(filter)
+	if err != nil {
+		return err
+	}
+	completed := make(map[uint][]string)
+	for _, c := range categories {
+		completed[c.UserID] = append(completed[c.UserID], c.Category)
+	}
+	rules, err := u.ruleRepo.GetAllRules()
+	if err != nil {
+		return err
+	}
+
+	// เกณฑ์ขึ้นกับคณะ/สาขา/ปีการศึกษา จึงเลือกครั้งเดียวต่อสาขา
+	branchRules := make(map[uint]entity.ActivityRule)
+	for _, student := range hours {
+		rule, ok := branchRules[student.BranchID]
+		if !ok {
+			rule = pickApplicableRule(candidateRules(rules, student.FacultyID, student.BranchID, filter.SchoolYear))
+			branchRules[student.BranchID] = rule
+		}
+		evaluation, err := applyActivityRule(rule, filter.SchoolYear, student.InsideHour, student.OutsideHour, completed[student.UserID])
+		if err != nil {
+			return err
+		}
+		if !evaluation.Passed {
+			continue
+		}
+		if group, ok := index[newStatsKey(filter.GroupBy, student.FacultyID, student.BranchID, student.Year)]; ok {
+			group.MetThreshold++
+		}
+	}
+	return nil
+}
+
+// เงื่อนไขเดียวกับ RuleRepository.FindCandidateRules
+func candidateRules(rules []entity.ActivityRule, facultyID uint, branchID uint, schoolYear uint) []entity.ActivityRule {
+	var candidates []entity.ActivityRule
+	for _, rule := range rules {
+		if rule.FacultyID != nil && *rule.FacultyID != facultyID {
+			continue
+		}
+		if rule.BranchID != nil && *rule.BranchID != branchID {
+			continue
+		}
+		if rule.SchoolYear != nil && *rule.SchoolYear != schoolYear {
+			continue
+		}
+		candidates = append(candidates, rule)
+	}
+	return candidates
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/request"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// คณะ 1 มีสาขา 1 (นักศึกษา 101, 102 ปี 1 และ 103 ปี 2) และสาขา 2 (นักศึกษา 201 ปี 1)
+// คณะ 2 (ผู้ดูแลคือ user 9) มีสาขา 3 (นักศึกษา 301)
+func newStatsDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:stats%d?mode=memory&cache=shared", atomic.AddInt64(&eventListDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.Faculty{}, &entity.Branch{}, &entity.Student{}, &entity.Event{},
+		&entity.EventInside{}, &entity.EventOutside{}, &entity.Done{}, &entity.ActivityRule{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	superUser := uint(9)
+	seed := []interface{}{
+		&entity.Faculty{FacultyID: 1, FacultyCode: "SCI", FacultyName: "Science"},
+		&entity.Faculty{FacultyID: 2, FacultyCode: "ENG", FacultyName: "Engineering", SuperUser: &superUser},
+		&entity.Branch{BranchID: 1, BranchCode: "CS", BranchName: "Computer", FacultyId: 1},
+		&entity.Branch{BranchID: 2, BranchCode: "MA", BranchName: "Math", FacultyId: 1},
+		&entity.Branch{BranchID: 3, BranchCode: "CE", BranchName: "Civil", FacultyId: 2},
+	}
+	for i, s := range []struct{ id, year, branch uint }{{101, 1, 1}, {102, 1, 1}, {103, 2, 1}, {201, 1, 2}, {301, 1, 3}} {
+		seed = append(seed, &entity.Student{UserID: s.id, TitleName: "นาย", FirstName: "a", LastName: "b",
+			Phone: fmt.Sprint(i), Code: fmt.Sprint(s.id), Year: s.year, BranchId: s.branch})
+	}
+	for _, row := range seed {
+		if err := db.Omit("Teacher", "Faculty", "Branch").Create(row).Error; err != nil {
+			t.Fatalf("failed to seed: %v", err)
+		}
+	}
+
+	for i, hour := range []uint{10, 8} {
+		event := entity.Event{EventID: uint(i + 1), EventName: "event", Creator: 1, StartDate: time.Now(),
+			SchoolYear: 2567, WorkingHour: hour, Location: "hall"}
+		if err := db.Omit("Teacher").Create(&event).Error; err != nil {
+			t.Fatalf("failed to seed event: %v", err)
+		}
+	}
+	insides := []entity.EventInside{
+		{EventId: 1, User: 101, State: entity.StateApproved},
+		{EventId: 2, User: 101, State: entity.StateApproved},
+		{EventId: 1, User: 102, State: entity.StateEvidenceSubmitted},
+		{EventId: 1, User: 301, State: entity.StateApproved},
+	}
+	for _, inside := range insides {
+		if err := db.Omit("Event", "Student", "Teacher", "Certifier").Create(&inside).Error; err != nil {
+			t.Fatalf("failed to seed inside: %v", err)
+		}
+	}
+	// กิจกรรมภายนอกที่ยังไม่อนุมัติไม่นับชั่วโมง
+	outsides := []entity.EventOutside{
+		{User: 101, EventName: "out", SchoolYear: 2567, StartDate: time.Now(), Intendant: "x", WorkingHour: 20, Location: "x", State: entity.StateApproved},
+		{User: 102, EventName: "out", SchoolYear: 2567, StartDate: time.Now(), Intendant: "x", WorkingHour: 40, Location: "x", State: entity.StateEvidenceSubmitted},
+	}
+	for _, outside := range outsides {
+		if err := db.Omit("Student", "Teacher", "Certifier").Create(&outside).Error; err != nil {
+			t.Fatalf("failed to seed outside: %v", err)
+		}
+	}
+	dones := []entity.Done{
+		{User: 101, Year: 2567, State: entity.StateApproved},
+		{User: 102, Year: 2567},
+		{User: 103, Year: 2567, State: entity.StateRejected, Comment: "ไม่ครบ"},
+	}
+	for _, done := range dones {
+		if err := db.Omit("Student", "Teacher", "Certifier").Create(&done).Error; err != nil {
+			t.Fatalf("failed to seed done: %v", err)
+		}
+	}
+	return db
+}
+
+// TestGetStats tests grouped statistics and access scope
+func TestGetStats(t *testing.T) {
+	db := newStatsDB(t)
+	u := NewStatsUsecase(repository.NewStatsRepository(db), repository.NewRuleRepository(db), repository.NewFacultyRepositiry(db))
+	admin := map[string]interface{}{"user_id": float64(1), "role": "admin"}
+
+	t.Run("Group by branch", func(t *testing.T) {
+		stats, err := u.GetStats(request.StatsFilter{SchoolYear: 2567}, admin)
+		assert.NoError(t, err)
+		assert.Len(t, stats.Groups, 3)
+
+		cs := stats.Groups[0]
+		assert.Equal(t, "Computer", cs.BranchName)
+		assert.Equal(t, int64(3), cs.Students)
+		assert.Equal(t, 6.0, cs.AvgInsideHour)     // (18 + 0 + 0) / 3
+		assert.Equal(t, 6.67, cs.AvgOutsideHour)   // 20 / 3
+		assert.Equal(t, int64(1), cs.MetThreshold) // 101: ภายใน 18 รวม 38 ผ่านเกณฑ์เริ่มต้น
+		assert.Equal(t, int64(2), cs.ParticipatingStudents)
+		assert.Equal(t, 66.67, cs.ParticipationRate)
+		assert.Equal(t, int64(1), cs.DoneApproved)
+		assert.Equal(t, int64(1), cs.DonePending)
+		assert.Equal(t, int64(1), cs.DoneRejected)
+
+		assert.Equal(t, "Math", stats.Groups[1].BranchName)
+		assert.Equal(t, int64(0), stats.Groups[1].ParticipatingStudents)
+	})
+
+	t.Run("Group by year", func(t *testing.T) {
+		stats, err := u.GetStats(request.StatsFilter{SchoolYear: 2567, FacultyID: 1, GroupBy: "year"}, admin)
+		assert.NoError(t, err)
+		assert.Len(t, stats.Groups, 3)
+		assert.Equal(t, uint(1), stats.Groups[0].Year)
+		assert.Equal(t, int64(2), stats.Groups[0].Students)
+		assert.Equal(t, uint(2), stats.Groups[1].Year)
+		assert.Equal(t, int64(1), stats.Groups[1].DoneRejected)
+	})
+
+	t.Run("Faculty super user sees only own faculty", func(t *testing.T) {
+		superUser := map[string]interface{}{"user_id": float64(9), "role": "teacher"}
+		stats, err := u.GetStats(request.StatsFilter{SchoolYear: 2567, GroupBy: "faculty"}, superUser)
+		assert.NoError(t, err)
+		assert.Len(t, stats.Groups, 1)
+		assert.Equal(t, "Engineering", stats.Groups[0].FacultyName)
+
+		_, err = u.GetStats(request.StatsFilter{SchoolYear: 2567, FacultyID: 1}, superUser)
+		assert.Error(t, err)
+
+		_, err = u.GetStats(request.StatsFilter{SchoolYear: 2567}, map[string]interface{}{"user_id": float64(5), "role": "teacher"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-clean-arch/pkg/hash"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+	"io"
+	"net/mail"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	maxImportRows       = 5000
+	importBatchSize     = 100
+	initialPasswordSize = 10
+)
+
+// คอลัมน์ที่ต้องมีในไฟล์ CSV (ลำดับใดก็ได้)
+var importColumns = []string{"email", "code", "title_name", "first_name", "last_name", "phone", "year", "branch_code"}
+
+type importRecord struct {
+	row        response.ImportRow
+	student    entity.Student
+	branchCode string
+}
+
+// นำเข้านักศึกษาจาก CSV: ตรวจทุกแถวก่อน แล้วสร้างเฉพาะแถวที่ถูกต้องเป็นชุดละ importBatchSize
+// dryRun = true ตรวจสอบอย่างเดียวไม่บันทึก
+func (u *userUsecase) ImportStudents(content []byte, dryRun bool, claims map[string]interface{}) (*response.ImportResult, error) {
+	records, err := u.parseImportCSV(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.validateImport(records); err != nil {
+		return nil, err
+	}
+
+	result := &response.ImportResult{DryRun: dryRun, Total: len(records)}
+	var valid []*importRecord
+	for _, record := range records {
+		if len(record.row.Errors) == 0 {
+			valid = append(valid, record)
+		}
+	}
+
+	if !dryRun {
+		for start := 0; start < len(valid); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(valid) {
+				end = len(valid)
+			}
+			u.createImportBatch(valid[start:end])
+		}
+	}
+
+	for _, record := range records {
+		if len(record.row.Errors) > 0 {
+			result.Failed++
+		} else if !dryRun {
+			result.Created++
+		}
+		result.Rows = append(result.Rows, record.row)
+	}
+
+	if !dryRun && result.Created > 0 {
+		codes := make([]string, 0, result.Created)
+		for _, record := range valid {
+			if len(record.row.Errors) == 0 {
+				codes = append(codes, record.student.Code)
+			}
+		}
+		u.audit.record(claims, "student.import", "student", "", nil,
+			map[string]interface{}{"created": result.Created, "failed": result.Failed, "codes": codes})
+	}
+	return result, nil
+}
+
+func (u *userUsecase) parseImportCSV(content []byte) ([]*importRecord, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: missing header")
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, column := range importColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("invalid CSV: missing column %q", column)
+		}
+	}
+
+	var records []*importRecord
+	for line := 2; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV at line %d: %v", line, err)
+		}
+		if len(records) >= maxImportRows {
+			return nil, fmt.Errorf("CSV exceeds the %d rows limit", maxImportRows)
+		}
+		value := func(column string) string {
+			if i := index[column]; i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		record := &importRecord{
+			row: response.ImportRow{Line: line, Email: strings.ToLower(value("email")), Code: value("code")},
+			student: entity.Student{
+				TitleName: value("title_name"),
+				FirstName: value("first_name"),
+				LastName:  value("last_name"),
+				Phone:     value("phone"),
+				Code:      value("code"),
+			},
+		}
+		for _, column := range []string{"email", "code", "first_name", "last_name", "phone", "year", "branch_code"} {
+			if value(column) == "" {
+				record.addError("%s is required", column)
+			}
+		}
+		if record.row.Email != "" {
+			if _, err := mail.ParseAddress(record.row.Email); err != nil {
+				record.addError("invalid email")
+			}
+		}
+		if yearStr := value("year"); yearStr != "" {
+			year, err := strconv.Atoi(yearStr)
+			if err != nil || year < 1 || year > 8 {
+				record.addError("year must be between 1 and 8")
+			}
+			record.student.Year = uint(year)
+		}
+		record.branchCode = value("branch_code")
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV has no rows")
+	}
+	return records, nil
+}
+
+func (r *importRecord) addError(format string, args ...interface{}) {
+	r.row.Errors = append(r.row.Errors, fmt.Sprintf(format, args...))
+}
+
+// ตรวจรหัสสาขา และข้อมูลที่ซ้ำกันภายในไฟล์หรือซ้ำกับในระบบ
+func (u *userUsecase) validateImport(records []*importRecord) error {
+	branches, err := u.facultyRepo.GetAllBranches()
+	if err != nil {
+		return err
+	}
+	branchIDs := make(map[string]uint, len(branches))
+	for _, branch := range branches {
+		branchIDs[strings.ToLower(branch.BranchCode)] = branch.BranchID
+	}
+
+	var emails, codes, phones []string
+	for _, record := range records {
+		emails = append(emails, record.row.Email)
+		codes = append(codes, record.student.Code)
+		phones = append(phones, record.student.Phone)
+	}
+	existingEmails, err := u.userRepo.ExistingEmails(emails)
+	if err != nil {
+		return err
+	}
+	existingStudents, err := u.userRepo.ExistingStudents(codes, phones)
+	if err != nil {
+		return err
+	}
+	taken := map[string]bool{}
+	for _, email := range existingEmails {
+		taken["email:"+strings.ToLower(email)] = true
+	}
+	for _, student := range existingStudents {
+		taken["code:"+student.Code] = true
+		taken["phone:"+student.Phone] = true
+	}
+
+	seen := map[string]int{}
+	for _, record := range records {
+		if record.branchCode != "" {
+			branchID, ok := branchIDs[strings.ToLower(record.branchCode)]
+			if !ok {
+				record.addError("unknown branch_code %q", record.branchCode)
+			}
+			record.student.BranchId = branchID
+		}
+		for _, field := range []struct{ name, value string }{
+			{"email", record.row.Email},
+			{"code", record.student.Code},
+			{"phone", record.student.Phone},
+		} {
+			if field.value == "" {
+				continue
+			}
+			key := field.name + ":" + field.value
+			if taken[key] {
+				record.addError("%s %s already exists", field.name, field.value)
+			}
+			if line, ok := seen[key]; ok {
+				record.addError("%s %s duplicates line %d", field.name, field.value, line)
+			} else {
+				seen[key] = record.row.Line
+			}
+		}
+	}
+	return nil
+}
+
+// สร้างบัญชีของแถวที่ถูกต้องหนึ่งชุด ถ้าบันทึกไม่สำเร็จทั้งชุดถือว่าล้มเหลว
+func (u *userUsecase) createImportBatch(batch []*importRecord) {
+	passwords, hashed, err := generateImportPasswords(len(batch))
+	if err != nil {
+		failImportBatch(batch, err)
+		return
+	}
+	users := make([]entity.User, 0, len(batch))
+	students := make([]entity.Student, 0, len(batch))
+	for i, record := range batch {
+		users = append(users, entity.User{Email: record.row.Email, Password: hashed[i], Role: "student"})
+		students = append(students, record.student)
+	}
+
+	if err := u.userRepo.CreateStudents(users, students); err != nil {
+		failImportBatch(batch, err)
+		return
+	}
+	for i, record := range batch {
+		record.row.Password = passwords[i]
+	}
+}
+
+// bcrypt ใช้ CPU มาก จึงสร้างรหัสผ่านเริ่มต้นและแฮชพร้อมกันไม่เกินจำนวน CPU
+func generateImportPasswords(n int) ([]string, []string, error) {
+	passwords := make([]string, n)
+	hashed := make([]string, n)
+	errs := make([]error, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU() && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				passwords[i], errs[i] = hash.GeneratePassword(initialPasswordSize)
+				if errs[i] == nil {
+					hashed[i], errs[i] = hash.HashPassword(passwords[i])
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return passwords, hashed, nil
+}
+
+func failImportBatch(batch []*importRecord, err error) {
+	for _, record := range batch {
+		record.addError("failed to create student: %v", err)
+	}
+}
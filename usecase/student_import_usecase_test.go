@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/hash"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newImportUsecase(t *testing.T) (*userUsecase, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:import%d?mode=memory&cache=shared", atomic.AddInt64(&eventListDBSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.Teacher{}, &entity.Faculty{}, &entity.Branch{}, &entity.Student{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	seed := []interface{}{
+		&entity.Faculty{FacultyID: 1, FacultyCode: "SCI", FacultyName: "Science"},
+		&entity.Branch{BranchID: 1, BranchCode: "CS", BranchName: "Computer", FacultyId: 1},
+		&entity.User{UserID: 1, Email: "old@example.com", Password: "x", Role: "student"},
+		&entity.Student{UserID: 1, TitleName: "นาย", FirstName: "a", LastName: "b", Phone: "0800000001", Code: "6500", Year: 1, BranchId: 1},
+	}
+	for _, row := range seed {
+		if err := db.Omit("Teacher", "Faculty", "Branch", "Student").Create(row).Error; err != nil {
+			t.Fatalf("failed to seed: %v", err)
+		}
+	}
+	u := &userUsecase{userRepo: repository.NewUserRepository(db), facultyRepo: repository.NewFacultyRepositiry(db)}
+	return u, db
+}
+
+const importCSV = "\xEF\xBB\xBFemail,code,title_name,first_name,last_name,phone,year,branch_code\n" +
+	"new@example.com,6501,นาย,สมชาย,ใจดี,0800000002,1,cs\n" +
+	"old@example.com,6502,นาย,สมศักดิ์,ใจดี,0800000003,1,CS\n" +
+	"other@example.com,6501,นาง,สมศรี,ใจดี,0800000004,9,EE\n"
+
+// TestImportStudents tests CSV validation, dry-run and batch creation
+func TestImportStudents(t *testing.T) {
+	t.Run("Rejects CSV without required columns", func(t *testing.T) {
+		u, _ := newImportUsecase(t)
+		_, err := u.ImportStudents([]byte("email,code\na@example.com,1\n"), true, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Dry run reports errors without saving", func(t *testing.T) {
+		u, db := newImportUsecase(t)
+		result, err := u.ImportStudents([]byte(importCSV), true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.Total)
+		assert.Equal(t, 0, result.Created)
+		assert.Equal(t, 2, result.Failed)
+
+		assert.Empty(t, result.Rows[0].Errors)
+		assert.Empty(t, result.Rows[0].Password)
+		assert.Equal(t, []string{"email old@example.com already exists"}, result.Rows[1].Errors)
+		assert.Equal(t, 4, result.Rows[2].Line)
+		assert.ElementsMatch(t, []string{
+			"year must be between 1 and 8",
+			`unknown branch_code "EE"`,
+			"code 6501 duplicates line 2",
+		}, result.Rows[2].Errors)
+
+		var count int64
+		db.Model(&entity.Student{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Creates valid rows with initial passwords", func(t *testing.T) {
+		u, db := newImportUsecase(t)
+		result, err := u.ImportStudents([]byte(importCSV), false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Created)
+		assert.Len(t, result.Rows[0].Password, initialPasswordSize)
+		assert.Empty(t, result.Rows[1].Password)
+
+		var user entity.User
+		assert.NoError(t, db.Preload("Student").First(&user, "email = ?", "new@example.com").Error)
+		assert.True(t, hash.CheckPasswordHash(result.Rows[0].Password, user.Password))
+		assert.Equal(t, "student", user.Role)
+		assert.Equal(t, uint(1), user.Student.BranchId)
+		assert.Equal(t, "6501", user.Student.Code)
+	})
+
+	t.Run("Each row gets its own password when hashed in parallel", func(t *testing.T) {
+		u, db := newImportUsecase(t)
+		csvContent := "email,code,title_name,first_name,last_name,phone,year,branch_code\n"
+		for i := 0; i < 8; i++ {
+			csvContent += fmt.Sprintf("s%d@example.com,66%02d,นาย,a,b,08100000%02d,1,CS\n", i, i, i)
+		}
+		result, err := u.ImportStudents([]byte(csvContent), false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 8, result.Created)
+
+		seen := map[string]bool{}
+		for _, row := range result.Rows {
+			var user entity.User
+			assert.NoError(t, db.First(&user, "email = ?", row.Email).Error)
+			assert.True(t, hash.CheckPasswordHash(row.Password, user.Password), row.Email)
+			assert.False(t, seen[row.Password])
+			seen[row.Password] = true
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility/filesystem"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+)
+
+// ขนาดไฟล์แม่แบบสูงสุด
+const maxTemplateSize = 1 * 1024 * 1024
+
+// เลขเอกสารตัวอย่างสำหรับทดลองสร้างเอกสารตอนอัปโหลดแม่แบบ
+var sampleFormStamp = filesystem.FormStamp{
+	Serial:    "00000000-0000-0000-0000-000000000000",
+	VerifyURL: "https://example.com/verify/00000000-0000-0000-0000-000000000000",
+}
+
+type TemplateUsecase interface {
+	UploadTemplate(name string, schoolYear *uint, content []byte, claims map[string]interface{}) (*entity.DocumentTemplate, error)
+	GetAllTemplates() ([]entity.DocumentTemplate, error)
+	GetTemplateByID(templateID uint) (*entity.DocumentTemplate, error)
+	DeleteTemplateByID(templateID uint, claims map[string]interface{}) error
+}
+
+type templateUsecase struct {
+	templateRepo repository.TemplateRepository
+	audit        auditTrail
+}
+
+func NewTemplateUsecase(templateRepo repository.TemplateRepository, auditRepo repository.AuditRepository) TemplateUsecase {
+	return &templateUsecase{
+		templateRepo: templateRepo,
+		audit:        auditTrail{repo: auditRepo},
+	}
+}
+
+// ไม่เก็บเนื้อหาแม่แบบใน audit log เพราะมีขนาดใหญ่
+func templateAudit(template *entity.DocumentTemplate) interface{} {
+	if template == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":        template.Name,
+		"school_year": template.SchoolYear,
+		"uploaded_by": template.UploadedBy,
+	}
+}
+
+func (u *templateUsecase) UploadTemplate(name string, schoolYear *uint, content []byte, claims map[string]interface{}) (*entity.DocumentTemplate, error) {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid user_id in claims")
+	}
+
+	// รองรับเฉพาะเอกสารที่ระบบสร้างได้
+	if _, err := filesystem.DefaultTemplate(name); err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("template file is empty")
+	}
+	if len(content) > maxTemplateSize {
+		return nil, fmt.Errorf("template size exceeds the 1MB limit")
+	}
+
+	tpl, err := filesystem.ParseTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+	// ลองสร้างเอกสารด้วยข้อมูลตัวอย่าง เพื่อตรวจไฟล์รูปภาพ/ฟอนต์ก่อนบันทึก
+	if _, _, err := filesystem.CreatePDF(response.OutsideResponse{}, sampleFormStamp, tpl); err != nil {
+		return nil, fmt.Errorf("template cannot be rendered: %w", err)
+	}
+
+	template := entity.DocumentTemplate{
+		Name:       name,
+		SchoolYear: schoolYear,
+		Content:    string(content),
+		UploadedBy: uint(userIDFloat),
+	}
+	if err := u.templateRepo.CreateTemplate(&template); err != nil {
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+	u.audit.record(claims, "template.upload", "template", template.TemplateID, nil, templateAudit(&template))
+	return &template, nil
+}
+
+func (u *templateUsecase) GetAllTemplates() ([]entity.DocumentTemplate, error) {
+	return u.templateRepo.GetAllTemplates()
+}
+
+func (u *templateUsecase) GetTemplateByID(templateID uint) (*entity.DocumentTemplate, error) {
+	return u.templateRepo.GetTemplateByID(templateID)
+}
+
+func (u *templateUsecase) DeleteTemplateByID(templateID uint, claims map[string]interface{}) error {
+	before, _ := u.templateRepo.GetTemplateByID(templateID)
+	if err := u.templateRepo.DeleteTemplateByID(templateID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "template.delete", "template", templateID, templateAudit(before), nil)
+	return nil
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/pkg/utility/filesystem"
+	"go-clean-arch/structure/entity"
+	"go-clean-arch/structure/response"
+	"time"
+)
+
+func teacherFullName(teacher entity.Teacher) string {
+	if teacher.UserID == 0 {
+		return ""
+	}
+	return teacher.TitleName + teacher.FirstName + " " + teacher.LastName
+}
+
+// ใบรายงานผลดูได้เฉพาะตัวนักศึกษา แอดมิน ผู้ดูแลคณะ หรือผู้ตรวจรายการใดรายการหนึ่งของนักศึกษาในปีนั้น
+func (u *eventUsecase) authorizeTranscript(claims map[string]interface{}, userID uint, inside []entity.EventInside, outside []entity.EventOutside, done *entity.Done) error {
+	access, err := u.evidenceAccessFor(claims, userID)
+	if err != nil {
+		return err
+	}
+	if access.privileged {
+		return nil
+	}
+	for _, event := range inside {
+		if access.allows(event.Certifier) {
+			return nil
+		}
+	}
+	for _, event := range outside {
+		if access.allows(event.Certifier) {
+			return nil
+		}
+	}
+	if done != nil && access.allows(done.Certifier) {
+		return nil
+	}
+	return fmt.Errorf("you do not have permission to view this transcript")
+}
+
+// รวบรวมกิจกรรมภายใน/ภายนอก ชั่วโมงรวม และผลการตรวจสอบประจำปีของนักศึกษา
+func (u *eventUsecase) buildTranscript(userID uint, year uint, claims map[string]interface{}) (*response.Transcript, error) {
+	student, err := u.userRepo.GetStudentByID(userID)
+	if err != nil || student == nil {
+		return nil, fmt.Errorf("student not found")
+	}
+
+	inside, err := u.eventRepo.AllEventInsideThisYear(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	outside, err := u.eventRepo.AllEventOutsideThisYear(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	done, err := u.userRepo.GetDone(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.authorizeTranscript(claims, userID, inside, outside, done); err != nil {
+		return nil, err
+	}
+
+	transcript := &response.Transcript{
+		Student: response.StudentResponse{
+			UserID:      student.UserID,
+			TitleName:   student.TitleName,
+			FirstName:   student.FirstName,
+			LastName:    student.LastName,
+			Phone:       student.Phone,
+			Code:        student.Code,
+			Year:        student.Year,
+			BranchID:    student.BranchId,
+			BranchName:  student.Branch.BranchName,
+			FacultyID:   student.Branch.Faculty.FacultyID,
+			FacultyName: student.Branch.Faculty.FacultyName,
+		},
+		SchoolYear:  year,
+		Inside:      []response.TranscriptInside{},
+		Outside:     []response.TranscriptOutside{},
+		GeneratedAt: time.Now(),
+	}
+
+	for _, event := range inside {
+		transcript.Inside = append(transcript.Inside, response.TranscriptInside{
+			EventName:   event.Event.EventName,
+			StartDate:   event.Event.StartDate,
+			Location:    event.Event.Location,
+			WorkingHour: event.Event.WorkingHour,
+			Certifier:   teacherFullName(event.Teacher),
+			State:       string(event.State),
+			Comment:     event.Comment,
+		})
+		// นับเฉพาะกิจกรรมที่อนุมัติแล้ว เช่นเดียวกับ GetTotalWorkingHours
+		if event.State == entity.StateApproved {
+			transcript.InsideHour += event.Event.WorkingHour
+		}
+	}
+	for _, event := range outside {
+		transcript.Outside = append(transcript.Outside, response.TranscriptOutside{
+			EventName:   event.EventName,
+			StartDate:   event.StartDate,
+			Location:    event.Location,
+			WorkingHour: event.WorkingHour,
+			Intendant:   event.Intendant,
+			State:       string(event.State),
+		})
+		if event.State == entity.StateApproved {
+			transcript.OutsideHour += event.WorkingHour
+		}
+	}
+	transcript.TotalHour = transcript.InsideHour + transcript.OutsideHour
+
+	if done != nil {
+		transcript.Done = &response.TranscriptDone{
+			Certifier: teacherFullName(done.Teacher),
+			State:     string(done.State),
+			Comment:   done.Comment,
+		}
+	}
+	return transcript, nil
+}
+
+func (u *eventUsecase) CreateTranscript(userID uint, year uint, claims map[string]interface{}) ([]byte, string, error) {
+	transcript, err := u.buildTranscript(userID, year, claims)
+	if err != nil {
+		return nil, "", err
+	}
+	pdfBytes, fileName, err := filesystem.CreateTranscriptPDF(*transcript)
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating PDF: %v", err)
+	}
+	return pdfBytes, fileName, nil
+}
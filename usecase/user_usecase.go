@@ -1,41 +1,152 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"go-clean-arch/pkg/hash"
 	"go-clean-arch/pkg/jwt"
+	"go-clean-arch/pkg/mailer"
 	"go-clean-arch/repository"
 	"go-clean-arch/structure/entity"
 	"go-clean-arch/structure/request"
 	"go-clean-arch/structure/response"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type UserUsecase interface {
 	CreateTeacher(req *request.RegisterTeacher) error
 	CreateStudent(req *request.RegisterStudent) error
-	GetUserByEmail(email string, password string) (string, string, error)
+	ImportStudents(content []byte, dryRun bool, claims map[string]interface{}) (*response.ImportResult, error)
+	GetUserByEmail(email string, password string) (*response.AuthTokens, string, error)
+	RefreshToken(refreshToken string) (*response.AuthTokens, string, error)
+	Logout(claims map[string]interface{}) error
+	RevokeAllSessions(userID uint, claims map[string]interface{}) error
+	ChangePassword(claims map[string]interface{}, req *request.ChangePasswordRequest) error
+	ForgotPassword(email string) error
+	ResetPassword(req *request.ResetPasswordRequest) error
 
 	GetUserByClaims(claims map[string]interface{}) (interface{}, error)
 	GetAllTeacher() ([]response.TeacherResponse, error)
 	GetAllStudent() ([]response.StudentResponse, error)
-	SendEvent(year uint,claims map[string]interface{}) error
+	SendEvent(year uint,claims map[string]interface{}) (*response.RuleEvaluation, error)
 	GetStudentsAndYearsByCertifier(claims map[string]interface{}) ([]response.StudentYear,error)
 
 	UpdateTeacherByID(req *request.RegisterTeacher, claims map[string]interface{}) error
 	UpdateStudentByID(req *request.RegisterStudent, claims map[string]interface{}) error
-	UpdateRoleByID(userID uint, role string) error
-	UpdateStatusDones(certifierID uint, userID uint, status bool, comment string) error 
+	UpdateRoleByID(userID uint, role string, claims map[string]interface{}) error
+	UpdateStatusDones(certifierID uint, userID uint, state string, comment string, claims map[string]interface{}) error
+}
+
+// cache สถานะการเพิกถอน session ของ middleware ต้องล้างทุกครั้งที่เพิกถอน session
+type SessionCache interface {
+	Evict(sessionID string)
+	EvictAll()
 }
 
 type userUsecase struct {
-	userRepo repository.UserRepository
-	jwt      jwt.JWTService
+	userRepo     repository.UserRepository
+	ruleRepo     repository.RuleRepository
+	sessionRepo  repository.SessionRepository
+	sessionCache SessionCache
+	facultyRepo  repository.FacultyBranchRepository
+	mailer       mailer.Mailer
+	resetURL     string
+	jwt          jwt.JWTService
+	audit        auditTrail
 }
 
-func NewUserUsecase(userRepo repository.UserRepository, jwt jwt.JWTService) UserUsecase {
+func NewUserUsecase(userRepo repository.UserRepository, ruleRepo repository.RuleRepository, sessionRepo repository.SessionRepository, sessionCache SessionCache, facultyRepo repository.FacultyBranchRepository, auditRepo repository.AuditRepository, mailer mailer.Mailer, resetURL string, jwt jwt.JWTService) UserUsecase {
 	return &userUsecase{
-		userRepo: userRepo,
-		jwt:      jwt,
+		userRepo:     userRepo,
+		ruleRepo:     ruleRepo,
+		sessionRepo:  sessionRepo,
+		sessionCache: sessionCache,
+		facultyRepo:  facultyRepo,
+		mailer:       mailer,
+		resetURL:     resetURL,
+		jwt:          jwt,
+		audit:        auditTrail{repo: auditRepo},
+	}
+}
+
+func (u *userUsecase) revokeSession(sessionID string) error {
+	if err := u.sessionRepo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+	if u.sessionCache != nil {
+		u.sessionCache.Evict(sessionID)
+	}
+	return nil
+}
+
+// cache ไม่รู้ว่า session เป็นของใคร จึงล้างทั้งหมดเมื่อเพิกถอนหลาย session
+func (u *userUsecase) revokeAllSessions(userID uint) error {
+	if err := u.sessionRepo.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+	if u.sessionCache != nil {
+		u.sessionCache.EvictAll()
+	}
+	return nil
+}
+
+func (u *userUsecase) revokeOtherSessions(userID uint, keepSessionID string) error {
+	if err := u.sessionRepo.RevokeOtherSessions(userID, keepSessionID); err != nil {
+		return err
+	}
+	if u.sessionCache != nil {
+		u.sessionCache.EvictAll()
+	}
+	return nil
+}
+
+// claims ของผู้ใช้ที่ทำรายการเองโดยไม่มี token (สมัครสมาชิก/รีเซ็ตรหัสผ่าน)
+func selfClaims(userID uint, role string) map[string]interface{} {
+	return map[string]interface{}{"user_id": float64(userID), "role": role}
+}
+
+// ข้อมูลส่วนตัวที่เก็บใน audit log (ไม่รวมรหัสผ่าน)
+func teacherAudit(teacher *entity.Teacher) interface{} {
+	if teacher == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"title_name": teacher.TitleName,
+		"first_name": teacher.FirstName,
+		"last_name":  teacher.LastName,
+		"phone":      teacher.Phone,
+		"code":       teacher.Code,
+	}
+}
+
+func studentAudit(student *entity.Student) interface{} {
+	if student == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"title_name": student.TitleName,
+		"first_name": student.FirstName,
+		"last_name":  student.LastName,
+		"phone":      student.Phone,
+		"code":       student.Code,
+		"year":       student.Year,
+		"branch_id":  student.BranchId,
+	}
+}
+
+func doneAudit(done *entity.Done) interface{} {
+	if done == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"year":      done.Year,
+		"certifier": done.Certifier,
+		"state":     done.State,
+		"comment":   done.Comment,
 	}
 }
 
@@ -62,6 +173,8 @@ func (u *userUsecase) CreateTeacher(req *request.RegisterTeacher) error {
 	if err := u.userRepo.CreateTeacher(user, teacher); err != nil {
 		return fmt.Errorf("failed to create teacher: %w", err)
 	}
+	u.audit.record(selfClaims(user.UserID, user.Role), "user.register", "teacher", user.UserID, nil,
+		map[string]interface{}{"email": user.Email, "role": user.Role, "profile": teacherAudit(teacher)})
 
 	return nil
 }
@@ -91,24 +204,216 @@ func (u *userUsecase) CreateStudent(req *request.RegisterStudent) error {
 	if err := u.userRepo.CreateStudent(user, student); err != nil {
 		return fmt.Errorf("failed to create student: %w", err)
 	}
+	u.audit.record(selfClaims(user.UserID, user.Role), "user.register", "student", user.UserID, nil,
+		map[string]interface{}{"email": user.Email, "role": user.Role, "profile": studentAudit(student)})
 
 	return nil
 }
 
-func (u *userUsecase) GetUserByEmail(email string, password string) (string, string, error) {
+func (u *userUsecase) GetUserByEmail(email string, password string) (*response.AuthTokens, string, error) {
 	user, err := u.userRepo.GetUserByEmail(email)
 	if user == nil || err != nil {
-		return "", "", fmt.Errorf("invalid email or password")
+		return nil, "", fmt.Errorf("invalid email or password")
 	}
 	if !hash.CheckPasswordHash(password, user.Password) {
-		return "", "", fmt.Errorf("invalid email or password")
+		return nil, "", fmt.Errorf("invalid email or password")
 	}
-	token, err := u.jwt.GenerateJWT(user.UserID, user.Role)
+
+	// สร้าง session ใหม่ทุกครั้งที่เข้าสู่ระบบ
+	refreshToken, err := hash.GenerateRandomToken(32)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, "", err
+	}
+	session := &entity.Session{
+		SessionID:        uuid.New().String(),
+		UserID:           user.UserID,
+		RefreshTokenHash: hash.HashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(jwt.RefreshTokenTTL),
+	}
+	if err := u.sessionRepo.CreateSession(session); err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
 	}
 
-	return token, user.Role, nil
+	tokens, err := u.issueTokens(user, session, refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return tokens, user.Role, nil
+}
+
+func (u *userUsecase) issueTokens(user *entity.User, session *entity.Session, refreshToken string) (*response.AuthTokens, error) {
+	accessToken, err := u.jwt.GenerateAccessToken(user.UserID, user.Role, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &response.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  time.Now().Add(jwt.AccessTokenTTL),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+func (u *userUsecase) RefreshToken(refreshToken string) (*response.AuthTokens, string, error) {
+	if refreshToken == "" {
+		return nil, "", fmt.Errorf("missing refresh token")
+	}
+	tokenHash := hash.HashToken(refreshToken)
+
+	session, err := u.sessionRepo.GetSessionByTokenHash(tokenHash)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid refresh token")
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, "", fmt.Errorf("session expired or revoked")
+	}
+
+	// refresh token ที่ถูกหมุนไปแล้วถูกนำมาใช้ซ้ำ แสดงว่า token อาจรั่วไหล จึงเพิกถอนทั้ง session
+	if session.RefreshTokenHash != tokenHash {
+		if err := u.revokeSession(session.SessionID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("refresh token reuse detected, session revoked")
+	}
+
+	// ดึง role ล่าสุดจากฐานข้อมูล
+	user, err := u.userRepo.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, "", fmt.Errorf("user not found")
+	}
+
+	newRefreshToken, err := hash.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	session.ExpiresAt = time.Now().Add(jwt.RefreshTokenTTL)
+	if err := u.sessionRepo.RotateSession(session.SessionID, tokenHash, hash.HashToken(newRefreshToken), session.ExpiresAt); err != nil {
+		return nil, "", err
+	}
+
+	tokens, err := u.issueTokens(user, session, newRefreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return tokens, user.Role, nil
+}
+
+func (u *userUsecase) Logout(claims map[string]interface{}) error {
+	sessionID, ok := claims["sid"].(string)
+	if !ok || sessionID == "" {
+		return fmt.Errorf("invalid session in claims")
+	}
+	return u.revokeSession(sessionID)
+}
+
+func (u *userUsecase) RevokeAllSessions(userID uint, claims map[string]interface{}) error {
+	if err := u.revokeAllSessions(userID); err != nil {
+		return err
+	}
+	u.audit.record(claims, "session.revoke_all", "user", userID, nil, nil)
+	return nil
+}
+
+const (
+	minPasswordLength = 8
+	passwordResetTTL  = 30 * time.Minute
+)
+
+func validateNewPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	return nil
+}
+
+func (u *userUsecase) ChangePassword(claims map[string]interface{}, req *request.ChangePasswordRequest) error {
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid user_id in claims")
+	}
+	userID := uint(userIDFloat)
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user not found")
+	}
+	if !hash.CheckPasswordHash(req.OldPassword, user.Password) {
+		return fmt.Errorf("old password is incorrect")
+	}
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hash.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := u.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return err
+	}
+	u.audit.record(claims, "user.change_password", "user", userID, nil, nil)
+
+	// ออกจากระบบทุกเครื่อง ยกเว้น session ที่ใช้เปลี่ยนรหัสผ่าน
+	sessionID, _ := claims["sid"].(string)
+	return u.revokeOtherSessions(userID, sessionID)
+}
+
+func (u *userUsecase) ForgotPassword(email string) error {
+	// ไม่บอกว่าอีเมลมีอยู่ในระบบหรือไม่ ข้อผิดพลาดที่เกิดเฉพาะกับอีเมลที่มีอยู่จึงบันทึก log แทนการตอบกลับ
+	user, err := u.userRepo.GetUserByEmail(email)
+	if err != nil || user == nil {
+		return nil
+	}
+
+	token, err := hash.GenerateRandomToken(32)
+	if err != nil {
+		log.Printf("failed to generate reset token for user %d: %v", user.UserID, err)
+		return nil
+	}
+	reset := &entity.PasswordReset{
+		TokenHash: hash.HashToken(token),
+		UserID:    user.UserID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := u.userRepo.CreatePasswordReset(reset); err != nil {
+		log.Printf("failed to create reset token for user %d: %v", user.UserID, err)
+		return nil
+	}
+
+	link := fmt.Sprintf("%s?token=%s", u.resetURL, url.QueryEscape(token))
+	body := fmt.Sprintf("มีการขอรีเซ็ตรหัสผ่านสำหรับบัญชี %s\nกรุณาเปิดลิงก์นี้ภายใน %d นาที:\n%s\n\nหากคุณไม่ได้เป็นผู้ขอ สามารถเพิกเฉยอีเมลนี้ได้",
+		user.Email, int(passwordResetTTL.Minutes()), link)
+	if err := u.mailer.Send(user.Email, "รีเซ็ตรหัสผ่าน", body); err != nil {
+		log.Printf("failed to send reset password mail to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+func (u *userUsecase) ResetPassword(req *request.ResetPasswordRequest) error {
+	if req.Token == "" {
+		return fmt.Errorf("invalid or expired reset token")
+	}
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hash.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userID, err := u.userRepo.ResetPassword(hash.HashToken(req.Token), hashedPassword)
+	if err != nil {
+		return err
+	}
+	role := ""
+	if user, err := u.userRepo.GetUserByID(userID); err == nil {
+		role = user.Role
+	}
+	u.audit.record(selfClaims(userID, role), "user.reset_password", "user", userID, nil, nil)
+
+	// รหัสผ่านเปลี่ยนแล้ว session เดิมทั้งหมดต้องเข้าสู่ระบบใหม่
+	return u.revokeAllSessions(userID)
 }
 
 func (u *userUsecase) getTeacherByUserID(userID uint) (*response.TeacherByClaimsResponse, error) {
@@ -221,7 +526,12 @@ func (u *userUsecase) UpdateTeacherByID(req *request.RegisterTeacher, claims map
 		Phone:     req.Phone,
 		Code:      req.Code,
 	}
-	return u.userRepo.UpdateTeacherByID(&teacher)
+	before, _, _ := u.userRepo.GetTeacherByID(userID)
+	if err := u.userRepo.UpdateTeacherByID(&teacher); err != nil {
+		return err
+	}
+	u.audit.record(claims, "teacher.update", "teacher", userID, teacherAudit(before), teacherAudit(&teacher))
+	return nil
 }
 
 func (u *userUsecase) UpdateStudentByID(req *request.RegisterStudent, claims map[string]interface{}) error {
@@ -241,47 +551,86 @@ func (u *userUsecase) UpdateStudentByID(req *request.RegisterStudent, claims map
 		Year:      req.Year,
 		BranchId:  req.BranchId,
 	}
-	return u.userRepo.UpdateStudentByID(&student)
+	before, _ := u.userRepo.GetStudentByID(userID)
+	if err := u.userRepo.UpdateStudentByID(&student); err != nil {
+		return err
+	}
+	u.audit.record(claims, "student.update", "student", userID, studentAudit(before), studentAudit(&student))
+	return nil
 }
 
-func (u *userUsecase) UpdateRoleByID(userID uint, role string) error {
+func (u *userUsecase) UpdateRoleByID(userID uint, role string, claims map[string]interface{}) error {
 	if role == "admin" || role == "student" || role == "teacher" {
-		return u.userRepo.UpdateRoleByID(userID, role)
+		var before interface{}
+		if user, err := u.userRepo.GetUserByID(userID); err == nil {
+			before = map[string]interface{}{"role": user.Role}
+		}
+		if err := u.userRepo.UpdateRoleByID(userID, role); err != nil {
+			return err
+		}
+		u.audit.record(claims, "user.update_role", "user", userID, before, map[string]interface{}{"role": role})
+		// token เดิมยังมี role เก่าอยู่ จึงต้องให้เข้าสู่ระบบใหม่
+		return u.revokeAllSessions(userID)
 	}
 	return fmt.Errorf("incorrect role")
 }
 
-func (u *userUsecase) SendEvent(year uint, claims map[string]interface{}) error {
+// คืน evaluation คู่กับ error เฉพาะเมื่อไม่ผ่านเกณฑ์หรือสถานะปัจจุบันส่งตรวจไม่ได้ (ความผิดของผู้ส่ง)
+// ข้อผิดพลาดของระบบ/ฐานข้อมูลคืน evaluation เป็น nil
+func (u *userUsecase) SendEvent(year uint, claims map[string]interface{}) (*response.RuleEvaluation, error) {
 	// ดึง user_id จาก claims
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
-		return fmt.Errorf("invalid user_id in claims")
+		return nil, fmt.Errorf("invalid user_id in claims")
 	}
 	userID := uint(userIDFloat)
 
-	// คำนวณชั่วโมงจาก EventInside และ EventOutside
-	outsideHour, insideHour, err := u.userRepo.GetTotalWorkingHours(userID, year)
+	// ตรวจชั่วโมงกิจกรรมตามเกณฑ์ของคณะ/สาขา/ปีการศึกษา
+	evaluation, err := evaluateActivityRule(u.ruleRepo, u.userRepo, userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	if !evaluation.Passed {
+		return evaluation, fmt.Errorf("activity requirements not met: inside=%d, outside=%d", evaluation.InsideHour, evaluation.OutsideHour)
+	}
+
+	// ดึง superUserID ของ student
+	superUserID, err := u.userRepo.GetSuperUserForStudent(userID)
 	if err != nil {
-		return fmt.Errorf("failed to get total working hours: %v", err)
+		return nil, fmt.Errorf("failed to get super user: %v", err)
+	}
+	if superUserID == nil {
+		return nil, fmt.Errorf("faculty has no super user assigned")
 	}
 
-	// ตรวจสอบชั่วโมง
-	if insideHour >= 18 && (outsideHour + insideHour) >= 36 {
-		// ดึง superUserID ของ student
-		superUserID, err := u.userRepo.GetSuperUserForStudent(userID)
-		if err != nil {
-			return fmt.Errorf("failed to get super user: %v", err)
+	done, err := u.userRepo.GetDone(userID, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dones: %v", err)
+	}
+	// สร้าง Dones
+	if done == nil {
+		if err := u.userRepo.CreateDones(userID, year, *superUserID); err != nil {
+			return nil, fmt.Errorf("failed to create dones: %v", err)
 		}
-		
-		// สร้าง Dones
-		err = u.userRepo.CreateDones(userID, year, *superUserID)
-		if err != nil {
-			return fmt.Errorf("failed to create dones: %v", err)
+		u.audit.record(claims, "done.submit", "done", doneTarget(userID, year), nil,
+			map[string]interface{}{"year": year, "certifier": *superUserID, "state": entity.StateEvidenceSubmitted})
+		return evaluation, nil
+	}
+	// ส่งตรวจซ้ำได้ระหว่างรอตรวจหรือเมื่อถูกขอให้ส่งใหม่
+	transition, err := newTransition(entity.ReviewKindDone, year, userID, done.State, entity.StateEvidenceSubmitted, userID, "")
+	if err != nil {
+		return evaluation, err
+	}
+	audit := u.audit.entry(claims, "done.submit", "done", doneTarget(userID, year), doneAudit(done),
+		map[string]interface{}{"year": year, "certifier": *superUserID, "state": transition.ToState})
+	if err := u.userRepo.ResubmitDone(transition, *superUserID, audit); err != nil {
+		if errors.Is(err, repository.ErrStateChanged) {
+			return evaluation, err
 		}
-		return nil
+		return nil, fmt.Errorf("failed to resubmit dones: %w", err)
 	}
-
-	return fmt.Errorf("insufficient working hours: inside=%d, outside=%d", insideHour, outsideHour)
+	return evaluation, nil
 }
 
 func (u *userUsecase) GetStudentsAndYearsByCertifier(claims map[string]interface{}) ([]response.StudentYear,error){
@@ -299,6 +648,30 @@ func (u *userUsecase) GetStudentsAndYearsByCertifier(claims map[string]interface
 	return result, nil
 }
 
-func (u *userUsecase) UpdateStatusDones(certifierID uint, userID uint, status bool, comment string) error {
-	return u.userRepo.UpdateStatusDones(certifierID, userID, status, comment)
+// Done หนึ่งแถวระบุด้วยนักศึกษาและปีการศึกษา
+func doneTarget(userID uint, year uint) string {
+	return fmt.Sprintf("%d/%d", userID, year)
+}
+
+func (u *userUsecase) UpdateStatusDones(certifierID uint, userID uint, state string, comment string, claims map[string]interface{}) error {
+	decision, err := parseReviewDecision(state, comment)
+	if err != nil {
+		return err
+	}
+	done, err := u.userRepo.GetDoneByCertifier(certifierID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get dones: %w", err)
+	}
+	if done == nil {
+		return fmt.Errorf("submission not found")
+	}
+	transition, err := newReview(entity.ReviewKindDone, done.Year, userID, done.State, decision, certifierID, comment)
+	if err != nil {
+		return err
+	}
+	after := *done
+	after.State = transition.ToState
+	after.Comment = comment
+	audit := u.audit.entry(claims, "done.review", "done", doneTarget(userID, done.Year), doneAudit(done), doneAudit(&after))
+	return u.userRepo.UpdateDoneState(transition, audit)
 }
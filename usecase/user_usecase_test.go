@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"fmt"
+	"go-clean-arch/repository"
+	"go-clean-arch/structure/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(to string, subject string, body string) error {
+	return fmt.Errorf("smtp unavailable")
+}
+
+// TestForgotPassword tests that the response never reveals whether an email exists
+func TestForgotPassword(t *testing.T) {
+	u, db := newImportUsecase(t)
+	assert.NoError(t, db.AutoMigrate(&entity.PasswordReset{}))
+	u.mailer = failingMailer{}
+	u.resetURL = "http://localhost/reset"
+
+	t.Run("Mail failure for an existing email is not reported", func(t *testing.T) {
+		assert.NoError(t, u.ForgotPassword("old@example.com"))
+		var count int64
+		assert.NoError(t, db.Model(&entity.PasswordReset{}).Where("user_id = ?", 1).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		assert.NoError(t, u.ForgotPassword("nobody@example.com"))
+	})
+}
+
+// TestSendEventErrors tests that only rule failures come back with an evaluation
+func TestSendEventErrors(t *testing.T) {
+	db := newStatsDB(t)
+	assert.NoError(t, db.AutoMigrate(&entity.User{}, &entity.Teacher{}))
+	// นักศึกษา 301 อยู่คณะ 2 ซึ่งไม่มีชั่วโมงขั้นต่ำ ส่วนคณะ 1 ใช้เกณฑ์เริ่มต้น
+	faculty := uint(2)
+	assert.NoError(t, db.Omit("Faculty", "Branch").Create(&entity.ActivityRule{FacultyID: &faculty, RequiredCategories: "[]"}).Error)
+	u := &userUsecase{userRepo: repository.NewUserRepository(db), ruleRepo: repository.NewRuleRepository(db)}
+	student := func(userID uint) map[string]interface{} {
+		return map[string]interface{}{"user_id": float64(userID), "role": "student"}
+	}
+
+	t.Run("Unmet requirements return the evaluation", func(t *testing.T) {
+		evaluation, err := u.SendEvent(2567, student(102))
+		assert.Error(t, err)
+		if assert.NotNil(t, evaluation) {
+			assert.False(t, evaluation.Passed)
+		}
+	})
+
+	t.Run("Database errors return no evaluation", func(t *testing.T) {
+		assert.NoError(t, db.Migrator().DropTable(&entity.Done{}))
+		evaluation, err := u.SendEvent(2567, student(301))
+		assert.ErrorContains(t, err, "failed to get dones")
+		assert.Nil(t, evaluation)
+	})
+}